@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -11,8 +12,14 @@ import (
 	"time"
 
 	"streamshort/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
+// naming matches GORM's default naming so generated tables line up with AutoMigrate
+var naming = schema.NamingStrategy{}
+
 type SimpleMigrationData struct {
 	Version     string
 	Description string
@@ -21,8 +28,22 @@ type SimpleMigrationData struct {
 }
 
 type SimpleTableData struct {
+	Name    string
+	Fields  []SimpleFieldData
+	Indexes []SimpleIndexData
+}
+
+type SimpleIndexData struct {
+	Name    string
+	Table   string
+	Columns string
+	Unique  bool
+}
+
+// simpleIndexTag is one field's membership in an index declared in its GORM tag
+type simpleIndexTag struct {
 	Name   string
-	Fields []SimpleFieldData
+	Unique bool
 }
 
 type SimpleFieldData struct {
@@ -41,17 +62,11 @@ func generateSimpleMigration(version, description string) {
 	// Generate simple SQL migration file
 	filename := filepath.Join(migrationsDir, fmt.Sprintf("%s_simple.sql", version))
 
-	// Create template with custom functions
-	tmpl := template.New("simple").Funcs(template.FuncMap{
-		"add": func(a, b int) int { return a + b },
-	})
-	tmpl = template.Must(tmpl.Parse(simpleTemplate))
-
 	// Create migration data
 	data := SimpleMigrationData{
 		Version:     version,
 		Description: description,
-		Created:     time.Now().Format("2025-08-16"),
+		Created:     time.Now().Format("2006-01-02"),
 		Tables:      extractSimpleTables(),
 	}
 
@@ -61,13 +76,22 @@ func generateSimpleMigration(version, description string) {
 	}
 	defer file.Close()
 
-	if err := tmpl.Execute(file, data); err != nil {
+	if err := renderSimpleMigration(file, data); err != nil {
 		log.Fatal("Failed to execute SQL template:", err)
 	}
 
 	fmt.Printf("📄 Generated simple SQL migration: %s\n", filename)
 }
 
+// renderSimpleMigration writes the SQL migration for data to w
+func renderSimpleMigration(w io.Writer, data SimpleMigrationData) error {
+	tmpl := template.New("simple").Funcs(template.FuncMap{
+		"add": func(a, b int) int { return a + b },
+	})
+	tmpl = template.Must(tmpl.Parse(simpleTemplate))
+	return tmpl.Execute(w, data)
+}
+
 func extractSimpleTables() []SimpleTableData {
 	var tables []SimpleTableData
 
@@ -79,6 +103,10 @@ func extractSimpleTables() []SimpleTableData {
 		&models.CreatorProfile{},
 		&models.PayoutDetails{},
 		&models.CreatorAnalytics{},
+		&models.EpisodeLike{},
+		&models.EpisodeRating{},
+		&models.EpisodeComment{},
+		&models.WatchProgress{},
 	}
 
 	for _, model := range models {
@@ -95,14 +123,19 @@ func extractSimpleTableFromModel(model interface{}) SimpleTableData {
 	t := reflect.TypeOf(model).Elem()
 
 	// Get table name
-	tableName := strings.ToLower(t.Name()) + "s"
+	tableName := naming.TableName(t.Name())
+	if tabler, ok := model.(schema.Tabler); ok {
+		tableName = tabler.TableName()
+	}
 
 	var fields []SimpleFieldData
+	var indexes []SimpleIndexData
+	indexPos := make(map[string]int)
 
 	// Extract fields
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if !field.IsExported() {
+		if !field.IsExported() || isSimpleAssociation(field.Type) {
 			continue
 		}
 
@@ -113,21 +146,54 @@ func extractSimpleTableFromModel(model interface{}) SimpleTableData {
 
 		fieldName := getSimpleFieldName(field)
 		fieldType := getSimpleFieldType(field, gormTag)
-		constraints := getSimpleConstraints(gormTag)
+		constraints, indexTags := getSimpleConstraints(gormTag)
 
 		fields = append(fields, SimpleFieldData{
 			Name:        fieldName,
 			Type:        fieldType,
 			Constraints: constraints,
 		})
+
+		// Fields sharing an index name form one composite index, in field order
+		for _, idx := range indexTags {
+			name := idx.Name
+			if name == "" {
+				name = fmt.Sprintf("idx_%s_%s", tableName, fieldName)
+			}
+			if pos, ok := indexPos[name]; ok {
+				indexes[pos].Columns += ", " + fieldName
+				indexes[pos].Unique = indexes[pos].Unique || idx.Unique
+				continue
+			}
+			indexPos[name] = len(indexes)
+			indexes = append(indexes, SimpleIndexData{
+				Name:    name,
+				Table:   tableName,
+				Columns: fieldName,
+				Unique:  idx.Unique,
+			})
+		}
 	}
 
 	return SimpleTableData{
-		Name:   tableName,
-		Fields: fields,
+		Name:    tableName,
+		Fields:  fields,
+		Indexes: indexes,
 	}
 }
 
+// isSimpleAssociation reports whether a field is a GORM relation (e.g. a
+// preloaded *User) rather than a column
+func isSimpleAssociation(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	return t != reflect.TypeOf(time.Time{}) && t != reflect.TypeOf(gorm.DeletedAt{})
+}
+
 func getSimpleFieldName(field reflect.StructField) string {
 	gormTag := field.Tag.Get("gorm")
 
@@ -136,11 +202,11 @@ func getSimpleFieldName(field reflect.StructField) string {
 		parts := strings.Split(gormTag, "column:")
 		if len(parts) > 1 {
 			columnPart := strings.Split(parts[1], " ")[0]
-			return strings.TrimSpace(columnPart)
+			return strings.TrimSpace(strings.Split(columnPart, ";")[0])
 		}
 	}
 
-	return strings.ToLower(field.Name)
+	return naming.ColumnName("", field.Name)
 }
 
 func getSimpleFieldType(field reflect.StructField, gormTag string) string {
@@ -178,7 +244,7 @@ func getSimpleFieldType(field reflect.StructField, gormTag string) string {
 	case reflect.Bool:
 		return "BOOLEAN"
 	case reflect.Struct:
-		if field.Type == reflect.TypeOf(time.Time{}) {
+		if field.Type == reflect.TypeOf(time.Time{}) || field.Type == reflect.TypeOf(gorm.DeletedAt{}) {
 			return "TIMESTAMP WITH TIME ZONE"
 		}
 		return "TEXT"
@@ -187,8 +253,13 @@ func getSimpleFieldType(field reflect.StructField, gormTag string) string {
 	}
 }
 
-func getSimpleConstraints(gormTag string) string {
+// getSimpleConstraints returns the column constraints declared in a GORM tag
+// along with the indexes the column belongs to. Named indexes
+// (index:NAME[,unique] or uniqueIndex:NAME) may span several columns, so they
+// are emitted as CREATE INDEX statements rather than column-level UNIQUE.
+func getSimpleConstraints(gormTag string) (string, []simpleIndexTag) {
 	var constraints []string
+	var indexes []simpleIndexTag
 
 	if strings.Contains(gormTag, "primaryKey") {
 		constraints = append(constraints, "PRIMARY KEY")
@@ -198,8 +269,18 @@ func getSimpleConstraints(gormTag string) string {
 		constraints = append(constraints, "NOT NULL")
 	}
 
-	if strings.Contains(gormTag, "unique") {
-		constraints = append(constraints, "UNIQUE")
+	for _, setting := range strings.Split(gormTag, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(setting), ":")
+		switch key {
+		case "unique":
+			constraints = append(constraints, "UNIQUE")
+		case "index", "uniqueIndex":
+			name, options, _ := strings.Cut(value, ",")
+			indexes = append(indexes, simpleIndexTag{
+				Name:   strings.TrimSpace(name),
+				Unique: key == "uniqueIndex" || strings.Contains(options, "unique"),
+			})
+		}
 	}
 
 	if strings.Contains(gormTag, "default:") {
@@ -215,7 +296,7 @@ func getSimpleConstraints(gormTag string) string {
 		}
 	}
 
-	return strings.Join(constraints, " ")
+	return strings.Join(constraints, " "), indexes
 }
 
 const simpleTemplate = `-- Migration: {{.Version}}_simple.sql
@@ -229,9 +310,10 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 {{range .Tables}}
 -- Create {{.Name}} table
 CREATE TABLE IF NOT EXISTS {{.Name}} (
-{{range .Fields}}    {{.Name}} {{.Type}}{{if .Constraints}} {{.Constraints}}{{end}},
-{{end}}
-);
+{{$count := len .Fields}}{{range $i, $f := .Fields}}    {{$f.Name}} {{$f.Type}}{{if $f.Constraints}} {{$f.Constraints}}{{end}}{{if lt (add $i 1) $count}},{{end}}
+{{end}});
+{{range .Indexes}}
+CREATE {{if .Unique}}UNIQUE {{end}}INDEX IF NOT EXISTS {{.Name}} ON {{.Table}} ({{.Columns}});{{end}}
 
 {{end}}
 
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+// userMigration renders the simple migration for the user model alone
+func userMigration(t *testing.T) string {
+	t.Helper()
+	var sql strings.Builder
+	err := renderSimpleMigration(&sql, SimpleMigrationData{
+		Version:     "test",
+		Description: "user model",
+		Created:     "2026-01-01",
+		Tables:      []SimpleTableData{extractSimpleTableFromModel(&models.User{})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sql.String()
+}
+
+func TestSimpleMigrationForUserModel(t *testing.T) {
+	sql := userMigration(t)
+	for _, want := range []string{
+		"CREATE TABLE IF NOT EXISTS users (",
+		"id uuid PRIMARY KEY DEFAULT gen_random_uuid(),",
+		"role varchar(20) NOT NULL DEFAULT 'user',",
+		"deleted_at TIMESTAMP WITH TIME ZONE\n);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone ON users (phone);",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("migration is missing %q:\n%s", want, sql)
+		}
+	}
+	if strings.Contains(sql, ",\n)") {
+		t.Errorf("migration has a trailing comma before the closing parenthesis:\n%s", sql)
+	}
+}
+
+func TestSimpleMigrationAppliesToPostgres(t *testing.T) {
+	db := testdb.Open(t)
+
+	// Apply into a scratch schema so the table is really created, not skipped
+	// because AutoMigrate already made one
+	schemaName := fmt.Sprintf("generate_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schemaName).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("SET LOCAL search_path TO " + schemaName + ", public").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(userMigration(t)).Error; err != nil {
+		t.Fatalf("apply migration: %v", err)
+	}
+
+	var columns []string
+	if err := db.Raw("SELECT column_name FROM information_schema.columns WHERE table_schema = ? AND table_name = 'users'", schemaName).
+		Scan(&columns).Error; err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(columns)
+	want := []string{"created_at", "deleted_at", "id", "phone", "role", "updated_at"}
+	if strings.Join(columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+
+	// Defaults from the model apply to a bare insert
+	var row struct {
+		ID   string
+		Role string
+	}
+	if err := db.Raw("INSERT INTO " + schemaName + ".users (phone, created_at, updated_at) VALUES ('+919800000067', now(), now()) RETURNING id, role").
+		Scan(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.ID == "" || row.Role != models.RoleUser {
+		t.Errorf("inserted row = %+v, want a generated id and role %q", row, models.RoleUser)
+	}
+
+	var unique bool
+	if err := db.Raw("SELECT indisunique FROM pg_index JOIN pg_class ON pg_class.oid = pg_index.indexrelid "+
+		"JOIN pg_namespace ON pg_namespace.oid = pg_class.relnamespace WHERE relname = 'idx_users_phone' AND nspname = ?", schemaName).
+		Scan(&unique).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !unique {
+		t.Error("idx_users_phone is not unique")
+	}
+}
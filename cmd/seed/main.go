@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"strings"
@@ -14,140 +15,249 @@ import (
 )
 
 func main() {
+	count := flag.Int("count", 2, "number of series to create")
+	episodes := flag.Int("episodes", 2, "number of episodes per series")
+	creators := flag.Int("creators", 1, "number of creators the series are spread across")
+	reset := flag.Bool("reset", false, "delete previously seeded data before seeding")
+	flag.Parse()
+
 	// Load env files if present
 	_ = godotenv.Load(".env.local", ".env")
 
 	db := config.InitDB()
 
-	if err := seed(db); err != nil {
+	opts := seedOptions{
+		Series:   *count,
+		Episodes: *episodes,
+		Creators: *creators,
+		Reset:    *reset,
+		// Without flags, seeding only fills an empty database so it is safe to rerun
+		SkipIfSeeded: flag.NFlag() == 0,
+	}
+	if opts.Series < 1 || opts.Episodes < 1 || opts.Creators < 1 {
+		log.Fatal("-count, -episodes and -creators must be at least 1")
+	}
+
+	if opts.Reset {
+		if err := resetSeedData(db); err != nil {
+			log.Fatalf("reset failed: %v", err)
+		}
+	}
+
+	if err := seed(db, opts); err != nil {
 		log.Fatalf("seeding failed: %v", err)
 	}
 
 	log.Println("Seeding completed.")
 }
 
-func seed(db *gorm.DB) error {
-	// If we already have series, assume seeded
-	var existingSeriesCount int64
-	if err := db.Model(&models.Series{}).Count(&existingSeriesCount).Error; err != nil {
-		return err
-	}
-	if existingSeriesCount > 0 {
-		log.Printf("Series already present (%d). Skipping seed.", existingSeriesCount)
-		return nil
-	}
+type seedOptions struct {
+	Series       int
+	Episodes     int
+	Creators     int
+	Reset        bool
+	SkipIfSeeded bool
+}
 
-	// 1) Ensure a user exists
-	const seedPhone = "+910000000001"
-	var user models.User
-	if err := db.Where("phone = ?", seedPhone).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			user = models.User{Phone: seedPhone}
-			if err := db.Create(&user).Error; err != nil {
-				return fmt.Errorf("create user: %w", err)
-			}
-			log.Printf("Created user %s", user.ID)
-		} else {
+// seedPhonePrefix marks seeded users. Indian mobile numbers never start with 0,
+// so the prefix cannot collide with a real account.
+const seedPhonePrefix = "+910"
+
+// seedPhone returns the phone number of the i-th seeded user (0-based)
+func seedPhone(i int) string {
+	return fmt.Sprintf("%s%09d", seedPhonePrefix, i+1)
+}
+
+// Values cycled through to give generated series some variety for search and filters
+var (
+	seedTitleAdjectives = []string{"Midnight", "Quick", "Hidden", "Everyday", "Lost", "Bright", "Silent", "Wild"}
+	seedTitleTopics     = []string{"Recipes", "Mysteries", "Romance", "Comedy Sketches", "Travel Diaries", "Startup Stories", "Fitness Tips", "Folk Tales"}
+	seedLanguages       = []string{"en", "hi", "ta", "te", "bn", "mr"}
+	seedTags            = [][]string{{"education", "howto"}, {"cooking", "lifestyle"}, {"drama", "romance"}, {"comedy"}, {"thriller", "mystery"}, {"travel", "lifestyle"}}
+)
+
+func seed(db *gorm.DB, opts seedOptions) error {
+	// If we already have series, assume seeded
+	if opts.SkipIfSeeded {
+		var existingSeriesCount int64
+		if err := db.Model(&models.Series{}).Count(&existingSeriesCount).Error; err != nil {
 			return err
 		}
-	} else {
-		log.Printf("Found user %s", user.ID)
+		if existingSeriesCount > 0 {
+			log.Printf("Series already present (%d). Skipping seed.", existingSeriesCount)
+			return nil
+		}
 	}
 
-	// 2) Ensure a creator profile exists
-	var creator models.CreatorProfile
-	if err := db.Where("user_id = ?", user.ID).First(&creator).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			creator = models.CreatorProfile{
-				UserID:          user.ID,
-				DisplayName:     "Demo Studio",
-				Bio:             "We make short-form series for demos",
-				KYCDocumentPath: "s3://uploads/kyc/demo_kyc_doc.jpg",
-				KYCStatus:       "verified",
-			}
-			if err := db.Create(&creator).Error; err != nil {
-				return fmt.Errorf("create creator profile: %w", err)
-			}
-			log.Printf("Created creator profile %s", creator.ID)
-		} else {
+	// 1) Ensure the users and their creator profiles exist
+	creators := make([]models.CreatorProfile, opts.Creators)
+	for i := range creators {
+		creator, err := ensureCreator(db, i)
+		if err != nil {
 			return err
 		}
-	} else {
-		log.Printf("Found creator profile %s", creator.ID)
+		creators[i] = creator
 	}
 
-	// 3) Create a couple of series
-	seriesList := []models.Series{
-		{
-			CreatorID:    creator.ID,
-			Title:        "Getting Started with StreamShort",
-			Synopsis:     "A walkthrough series covering platform basics",
-			Language:     "en",
+	// 2) Create the series, spread evenly across creators
+	seriesList := make([]models.Series, opts.Series)
+	for i := range seriesList {
+		s := models.Series{
+			CreatorID:    creators[i%len(creators)].ID,
+			Title:        fmt.Sprintf("%s %s #%d", seedTitleAdjectives[i%len(seedTitleAdjectives)], seedTitleTopics[(i/len(seedTitleAdjectives))%len(seedTitleTopics)], i+1),
+			Synopsis:     fmt.Sprintf("Seeded short-form series number %d", i+1),
+			Language:     seedLanguages[i%len(seedLanguages)],
 			PriceType:    "free",
-			ThumbnailURL: strPtr("https://example.com/thumbs/series1.jpg"),
+			ThumbnailURL: strPtr(fmt.Sprintf("https://example.com/thumbs/series%d.jpg", i+1)),
 			Status:       "published",
-		},
-		{
-			CreatorID:    creator.ID,
-			Title:        "Culinary Shorts: 5-Min Recipes",
-			Synopsis:     "Quick recipes for busy folks",
-			Language:     "en",
-			PriceType:    "subscription",
-			PriceAmount:  float64Ptr(2.99),
-			ThumbnailURL: strPtr("https://example.com/thumbs/series2.jpg"),
-			Status:       "draft",
-		},
-	}
-
-	for i := range seriesList {
-		if err := db.Create(&seriesList[i]).Error; err != nil {
-			return fmt.Errorf("create series %d: %w", i+1, err)
 		}
-		log.Printf("Created series %s - %s", seriesList[i].ID, seriesList[i].Title)
+		if i%2 == 1 {
+			s.PriceType = "subscription"
+			s.PriceAmount = float64Ptr(2.99)
+		}
+		// Leave some drafts so status filters have something to exclude
+		if i%5 == 1 {
+			s.Status = "draft"
+		}
+		seriesList[i] = s
+	}
+	if err := db.CreateInBatches(&seriesList, 100).Error; err != nil {
+		return fmt.Errorf("create series: %w", err)
 	}
+	log.Printf("Created %d series", len(seriesList))
 
 	// Manually set category_tags via array literal to avoid driver array encoding issues
-	if err := setTextArray(db, "series", "category_tags", seriesList[0].ID, []string{"education", "howto"}); err != nil {
-		return fmt.Errorf("set category_tags for series1: %w", err)
-	}
-	if err := setTextArray(db, "series", "category_tags", seriesList[1].ID, []string{"cooking", "lifestyle"}); err != nil {
-		return fmt.Errorf("set category_tags for series2: %w", err)
+	for i, s := range seriesList {
+		if err := setTextArray(db, "series", "category_tags", s.ID, seedTags[i%len(seedTags)]); err != nil {
+			return fmt.Errorf("set category_tags for series %s: %w", s.ID, err)
+		}
 	}
 
-	// 4) Create episodes for each series
+	// 3) Create episodes for each series. The last one is ready but not yet published.
 	for _, s := range seriesList {
-		episodes := []models.Episode{
-			{
+		episodes := make([]models.Episode, opts.Episodes)
+		for j := range episodes {
+			episodes[j] = models.Episode{
 				SeriesID:        s.ID,
-				Title:           fmt.Sprintf("%s - Episode 1", s.Title),
-				EpisodeNumber:   1,
-				DurationSeconds: 300,
-				HLSManifestURL:  strPtr("https://cdn.example.com/hls/ep1.m3u8"),
-				ThumbURL:        strPtr("https://example.com/thumbs/ep1.jpg"),
+				Title:           fmt.Sprintf("%s - Episode %d", s.Title, j+1),
+				EpisodeNumber:   j + 1,
+				DurationSeconds: 300 + 60*(j%5),
+				HLSManifestURL:  strPtr(fmt.Sprintf("https://cdn.example.com/hls/ep%d.m3u8", j+1)),
+				ThumbURL:        strPtr(fmt.Sprintf("https://example.com/thumbs/ep%d.jpg", j+1)),
 				Status:          "published",
-				PublishedAt:     timePtr(time.Now().Add(-48 * time.Hour)),
-			},
-			{
-				SeriesID:        s.ID,
-				Title:           fmt.Sprintf("%s - Episode 2", s.Title),
-				EpisodeNumber:   2,
-				DurationSeconds: 360,
-				HLSManifestURL:  strPtr("https://cdn.example.com/hls/ep2.m3u8"),
-				ThumbURL:        strPtr("https://example.com/thumbs/ep2.jpg"),
-				Status:          "ready",
-			},
-		}
-		for j := range episodes {
-			if err := db.Create(&episodes[j]).Error; err != nil {
-				return fmt.Errorf("create episode %d for series %s: %w", j+1, s.ID, err)
+				PublishedAt:     timePtr(time.Now().Add(-time.Duration(opts.Episodes-j) * 24 * time.Hour)),
 			}
-			log.Printf("  Created episode %s - %s", episodes[j].ID, episodes[j].Title)
+		}
+		if len(episodes) > 1 {
+			episodes[len(episodes)-1].Status = "ready"
+			episodes[len(episodes)-1].PublishedAt = nil
+		}
+		if err := db.CreateInBatches(&episodes, 100).Error; err != nil {
+			return fmt.Errorf("create episodes for series %s: %w", s.ID, err)
 		}
 	}
+	log.Printf("Created %d episodes", len(seriesList)*opts.Episodes)
 
 	return nil
 }
 
+// ensureCreator finds or creates the i-th seeded user and their verified creator profile
+func ensureCreator(db *gorm.DB, i int) (models.CreatorProfile, error) {
+	phone := seedPhone(i)
+	var user models.User
+	if err := db.Where("phone = ?", phone).First(&user).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return models.CreatorProfile{}, err
+		}
+		user = models.User{Phone: phone}
+		if err := db.Create(&user).Error; err != nil {
+			return models.CreatorProfile{}, fmt.Errorf("create user %s: %w", phone, err)
+		}
+		log.Printf("Created user %s", user.ID)
+	}
+
+	var creator models.CreatorProfile
+	if err := db.Where("user_id = ?", user.ID).First(&creator).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return models.CreatorProfile{}, err
+		}
+		creator = models.CreatorProfile{
+			UserID:          user.ID,
+			DisplayName:     "Demo Studio",
+			Bio:             "We make short-form series for demos",
+			KYCDocumentPath: fmt.Sprintf("s3://uploads/kyc/demo_kyc_doc_%d.jpg", i+1),
+			KYCStatus:       "verified",
+		}
+		if i > 0 {
+			creator.DisplayName = fmt.Sprintf("Demo Studio %d", i+1)
+		}
+		if err := db.Create(&creator).Error; err != nil {
+			return models.CreatorProfile{}, fmt.Errorf("create creator profile for %s: %w", phone, err)
+		}
+		log.Printf("Created creator profile %s", creator.ID)
+	}
+	return creator, nil
+}
+
+// resetSeedData permanently deletes seeded users and everything hanging off
+// their creator profiles, including rows other users created against seeded content
+func resetSeedData(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var userIDs, creatorIDs, seriesIDs, episodeIDs []string
+		if err := tx.Unscoped().Model(&models.User{}).Where("phone LIKE ?", seedPhonePrefix+"%").Pluck("id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			log.Println("No seeded data to reset.")
+			return nil
+		}
+		if err := tx.Unscoped().Model(&models.CreatorProfile{}).Where("user_id IN ?", userIDs).Pluck("id", &creatorIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Series{}).Where("creator_id IN ?", creatorIDs).Pluck("id", &seriesIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Episode{}).Where("series_id IN ?", seriesIDs).Pluck("id", &episodeIDs).Error; err != nil {
+			return err
+		}
+
+		// Children are deleted before the rows their foreign keys point at
+		steps := []struct {
+			query *gorm.DB
+			model interface{}
+		}{
+			{tx.Where("episode_id IN ?", episodeIDs), &models.TranscodingJob{}},
+			{tx.Where("episode_id IN ?", episodeIDs), &models.EpisodeCaption{}},
+			{tx.Where("episode_id IN ?", episodeIDs), &models.EpisodeLike{}},
+			{tx.Where("episode_id IN ?", episodeIDs), &models.EpisodeRating{}},
+			{tx.Where("episode_id IN ?", episodeIDs), &models.EpisodeComment{}},
+			{tx.Where("episode_id IN ?", episodeIDs), &models.WatchProgress{}},
+			{tx.Where("episode_id IN ?", episodeIDs), &models.EpisodeView{}},
+			{tx.Where("series_id IN ?", seriesIDs), &models.Notification{}},
+			{tx.Where("series_id IN ?", seriesIDs), &models.UserFavorite{}},
+			{tx.Where("series_id IN ?", seriesIDs), &models.Subscription{}},
+			{tx.Where("series_id IN ?", seriesIDs), &models.Purchase{}},
+			{tx.Where("user_id IN ?", userIDs), &models.UploadRequest{}},
+			{tx.Where("series_id IN ?", seriesIDs), &models.Episode{}},
+			{tx.Where("id IN ?", seriesIDs), &models.Series{}},
+			{tx.Where("creator_id IN ?", creatorIDs), &models.CreatorFollow{}},
+			{tx.Where("creator_id IN ?", creatorIDs), &models.CreatorAnalytics{}},
+			{tx.Where("creator_id IN ?", creatorIDs), &models.PayoutDetails{}},
+			{tx.Where("id IN ?", creatorIDs), &models.CreatorProfile{}},
+			{tx.Where("user_id IN ?", userIDs), &models.RefreshToken{}},
+			{tx.Where("user_id IN ?", userIDs), &models.UserPreferences{}},
+			{tx.Where("id IN ?", userIDs), &models.User{}},
+		}
+		for _, step := range steps {
+			if err := step.query.Unscoped().Delete(step.model).Error; err != nil {
+				return fmt.Errorf("delete %T: %w", step.model, err)
+			}
+		}
+		log.Printf("Reset %d seeded users, %d series and %d episodes", len(userIDs), len(seriesIDs), len(episodeIDs))
+		return nil
+	})
+}
+
 func strPtr(s string) *string        { return &s }
 func float64Ptr(f float64) *float64  { return &f }
 func timePtr(t time.Time) *time.Time { return &t }
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"streamshort/models"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -25,34 +31,23 @@ func InitDB() *gorm.DB {
 		log.Println("Using default database URL. Set DATABASE_URL environment variable for production.")
 	}
 
-	// Ensure DSN has Neon-friendly flags
-	lower := strings.ToLower(dbURL)
-	if !strings.Contains(lower, "prefer_simple_protocol") {
-		if strings.Contains(dbURL, "?") {
-			dbURL += "&prefer_simple_protocol=true"
-		} else {
-			dbURL += "?prefer_simple_protocol=true"
-		}
-	}
-	if !strings.Contains(lower, "search_path=") {
-		if strings.Contains(dbURL, "?") {
-			dbURL += "&search_path=public"
-		} else {
-			dbURL += "?search_path=public"
-		}
-	}
-
-	// Configure GORM
-	config := &gorm.Config{
-		Logger:                                   logger.Default.LogMode(logger.Info),
-		DisableForeignKeyConstraintWhenMigrating: true,
+	db, err := Open(dbURL, logger.Default.LogMode(logger.Info))
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Connect to database
-	db, err := gorm.Open(postgres.Open(dbURL), config)
+	// Bound the pool: Neon allows few connections, and an unbounded pool fails
+	// with "too many connections" under load
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal("Failed to access database connection pool:", err)
 	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	log.Printf("Database pool: max_open=%d max_idle=%d max_lifetime=%s max_idle_time=%s",
+		cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBConnMaxLifetime, cfg.DBConnMaxIdleTime)
 
 	// Ensure required extensions exist
 	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pgcrypto;").Error; err != nil {
@@ -72,21 +67,7 @@ func InitDB() *gorm.DB {
 		log.Println("Running database auto-migration...")
 
 		// Migrate models one by one to handle errors gracefully
-		modelsToMigrate := []interface{}{
-			&models.User{},
-			&models.OTPTransaction{},
-			&models.RefreshToken{},
-			&models.CreatorProfile{},
-			&models.PayoutDetails{},
-			&models.CreatorAnalytics{},
-			&models.Series{},
-			&models.Episode{},
-			&models.UploadRequest{},
-			// Engagement models
-			&models.EpisodeLike{},
-			&models.EpisodeRating{},
-			&models.EpisodeComment{},
-		}
+		modelsToMigrate := MigratedModels()
 
 		for _, model := range modelsToMigrate {
 			if err := db.AutoMigrate(model); err != nil {
@@ -118,3 +99,92 @@ func InitDB() *gorm.DB {
 	log.Println("Database connected and auto-migrated successfully.")
 	return db
 }
+
+// Open connects to Postgres at dsn. Timestamps are written and scanned in
+// UTC whatever the host's time zone, so API responses never depend on
+// time.Local.
+func Open(dsn string, gormLogger logger.Interface) (*gorm.DB, error) {
+	connConfig, err := pgx.ParseConfig(withNeonFlags(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "timestamptz",
+			OID:   pgtype.TimestamptzOID,
+			Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+		})
+		return nil
+	}))
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                                   gormLogger,
+		DisableForeignKeyConstraintWhenMigrating: true,
+		// created_at/updated_at are always written in UTC, whatever the server TZ
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// withNeonFlags adds the Neon-friendly simple protocol and search_path
+// parameters to a DSN unless it already sets them
+func withNeonFlags(dsn string) string {
+	lower := strings.ToLower(dsn)
+	if !strings.Contains(lower, "prefer_simple_protocol") {
+		if strings.Contains(dsn, "?") {
+			dsn += "&prefer_simple_protocol=true"
+		} else {
+			dsn += "?prefer_simple_protocol=true"
+		}
+	}
+	if !strings.Contains(lower, "search_path=") {
+		if strings.Contains(dsn, "?") {
+			dsn += "&search_path=public"
+		} else {
+			dsn += "?search_path=public"
+		}
+	}
+	return dsn
+}
+
+// MigratedModels lists every model whose table AutoMigrate manages
+func MigratedModels() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.OTPTransaction{},
+		&models.RefreshToken{},
+		&models.UserPreferences{},
+		&models.CreatorProfile{},
+		&models.PayoutDetails{},
+		&models.CreatorPayout{},
+		&models.CreatorEarning{},
+		&models.CreatorAnalytics{},
+		&models.Series{},
+		&models.Episode{},
+		&models.UploadRequest{},
+		&models.TranscodingJob{},
+		&models.EpisodeCaption{},
+		&models.OrphanedObject{},
+		// Engagement models
+		&models.EpisodeLike{},
+		&models.EpisodeRating{},
+		&models.EpisodeComment{},
+		&models.WatchProgress{},
+		&models.EpisodeView{},
+		&models.UserFavorite{},
+		&models.CreatorFollow{},
+		&models.PlaybackSession{},
+		&models.ContentReport{},
+		// Payment models
+		&models.Subscription{},
+		&models.Purchase{},
+		&models.PaymentTransaction{},
+		&models.PaymentWebhook{},
+		&models.IdempotencyKey{},
+		&models.Notification{},
+	}
+}
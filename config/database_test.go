@@ -0,0 +1,55 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestTimestampsAreUTCWhateverTheHostZone(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	previous := time.Local
+	time.Local = kolkata
+	t.Cleanup(func() { time.Local = previous })
+
+	db := testdb.Open(t)
+
+	user := models.User{Phone: "+919800000087"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.CreatedAt.Location() != time.UTC {
+		t.Errorf("created_at written in %v, want UTC", user.CreatedAt.Location())
+	}
+
+	var loaded models.User
+	if err := db.First(&loaded, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CreatedAt.Location() != time.UTC {
+		t.Errorf("created_at scanned in %v, want UTC", loaded.CreatedAt.Location())
+	}
+	if !loaded.CreatedAt.Equal(user.CreatedAt.Truncate(time.Microsecond)) {
+		t.Errorf("created_at = %v, want %v", loaded.CreatedAt, user.CreatedAt)
+	}
+
+	body, err := json.Marshal(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rendered struct {
+		CreatedAt string `json:"created_at"`
+	}
+	if err := json.Unmarshal(body, &rendered); err != nil {
+		t.Fatal(err)
+	}
+	if got := rendered.CreatedAt; got[len(got)-1] != 'Z' {
+		t.Errorf("created_at rendered as %q, want a UTC (Z) timestamp", got)
+	}
+}
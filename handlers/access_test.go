@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestHasSeriesAccess(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	price := 49.0
+	paid := func(priceType string) func(*models.Series) {
+		return func(s *models.Series) {
+			s.PriceType = priceType
+			s.PriceAmount = &price
+		}
+	}
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		series   func(*models.Series)
+		purchase *models.Purchase
+		sub      *models.Subscription
+		want     bool
+	}{
+		{"free series", nil, nil, nil, true},
+		{"subscription series without a subscription", paid("subscription"), nil, nil, false},
+		{"active subscription", paid("subscription"), nil,
+			&models.Subscription{Status: models.SubscriptionStatusActive, ExpiresAt: &future}, true},
+		{"cancelled subscription within its period", paid("subscription"), nil,
+			&models.Subscription{Status: models.SubscriptionStatusCancelled, ExpiresAt: &future}, true},
+		{"active subscription past its expiry", paid("subscription"), nil,
+			&models.Subscription{Status: models.SubscriptionStatusActive, ExpiresAt: &past}, false},
+		{"expired subscription", paid("subscription"), nil,
+			&models.Subscription{Status: models.SubscriptionStatusExpired, ExpiresAt: &future}, false},
+		{"pending subscription", paid("subscription"), nil,
+			&models.Subscription{Status: models.SubscriptionStatusPending}, false},
+		{"one-time series without a purchase", paid("one_time"), nil, nil, false},
+		{"completed purchase", paid("one_time"),
+			&models.Purchase{Status: models.PurchaseStatusCompleted, CompletedAt: &now}, nil, true},
+		{"pending purchase", paid("one_time"), &models.Purchase{Status: models.PurchaseStatusPending}, nil, false},
+		{"refunded purchase", paid("one_time"), &models.Purchase{Status: models.PurchaseStatusRefunded}, nil, false},
+		{"purchase kept after a switch to subscription", paid("subscription"),
+			&models.Purchase{Status: models.PurchaseStatusCompleted, CompletedAt: &now}, nil, true},
+		{"subscription kept after a switch to one-time", paid("one_time"), nil,
+			&models.Subscription{Status: models.SubscriptionStatusActive, ExpiresAt: &future}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series := createTestSeries(t, db, creator.ID, tt.series)
+			user := createTestUser(t, db)
+			if tt.purchase != nil {
+				tt.purchase.UserID, tt.purchase.SeriesID, tt.purchase.Amount = user.ID, series.ID, price
+				if err := db.Create(tt.purchase).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.sub != nil {
+				tt.sub.UserID, tt.sub.SeriesID, tt.sub.Amount = user.ID, series.ID, price
+				if err := db.Create(tt.sub).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := hasSeriesAccess(context.Background(), db, user.ID, series)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("hasSeriesAccess = %v, want %v", got, tt.want)
+			}
+
+			// Another user's entitlement never grants access
+			if tt.series != nil {
+				other := createTestUser(t, db)
+				if got, err := hasSeriesAccess(context.Background(), db, other.ID, series); err != nil || got {
+					t.Errorf("other user access = %v, %v; want false", got, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"gorm.io/gorm"
+)
+
+// errAccountDeleted is returned when a login targets a deleted account
+var errAccountDeleted = errors.New("account deleted")
+
+// DeleteAccountRequest confirms the deletion with a fresh OTP sent to the
+// account's phone via POST /auth/otp/send
+type DeleteAccountRequest struct {
+	OTP   string `json:"otp" validate:"required"`
+	TxnID string `json:"txn_id"`
+}
+
+type DeleteAccountResponse struct {
+	DeletedAt time.Time `json:"deleted_at"`
+	// Deleted counts the affected rows per kind of data
+	Deleted map[string]int64 `json:"deleted"`
+}
+
+// DeleteAccount deletes the authenticated user's account along with their
+// creator profile and content, subscriptions, engagement and sessions, all
+// in one transaction. Rows are soft-deleted; payout records are kept for
+// accounting. The phone number stays reserved until the sweeper releases it
+// after the retention period.
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req DeleteAccountRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	var user models.User
+	if err := h.db.WithContext(r.Context()).Where("id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	deleted := make(map[string]int64)
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := h.consumeOTP(r.Context(), tx, user.Phone, req.TxnID, req.OTP); err != nil {
+			return err
+		}
+
+		var creatorIDs, seriesIDs []string
+		if err := tx.Model(&models.CreatorProfile{}).Where("user_id = ?", userID).Pluck("id", &creatorIDs).Error; err != nil {
+			return err
+		}
+		if len(creatorIDs) > 0 {
+			if err := tx.Model(&models.Series{}).Where("creator_id IN ?", creatorIDs).Pluck("id", &seriesIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		steps := []struct {
+			name  string
+			query *gorm.DB
+			model interface{}
+		}{
+			{"episodes", tx.Where("series_id IN ?", seriesIDs), &models.Episode{}},
+			{"series", tx.Where("id IN ?", seriesIDs), &models.Series{}},
+			{"payout_details", tx.Where("creator_id IN ?", creatorIDs), &models.PayoutDetails{}},
+			{"creator_profiles", tx.Where("id IN ?", creatorIDs), &models.CreatorProfile{}},
+			{"upload_requests", tx.Where("user_id = ?", userID), &models.UploadRequest{}},
+			{"subscriptions", tx.Where("user_id = ?", userID), &models.Subscription{}},
+			{"purchases", tx.Where("user_id = ?", userID), &models.Purchase{}},
+			{"episode_likes", tx.Where("user_id = ?", userID), &models.EpisodeLike{}},
+			{"episode_ratings", tx.Where("user_id = ?", userID), &models.EpisodeRating{}},
+			{"episode_comments", tx.Where("user_id = ?", userID), &models.EpisodeComment{}},
+			{"watch_progress", tx.Where("user_id = ?", userID), &models.WatchProgress{}},
+			{"playback_sessions", tx.Where("user_id = ?", userID), &models.PlaybackSession{}},
+			{"favorites", tx.Where("user_id = ?", userID), &models.UserFavorite{}},
+			{"follows", tx.Where("user_id = ? OR creator_id IN ?", userID, creatorIDs), &models.CreatorFollow{}},
+			{"reports", tx.Where("reporter_id = ?", userID), &models.ContentReport{}},
+			{"notifications", tx.Where("user_id = ?", userID), &models.Notification{}},
+			{"preferences", tx.Where("user_id = ?", userID), &models.UserPreferences{}},
+		}
+		for _, step := range steps {
+			res := step.query.Delete(step.model)
+			if res.Error != nil {
+				return res.Error
+			}
+			deleted[step.name] = res.RowsAffected
+		}
+
+		res := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false).Update("revoked", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted["sessions"] = res.RowsAffected
+
+		return tx.Delete(&user).Error
+	})
+	if writeOTPError(w, err) {
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete account")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DeleteAccountResponse{DeletedAt: time.Now(), Deleted: deleted})
+}
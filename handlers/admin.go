@@ -2,18 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	// In a real implementation, you'd have admin service clients here
+	db *gorm.DB
 }
 
-func NewAdminHandler() *AdminHandler {
-	return &AdminHandler{}
+func NewAdminHandler(db *gorm.DB) *AdminHandler {
+	return &AdminHandler{db: db}
 }
 
 // Request/Response structs matching OpenAPI schema
@@ -29,11 +36,6 @@ type PendingUpload struct {
 	Status      string    `json:"status"`
 }
 
-type PendingUploadsResponse struct {
-	Total int64           `json:"total"`
-	Items []PendingUpload `json:"items"`
-}
-
 type ApproveContentRequest struct {
 	Action string `json:"action"` // "approve" or "reject"
 	Reason string `json:"reason"` // Required if action is "reject"
@@ -47,67 +49,120 @@ type ApproveContentResponse struct {
 	AdminID     string    `json:"admin_id"`
 }
 
-// GetPendingUploads lists all pending uploads for admin review
+// GetPendingUploads lists uploads for admin review, filtered by status
+// (default "pending") and optionally a from/to upload date, oldest first
+// unless sort=newest
 func (h *AdminHandler) GetPendingUploads(w http.ResponseWriter, r *http.Request) {
-	// In a real implementation, you'd check if the user has admin privileges
-	// For now, we'll assume this endpoint is protected by admin middleware
-
-	// Mock pending uploads data
-	pendingUploads := []PendingUpload{
-		{
-			ID:          uuid.New().String(),
-			Filename:    "episode1_master.mp4",
-			SizeBytes:   73400320,
-			ContentType: "video/mp4",
-			UploadedAt:  time.Now().Add(-2 * time.Hour),
-			CreatorID:   "creator_123",
-			SeriesID:    "series_456",
-			EpisodeID:   "episode_789",
-			Status:      "pending_review",
-		},
-		{
-			ID:          uuid.New().String(),
-			Filename:    "episode2_master.mp4",
-			SizeBytes:   81234567,
-			ContentType: "video/mp4",
-			UploadedAt:  time.Now().Add(-1 * time.Hour),
-			CreatorID:   "creator_124",
-			SeriesID:    "series_457",
-			EpisodeID:   "episode_790",
-			Status:      "pending_review",
-		},
-	}
-
-	response := PendingUploadsResponse{
-		Total: int64(len(pendingUploads)),
-		Items: pendingUploads,
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	allowedStatuses := map[string]bool{
+		"pending":   true,
+		"uploading": true,
+		"completed": true,
+		"failed":    true,
+	}
+	if !allowedStatuses[status] {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid status")
+		return
 	}
 
+	direction, err := adminSortDirection(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Table("upload_requests").
+		Joins("LEFT JOIN creator_profiles ON creator_profiles.user_id = upload_requests.user_id").
+		Joins("LEFT JOIN episodes ON episodes.id = upload_requests.episode_id").
+		Where("upload_requests.deleted_at IS NULL AND upload_requests.status = ?", status)
+	query, err = filterCreatedAt(r, query, "upload_requests.created_at")
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count uploads")
+		return
+	}
+
+	items := make([]PendingUpload, 0, perPage)
+	if err := query.
+		Select("upload_requests.id, upload_requests.filename, upload_requests.size_bytes, upload_requests.content_type, " +
+			"upload_requests.created_at AS uploaded_at, COALESCE(creator_profiles.id::text, '') AS creator_id, " +
+			"COALESCE(episodes.series_id::text, '') AS series_id, COALESCE(upload_requests.episode_id::text, '') AS episode_id, " +
+			"upload_requests.status").
+		Order("upload_requests.created_at " + direction + ", upload_requests.id " + direction).
+		Offset(offset).Limit(perPage).
+		Scan(&items).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch uploads")
+		return
+	}
+
+	response := httputil.NewPaginatedResponse(items, total, page, perPage)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
+// adminSortDirection reads the sort query param of moderation queues:
+// "oldest" (the default, so the longest waiting item comes first) or "newest"
+func adminSortDirection(r *http.Request) (string, error) {
+	switch r.URL.Query().Get("sort") {
+	case "", "oldest":
+		return "ASC", nil
+	case "newest":
+		return "DESC", nil
+	}
+	return "", errors.New("sort must be 'oldest' or 'newest'")
+}
+
+// filterCreatedAt narrows query to rows whose column falls within the optional
+// from/to query params (RFC3339 or YYYY-MM-DD, where a bare to date includes that day)
+func filterCreatedAt(r *http.Request, query *gorm.DB, column string) (*gorm.DB, error) {
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, _, err := parseDateParam(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'from' date: %s", v)
+		}
+		query = query.Where(column+" >= ?", from)
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to, dateOnly, err := parseDateParam(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'to' date: %s", v)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		query = query.Where(column+" < ?", to)
+	}
+	return query, nil
+}
+
 // ApproveContent handles content approval/rejection
 func (h *AdminHandler) ApproveContent(w http.ResponseWriter, r *http.Request) {
-	// In a real implementation, you'd check if the user has admin privileges
-	// For now, we'll assume this endpoint is protected by admin middleware
-
 	var req ApproveContentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate action
 	if req.Action != "approve" && req.Action != "reject" {
-		http.Error(w, "Action must be 'approve' or 'reject'", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Action must be 'approve' or 'reject'")
 		return
 	}
 
 	// Validate reason for rejection
 	if req.Action == "reject" && req.Reason == "" {
-		http.Error(w, "Reason is required when rejecting content", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Reason is required when rejecting content")
 		return
 	}
 
@@ -126,3 +181,156 @@ func (h *AdminHandler) ApproveContent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+type PendingKYC struct {
+	CreatorID       string    `json:"creator_id"`
+	UserID          string    `json:"user_id"`
+	DisplayName     string    `json:"display_name"`
+	KYCDocumentPath string    `json:"kyc_document_s3_path"`
+	SubmittedAt     time.Time `json:"submitted_at"`
+}
+
+type ReviewKYCRequest struct {
+	Action string `json:"action" validate:"oneof=approve reject"`
+	// Reason is shown to the creator when the KYC is rejected
+	Reason string `json:"reason"`
+}
+
+type ReviewKYCResponse struct {
+	CreatorID  string    `json:"creator_id"`
+	KYCStatus  string    `json:"kyc_status"`
+	Reason     *string   `json:"reason,omitempty"`
+	ReviewedBy string    `json:"reviewed_by"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// GetPendingKYC lists creators whose KYC awaits review, longest waiting first
+func (h *AdminHandler) GetPendingKYC(w http.ResponseWriter, r *http.Request) {
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Model(&models.CreatorProfile{}).Where("kyc_status = ?", models.KYCStatusPending)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count pending KYC reviews")
+		return
+	}
+
+	items := make([]PendingKYC, 0, perPage)
+	if err := query.
+		Select("id AS creator_id, user_id, display_name, kyc_document_s3_path, updated_at AS submitted_at").
+		Order("updated_at ASC, id ASC").
+		Offset(offset).Limit(perPage).
+		Scan(&items).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch pending KYC reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(items, total, page, perPage))
+}
+
+// ReviewCreatorKYC approves or rejects a creator's KYC, recording the
+// reviewing admin. A rejection reason is shown to the creator on their profile.
+func (h *AdminHandler) ReviewCreatorKYC(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req ReviewKYCRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	var creator models.CreatorProfile
+	if err := h.db.WithContext(r.Context()).Where("id = ?", mux.Vars(r)["id"]).First(&creator).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if creator.KYCDocumentPath == "" {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "Creator has not submitted a KYC document")
+		return
+	}
+
+	now := time.Now()
+	response := ReviewKYCResponse{
+		CreatorID:  creator.ID,
+		KYCStatus:  models.KYCStatusVerified,
+		ReviewedBy: adminID,
+		ReviewedAt: now,
+	}
+	if req.Action == "reject" {
+		response.KYCStatus = models.KYCStatusRejected
+		if reason := strings.TrimSpace(req.Reason); reason != "" {
+			response.Reason = &reason
+		}
+	}
+
+	// The document checked must still be the one on file, in case the creator
+	// replaced it while the review was in progress
+	res := h.db.WithContext(r.Context()).Model(&models.CreatorProfile{}).
+		Where("id = ? AND kyc_document_s3_path = ?", creator.ID, creator.KYCDocumentPath).
+		Updates(map[string]interface{}{
+			"kyc_status":           response.KYCStatus,
+			"kyc_rejection_reason": response.Reason,
+			"kyc_reviewed_by":      adminID,
+			"kyc_reviewed_at":      now,
+		})
+	if res.Error != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update KYC status")
+		return
+	}
+	if res.RowsAffected == 0 {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "The KYC document changed during review; reload and review again")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// VerifyPayoutDetails marks a creator's payout bank account as confirmed so
+// they can request payouts. UpdatePayoutDetails clears it again.
+func (h *AdminHandler) VerifyPayoutDetails(w http.ResponseWriter, r *http.Request) {
+	var details models.PayoutDetails
+	if err := h.db.WithContext(r.Context()).Where("creator_id = ?", mux.Vars(r)["id"]).First(&details).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator has no payout details")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// Only the account that was read is verified, in case the creator
+	// replaced it in the meantime
+	res := h.db.WithContext(r.Context()).Model(&models.PayoutDetails{}).
+		Where("id = ? AND updated_at = ?", details.ID, details.UpdatedAt).
+		Update("verified", true)
+	if res.Error != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to verify payout details")
+		return
+	}
+	if res.RowsAffected == 0 {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "The payout details changed during review; reload and verify again")
+		return
+	}
+
+	response := PayoutDetailsResponse{
+		BankName:            details.BankName,
+		AccountNumberMasked: maskAccountNumber(details.AccountNumber),
+		IFSCCode:            details.IFSCCode,
+		AccountHolder:       details.AccountHolder,
+		Verified:            true,
+		UpdatedAt:           details.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
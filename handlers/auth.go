@@ -1,31 +1,66 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	mathrand "math/rand"
 	"net/http"
 	"strconv"
 	"time"
 
 	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/metrics"
+	"streamshort/pkg/phone"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
+// SMSSender delivers text messages to a phone number
+type SMSSender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// AuthOptions holds tunable limits for the OTP flow
+type AuthOptions struct {
+	// OTPMaxSendsPerHour caps how many codes a single phone can request per hour
+	OTPMaxSendsPerHour int
+	// OTPMaxVerifyAttempts is how many wrong codes lock an OTP transaction
+	OTPMaxVerifyAttempts int
+	// AccessTokenTTL and RefreshTokenTTL are the token lifetimes; zero
+	// means TokenExpiration and RefreshTokenExpiration
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// RevokeOnPhoneChange signs out every session when a user changes their phone number
+	RevokeOnPhoneChange bool
+}
+
 type AuthHandler struct {
-	db *gorm.DB
+	db        *gorm.DB
+	jwtSecret []byte
+	sms       SMSSender
+	opts      AuthOptions
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *gorm.DB, jwtSecret string, sms SMSSender, opts AuthOptions) *AuthHandler {
+	if opts.AccessTokenTTL <= 0 {
+		opts.AccessTokenTTL = TokenExpiration
+	}
+	if opts.RefreshTokenTTL <= 0 {
+		opts.RefreshTokenTTL = RefreshTokenExpiration
+	}
+	return &AuthHandler{db: db, jwtSecret: []byte(jwtSecret), sms: sms, opts: opts}
 }
 
 // Request/Response structs matching OpenAPI schema
 type PhoneOtpRequest struct {
-	Phone string `json:"phone"`
+	Phone string `json:"phone" validate:"required"`
 }
 
 type PhoneOtpSendResponse struct {
@@ -35,52 +70,128 @@ type PhoneOtpSendResponse struct {
 }
 
 type PhoneOtpVerifyRequest struct {
-	Phone string `json:"phone"`
-	OTP   string `json:"otp"`
+	Phone string `json:"phone" validate:"required"`
+	OTP   string `json:"otp" validate:"required"`
+	TxnID string `json:"txn_id,omitempty"`
 }
 
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int    `json:"expires_in"`
+	// ExpiresAt lets clients refresh ahead of expiry without tracking when
+	// the response arrived
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    string    `json:"user_id"`
 }
 
 type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token"`
+	AllDevices   bool   `json:"all_devices"`
+}
+
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	FamilyID  string    `json:"family_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SessionListResponse struct {
+	Items []SessionResponse `json:"items"`
 }
 
 // JWT Claims
 type Claims struct {
 	UserID string `json:"user_id"`
 	Phone  string `json:"phone"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
+// Token lifetimes. The access and refresh ones are defaults that AuthOptions can override.
 const (
-	JWTSecret              = "your-secret-key-change-in-production"
 	OTPExpiration          = 5 * time.Minute
 	TokenExpiration        = 1 * time.Hour
 	RefreshTokenExpiration = 7 * 24 * time.Hour
 )
 
-// GetJWTSecret returns the JWT secret for use in middleware
-func GetJWTSecret() string {
-	return JWTSecret
+// OTP verification failures returned by consumeOTP, along with *otpLockedError
+var (
+	errOTPInvalid = errors.New("invalid OTP")
+	errOTPExpired = errors.New("OTP expired")
+)
+
+// otpLockedError is returned while an OTP transaction is locked after too
+// many wrong codes. The lock lasts until the code would have expired.
+type otpLockedError struct {
+	retryAfter time.Duration
+}
+
+func (e *otpLockedError) Error() string {
+	return "too many failed OTP attempts"
+}
+
+// errRefreshTokenReused is returned when a refresh token was already rotated
+var errRefreshTokenReused = errors.New("refresh token reused")
+
+// writeInvalidPhone rejects a phone number that cannot be normalized
+func writeInvalidPhone(w http.ResponseWriter) {
+	httputil.WriteValidationError(w, map[string]httputil.FieldError{
+		"phone": {Rule: "phone", Message: "phone must be a valid phone number"},
+	})
 }
 
 // Send OTP endpoint
 func (h *AuthHandler) SendOTP(w http.ResponseWriter, r *http.Request) {
 	var req PhoneOtpRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	normalized, err := phone.Normalize(req.Phone, phone.DefaultRegion)
+	if err != nil {
+		writeInvalidPhone(w)
 		return
 	}
+	req.Phone = normalized
 
-	if req.Phone == "" {
-		http.Error(w, "Phone number is required", http.StatusBadRequest)
+	response, ok := h.issueOTP(w, r, req.Phone)
+	if !ok {
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// issueOTP sends a new code to phoneNumber, retiring any open ones, within
+// the hourly send limit. On failure it writes the error response and returns
+// false.
+func (h *AuthHandler) issueOTP(w http.ResponseWriter, r *http.Request, phoneNumber string) (PhoneOtpSendResponse, bool) {
+	// Limit how many codes can be requested for a phone within an hour
+	windowStart := time.Now().Add(-time.Hour)
+	var recent []models.OTPTransaction
+	if err := h.db.WithContext(r.Context()).Where("phone = ? AND created_at > ?", phoneNumber, windowStart).
+		Order("created_at").Find(&recent).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return PhoneOtpSendResponse{}, false
+	}
+	if h.opts.OTPMaxSendsPerHour > 0 && len(recent) >= h.opts.OTPMaxSendsPerHour {
+		retryAfter := time.Until(recent[0].CreatedAt.Add(time.Hour))
+		writeRateLimited(w, "Too many OTP requests for this phone number", retryAfter)
+		return PhoneOtpSendResponse{}, false
+	}
+	// A new code cannot be used to dodge the lock on the newest one
+	if len(recent) > 0 && h.otpLocked(recent[len(recent)-1]) {
+		writeRateLimited(w, "Too many failed attempts; try again later", time.Until(recent[len(recent)-1].ExpiresAt))
+		return PhoneOtpSendResponse{}, false
+	}
+
 	// Generate OTP (6 digits)
 	otp := generateOTP()
 
@@ -90,88 +201,98 @@ func (h *AuthHandler) SendOTP(w http.ResponseWriter, r *http.Request) {
 	// Create OTP transaction
 	otpTx := models.OTPTransaction{
 		TxnID:     txnID,
-		Phone:     req.Phone,
+		Phone:     phoneNumber,
 		OTP:       otp,
 		ExpiresAt: time.Now().Add(OTPExpiration),
 	}
 
-	if err := h.db.Create(&otpTx).Error; err != nil {
-		http.Error(w, "Failed to create OTP transaction", http.StatusInternalServerError)
-		return
+	// Only the newest code may work, so retire any still-open ones for the phone.
+	// They keep counting toward the hourly send limit above.
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OTPTransaction{}).
+			Where("phone = ? AND used = ? AND invalidated = ?", phoneNumber, false, false).
+			Update("invalidated", true).Error; err != nil {
+			return err
+		}
+		return tx.Create(&otpTx).Error
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create OTP transaction")
+		return PhoneOtpSendResponse{}, false
 	}
 
-	// In a real application, you would send the OTP via SMS here
-	// For now, we'll just log it
-	fmt.Printf("OTP for %s: %s\n", req.Phone, otp)
+	// Deliver the code; the OTP itself is never echoed back in the response
+	message := fmt.Sprintf("Your StreamShort verification code is %s. It expires in %d minutes.", otp, int(OTPExpiration.Minutes()))
+	if err := h.sms.Send(r.Context(), phoneNumber, message); err != nil {
+		log.Printf("Failed to send OTP to %s: %v", phoneNumber, err)
+		httputil.WriteError(w, http.StatusBadGateway, httputil.CodeUpstreamError, "Failed to send OTP")
+		return PhoneOtpSendResponse{}, false
+	}
+	metrics.OTPsSent.Inc()
 
-	response := PhoneOtpSendResponse{
+	return PhoneOtpSendResponse{
 		TxnID:     txnID,
 		ExpiresIn: int(OTPExpiration.Seconds()),
-		Message:   fmt.Sprintf("OTP sent to %s", req.Phone),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+		Message:   fmt.Sprintf("OTP sent to %s", phoneNumber),
+	}, true
 }
 
 // Verify OTP endpoint
 func (h *AuthHandler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	var req PhoneOtpVerifyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	if req.Phone == "" || req.OTP == "" {
-		http.Error(w, "Phone and OTP are required", http.StatusBadRequest)
-		return
-	}
-
-	// Find OTP transaction
-	// phone := "+91" + req.Phone
-	var otpTx models.OTPTransaction
-	if err := h.db.Where("phone = ? AND otp = ? AND used = ?",
-		req.Phone, req.OTP, false).First(&otpTx).Error; err != nil {
-		http.Error(w, "Invalid OTP", http.StatusUnauthorized)
+	normalized, err := phone.Normalize(req.Phone, phone.DefaultRegion)
+	if err != nil {
+		writeInvalidPhone(w)
 		return
 	}
+	req.Phone = normalized
 
-	// Mark OTP as used
-	h.db.Model(&otpTx).Update("used", true)
-
-	// Get or create user
+	// The code is only spent if the user and session are created with it
 	var user models.User
-	if err := h.db.Where("phone = ?", req.Phone).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Create new user
-			user = models.User{Phone: req.Phone}
-			if err := h.db.Create(&user).Error; err != nil {
-				http.Error(w, "Failed to create user", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-			return
+	var refreshToken string
+	err = h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := h.consumeOTP(r.Context(), tx, req.Phone, req.TxnID, req.OTP); err != nil {
+			return err
 		}
-	}
 
-	// Generate tokens
-	accessToken, err := h.generateAccessToken(user)
+		var err error
+		user, err = findOrCreateUser(tx, req.Phone)
+		if err != nil {
+			return err
+		}
+
+		// Each login starts a new refresh token family
+		refreshToken, err = h.generateRefreshToken(tx, user.ID, uuid.New().String())
+		return err
+	})
+	if writeOTPError(w, err) {
+		return
+	}
+	if err == errAccountDeleted {
+		httputil.WriteError(w, http.StatusForbidden, httputil.CodeForbidden, "This account has been deleted")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to sign in")
 		return
 	}
 
-	refreshToken, err := h.generateRefreshToken(user.ID)
+	accessToken, expiresAt, err := h.generateAccessToken(user)
 	if err != nil {
-		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
 		return
 	}
 
 	response := TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-		ExpiresIn:    int(TokenExpiration.Seconds()),
+		ExpiresIn:    int(h.opts.AccessTokenTTL.Seconds()),
+		ExpiresAt:    expiresAt,
+		UserID:       user.ID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -181,51 +302,85 @@ func (h *AuthHandler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 // Refresh token endpoint
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	var req RefreshRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	if req.RefreshToken == "" {
-		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+	// Find refresh token, including revoked ones so replays can be detected
+	var refreshToken models.RefreshToken
+	if err := h.db.WithContext(r.Context()).Where("token = ?", req.RefreshToken).First(&refreshToken).Error; err != nil {
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid refresh token")
 		return
 	}
 
-	// Find refresh token
-	var refreshToken models.RefreshToken
-	if err := h.db.Where("token = ? AND revoked = ? AND expires_at > ?",
-		req.RefreshToken, false, time.Now()).First(&refreshToken).Error; err != nil {
-		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+	if refreshToken.Revoked {
+		// A rotated token being presented again means it was copied; the
+		// legitimate holder and the attacker share a family, so end it
+		h.revokeReusedToken(context.WithoutCancel(r.Context()), refreshToken)
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Refresh token reuse detected")
 		return
 	}
 
-	// Get user
-	var user models.User
-	if err := h.db.First(&user, refreshToken.UserID).Error; err != nil {
-		http.Error(w, "User not found", http.StatusUnauthorized)
+	if !refreshToken.ExpiresAt.After(time.Now()) {
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Refresh token expired")
 		return
 	}
 
-	// Generate new tokens
-	accessToken, err := h.generateAccessToken(user)
+	// Rotation revokes the old token and issues its replacement together, so
+	// a failure cannot leave the session with no valid token
+	var user models.User
+	var newRefreshToken string
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Revoke the old token first; losing this race to a concurrent request is also reuse
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked = ?", refreshToken.ID, false).
+			Update("revoked", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+
+		if err := tx.Where("id = ?", refreshToken.UserID).First(&user).Error; err != nil {
+			return err
+		}
+
+		// Tokens issued before families existed start a new one on rotation
+		familyID := refreshToken.FamilyID
+		if familyID == "" {
+			familyID = uuid.New().String()
+		}
+		var err error
+		newRefreshToken, err = h.generateRefreshToken(tx, user.ID, familyID)
+		return err
+	})
+	if err == errRefreshTokenReused {
+		h.revokeReusedToken(context.WithoutCancel(r.Context()), refreshToken)
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Refresh token reuse detected")
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "User not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to refresh token")
 		return
 	}
 
-	newRefreshToken, err := h.generateRefreshToken(user.ID)
+	accessToken, expiresAt, err := h.generateAccessToken(user)
 	if err != nil {
-		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
 		return
 	}
 
-	// Revoke old refresh token
-	h.db.Model(&refreshToken).Update("revoked", true)
-
 	response := TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
-		ExpiresIn:    int(TokenExpiration.Seconds()),
+		ExpiresIn:    int(h.opts.AccessTokenTTL.Seconds()),
+		ExpiresAt:    expiresAt,
+		UserID:       user.ID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -233,37 +388,314 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 }
 
 // Helper functions
-func (h *AuthHandler) generateAccessToken(user models.User) (string, error) {
+
+// consumeOTP checks code against the newest OTP transaction for the phone
+// and marks it used through tx, so the code is only spent if the caller's
+// transaction commits. Failures are errOTPInvalid, errOTPExpired or an
+// *otpLockedError, which writeOTPError turns into responses.
+func (h *AuthHandler) consumeOTP(ctx context.Context, tx *gorm.DB, phoneNumber, txnID, code string) error {
+	// Only the most recent transaction for the phone is eligible, so an older
+	// code that happens to collide can never be matched
+	query := tx.Where("phone = ? AND invalidated = ?", phoneNumber, false)
+	if txnID != "" {
+		query = query.Where("txn_id = ?", txnID)
+	}
+	var otpTx models.OTPTransaction
+	if err := query.Order("created_at DESC").First(&otpTx).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			metrics.OTPVerificationsFailed.Inc()
+			return errOTPInvalid
+		}
+		return err
+	}
+
+	// A locked code stays locked, even to the right guess, until it expires
+	if h.otpLocked(otpTx) {
+		metrics.OTPVerificationsFailed.Inc()
+		return &otpLockedError{retryAfter: time.Until(otpTx.ExpiresAt)}
+	}
+	if otpTx.Used {
+		metrics.OTPVerificationsFailed.Inc()
+		return errOTPInvalid
+	}
+	if !otpTx.ExpiresAt.After(time.Now()) {
+		metrics.OTPVerificationsFailed.Inc()
+		return errOTPExpired
+	}
+
+	if otpTx.OTP != code {
+		metrics.OTPVerificationsFailed.Inc()
+		// Count the attempt outside tx, which the caller rolls back, and even
+		// if the client hangs up before the response
+		attempts, err := h.recordFailedAttempt(context.WithoutCancel(ctx), otpTx.ID)
+		if err != nil {
+			return err
+		}
+		otpTx.FailedAttempts = attempts
+		if h.otpLocked(otpTx) {
+			return &otpLockedError{retryAfter: time.Until(otpTx.ExpiresAt)}
+		}
+		return errOTPInvalid
+	}
+
+	// A concurrent request spending the same code waits here and then finds it used
+	res := tx.Model(&models.OTPTransaction{}).Where("id = ? AND used = ?", otpTx.ID, false).Update("used", true)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		metrics.OTPVerificationsFailed.Inc()
+		return errOTPInvalid
+	}
+	return nil
+}
+
+// writeOTPError writes the response for a consumeOTP failure and reports
+// whether err was one
+func writeOTPError(w http.ResponseWriter, err error) bool {
+	var locked *otpLockedError
+	switch {
+	case err == errOTPInvalid:
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid OTP")
+	case err == errOTPExpired:
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "OTP expired")
+	case errors.As(err, &locked):
+		writeRateLimited(w, "Too many failed attempts; try again later", locked.retryAfter)
+	default:
+		return false
+	}
+	return true
+}
+
+// findOrCreateUser returns the user for a phone number, creating it on first login.
+// Two concurrent first logins race on the unique phone index; the loser re-reads
+// the row the winner created so both end up with the same user. A phone that
+// belongs to a deleted account returns errAccountDeleted until the sweeper
+// releases it.
+func findOrCreateUser(db *gorm.DB, phone string) (models.User, error) {
+	var user models.User
+	err := db.Where("phone = ?", phone).First(&user).Error
+	if err != gorm.ErrRecordNotFound {
+		return user, err
+	}
+
+	var deleted int64
+	if err := db.Unscoped().Model(&models.User{}).
+		Where("phone = ? AND deleted_at IS NOT NULL", phone).Count(&deleted).Error; err != nil {
+		return user, err
+	}
+	if deleted > 0 {
+		return user, errAccountDeleted
+	}
+
+	// The nested transaction is a savepoint when db is already in one, so a
+	// lost race does not abort the caller's transaction
+	user = models.User{Phone: phone}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&user).Error
+	})
+	if err == nil || !isUniqueViolation(err) {
+		return user, err
+	}
+
+	user = models.User{}
+	err = db.Where("phone = ?", phone).First(&user).Error
+	return user, err
+}
+
+// Logout revokes the session behind a refresh token, or every session of the
+// user when all_devices is set. It succeeds even if nothing was left to revoke.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req LogoutRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.AllDevices {
+		if err := h.db.WithContext(r.Context()).Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked = ?", userID, false).
+			Update("revoked", true).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to revoke sessions")
+			return
+		}
+	} else {
+		if req.RefreshToken == "" {
+			httputil.WriteValidationError(w, map[string]httputil.FieldError{
+				"refresh_token": {Rule: "required_unless", Param: "all_devices", Message: "refresh_token is required unless all_devices is set"},
+			})
+			return
+		}
+
+		var refreshToken models.RefreshToken
+		err := h.db.WithContext(r.Context()).Where("token = ? AND user_id = ?", req.RefreshToken, userID).First(&refreshToken).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		// The whole family is revoked so a rotated copy of the token is logged out too
+		if err == nil {
+			if err := h.revokeTokenFamily(r.Context(), refreshToken); err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to revoke session")
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Logged out successfully",
+	})
+}
+
+// GetSessions lists the user's active refresh tokens for device management
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var tokens []models.RefreshToken
+	if err := h.db.WithContext(r.Context()).Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("created_at DESC").
+		Find(&tokens).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch sessions")
+		return
+	}
+
+	response := SessionListResponse{Items: make([]SessionResponse, 0, len(tokens))}
+	for _, t := range tokens {
+		response.Items = append(response.Items, SessionResponse{
+			ID:        t.ID,
+			FamilyID:  t.FamilyID,
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// recordFailedAttempt atomically bumps the failure counter on an OTP
+// transaction and returns the new count, so concurrent wrong guesses are all
+// counted. The transaction is marked used in the same statement once the
+// limit is reached, so it can never be spent afterwards.
+func (h *AuthHandler) recordFailedAttempt(ctx context.Context, id string) (int, error) {
+	var attempts int
+	err := h.db.WithContext(ctx).Raw(`
+		UPDATE otp_transactions
+		SET failed_attempts = failed_attempts + 1,
+			used = used OR (? > 0 AND failed_attempts + 1 >= ?),
+			updated_at = ?
+		WHERE id = ?
+		RETURNING failed_attempts`,
+		h.opts.OTPMaxVerifyAttempts, h.opts.OTPMaxVerifyAttempts, time.Now(), id).Scan(&attempts).Error
+	if err != nil {
+		log.Printf("Failed to record OTP attempt %s: %v", id, err)
+	}
+	return attempts, err
+}
+
+// otpLocked reports whether otpTx has used up its wrong guesses and has not
+// yet expired
+func (h *AuthHandler) otpLocked(otpTx models.OTPTransaction) bool {
+	return h.opts.OTPMaxVerifyAttempts > 0 && otpTx.FailedAttempts >= h.opts.OTPMaxVerifyAttempts &&
+		otpTx.ExpiresAt.After(time.Now())
+}
+
+// writeRateLimited responds with 429 and tells the client when it may retry
+func writeRateLimited(w http.ResponseWriter, message string, retryAfter time.Duration) {
+	seconds := int(retryAfter.Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	if seconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	httputil.WriteErrorDetails(w, http.StatusTooManyRequests, httputil.CodeRateLimited, message, map[string]interface{}{
+		"retry_after_seconds": seconds,
+	})
+}
+
+// generateAccessToken signs an access token for user and returns it with its
+// expiry, truncated to the second like the JWT exp claim
+func (h *AuthHandler) generateAccessToken(user models.User) (string, time.Time, error) {
+	now := time.Now().Truncate(time.Second)
+	expiresAt := now.Add(h.opts.AccessTokenTTL)
 	claims := Claims{
 		UserID: user.ID,
 		Phone:  user.Phone,
+		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWTSecret))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(h.jwtSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
 }
 
-func (h *AuthHandler) generateRefreshToken(userID string) (string, error) {
+// generateRefreshToken stores a new refresh token through db, which may be a transaction
+func (h *AuthHandler) generateRefreshToken(db *gorm.DB, userID, familyID string) (string, error) {
 	token := "rfrsh_" + uuid.New().String()
 
 	refreshToken := models.RefreshToken{
 		Token:     token,
 		UserID:    userID,
-		ExpiresAt: time.Now().Add(RefreshTokenExpiration),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(h.opts.RefreshTokenTTL),
 	}
 
-	if err := h.db.Create(&refreshToken).Error; err != nil {
+	if err := db.Create(&refreshToken).Error; err != nil {
 		return "", err
 	}
 
 	return token, nil
 }
 
+// revokeTokenFamily revokes every token rotated from the same login as token.
+// Tokens without a family fall back to revoking all of the user's tokens.
+func (h *AuthHandler) revokeTokenFamily(ctx context.Context, token models.RefreshToken) error {
+	query := h.db.WithContext(ctx).Model(&models.RefreshToken{}).Where("revoked = ?", false)
+	if token.FamilyID != "" {
+		query = query.Where("family_id = ?", token.FamilyID)
+	} else {
+		query = query.Where("user_id = ?", token.UserID)
+	}
+	return query.Update("revoked", true).Error
+}
+
+// revokeReusedToken ends the family of a refresh token that was presented after rotation.
+// Callers detach ctx from the request so a disconnecting client can't cancel it.
+func (h *AuthHandler) revokeReusedToken(ctx context.Context, token models.RefreshToken) {
+	if err := h.revokeTokenFamily(ctx, token); err != nil {
+		log.Printf("Failed to revoke refresh token family for user %s: %v", token.UserID, err)
+		return
+	}
+	log.Printf("Refresh token reuse detected for user %s; revoked token family", token.UserID)
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 func generateOTP() string {
 	// Generate 6-digit OTP
 	otp := ""
@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/testdb"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// testJWTSecret signs the tokens of AuthHandlers under test
+const testJWTSecret = "test-secret-at-least-32-bytes-long!!"
+
+func TestWriteOTPErrorLocked(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if !writeOTPError(rec, &otpLockedError{retryAfter: 90 * time.Second}) {
+		t.Fatal("locked error was not handled")
+	}
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After = %q, want 90", got)
+	}
+	var body struct {
+		Error struct {
+			Details map[string]interface{} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Error.Details["retry_after_seconds"]; got != float64(90) {
+		t.Errorf("retry_after_seconds = %v, want 90", got)
+	}
+}
+
+func TestOTPLocked(t *testing.T) {
+	h := &AuthHandler{opts: AuthOptions{OTPMaxVerifyAttempts: 3}}
+	live := time.Now().Add(time.Minute)
+	tests := []struct {
+		name string
+		otp  models.OTPTransaction
+		want bool
+	}{
+		{"under the limit", models.OTPTransaction{FailedAttempts: 2, ExpiresAt: live}, false},
+		{"at the limit", models.OTPTransaction{FailedAttempts: 3, ExpiresAt: live}, true},
+		{"expired lock", models.OTPTransaction{FailedAttempts: 3, ExpiresAt: time.Now().Add(-time.Minute)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.otpLocked(tt.otp); got != tt.want {
+				t.Errorf("otpLocked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	unlimited := &AuthHandler{}
+	if unlimited.otpLocked(models.OTPTransaction{FailedAttempts: 100, ExpiresAt: live}) {
+		t.Error("a zero attempt limit must never lock")
+	}
+}
+
+func TestVerifyOTPRollsBackOnMidTransactionFailure(t *testing.T) {
+	db := testdb.Open(t)
+	const phoneNumber = "+919876500101"
+	otp := models.OTPTransaction{
+		TxnID:     uuid.New().String(),
+		Phone:     phoneNumber,
+		OTP:       "123456",
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}
+	if err := db.Create(&otp).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandler(db, testJWTSecret, nil, AuthOptions{})
+
+	// Fail the last write of the login, after the code is spent and the user created
+	const callback = "test:fail_refresh_token"
+	injected := errors.New("injected failure")
+	if err := db.Callback().Create().Before("gorm:create").Register(callback, func(tx *gorm.DB) {
+		if tx.Statement.Table == "refresh_tokens" {
+			tx.AddError(injected)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	removed := false
+	removeCallback := func() {
+		if !removed {
+			db.Callback().Create().Remove(callback)
+			removed = true
+		}
+	}
+	t.Cleanup(removeCallback)
+
+	body := `{"phone":"` + phoneNumber + `","otp":"123456","txn_id":"` + otp.TxnID + `"}`
+	if rec := serve(h.VerifyOTP, http.MethodPost, "/auth/otp/verify", nil, "", body); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500 from the injected failure", rec.Code)
+	}
+
+	var reloaded models.OTPTransaction
+	if err := db.First(&reloaded, "id = ?", otp.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Used {
+		t.Error("OTP was spent although the login failed")
+	}
+	var users, tokens int64
+	db.Unscoped().Model(&models.User{}).Where("phone = ?", phoneNumber).Count(&users)
+	db.Model(&models.RefreshToken{}).Joins("JOIN users ON users.id = refresh_tokens.user_id").
+		Where("users.phone = ?", phoneNumber).Count(&tokens)
+	if users != 0 || tokens != 0 {
+		t.Errorf("rolled back login left %d users and %d refresh tokens", users, tokens)
+	}
+
+	// The unspent code still signs in once the failure is gone
+	removeCallback()
+	if rec := serve(h.VerifyOTP, http.MethodPost, "/auth/otp/verify", nil, "", body); rec.Code != http.StatusOK {
+		t.Fatalf("retry status = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestConcurrentFirstLoginsCreateOneUser(t *testing.T) {
+	db := testdb.Shared(t)
+	phoneNumber := fmt.Sprintf("+919%09d", rand.Intn(1e9))
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM refresh_tokens WHERE user_id IN (SELECT id FROM users WHERE phone = ?)", phoneNumber)
+		db.Unscoped().Where("phone = ?", phoneNumber).Delete(&models.User{})
+		db.Unscoped().Where("phone = ?", phoneNumber).Delete(&models.OTPTransaction{})
+	})
+
+	// Two live codes for the same new phone, each verified by its own request
+	const logins = 2
+	bodies := make([]string, logins)
+	for i := range bodies {
+		otp := models.OTPTransaction{
+			TxnID:     uuid.New().String(),
+			Phone:     phoneNumber,
+			OTP:       fmt.Sprintf("%06d", 100000+i),
+			ExpiresAt: time.Now().Add(5 * time.Minute),
+		}
+		if err := db.Create(&otp).Error; err != nil {
+			t.Fatal(err)
+		}
+		bodies[i] = `{"phone":"` + phoneNumber + `","otp":"` + otp.OTP + `","txn_id":"` + otp.TxnID + `"}`
+	}
+	h := NewAuthHandler(db, testJWTSecret, nil, AuthOptions{})
+
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, logins)
+	start := make(chan struct{})
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			recs[i] = serve(h.VerifyOTP, http.MethodPost, "/auth/otp/verify", nil, "", bodies[i])
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	userIDs := make(map[string]bool)
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK {
+			t.Fatalf("login %d = %d: %s", i, rec.Code, rec.Body)
+		}
+		var resp TokenResponse
+		decodeBody(t, rec, &resp)
+		userIDs[resp.UserID] = true
+	}
+	if len(userIDs) != 1 {
+		t.Errorf("logins signed in as %d different users, want 1", len(userIDs))
+	}
+	var users int64
+	db.Unscoped().Model(&models.User{}).Where("phone = ?", phoneNumber).Count(&users)
+	if users != 1 {
+		t.Errorf("%d users rows for the phone, want exactly 1", users)
+	}
+}
+
+// refresh presents token to RefreshToken
+func refresh(h *AuthHandler, token string) *httptest.ResponseRecorder {
+	return serve(h.RefreshToken, http.MethodPost, "/auth/refresh", nil, "", `{"refresh_token":"`+token+`"}`)
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	db := testdb.Open(t)
+	user := createTestUser(t, db)
+	h := NewAuthHandler(db, testJWTSecret, nil, AuthOptions{})
+	familyID := uuid.New().String()
+	old, err := h.generateRefreshToken(db, user.ID, familyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := refresh(h, old)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh = %d: %s", rec.Code, rec.Body)
+	}
+	var resp TokenResponse
+	decodeBody(t, rec, &resp)
+	if resp.AccessToken == "" || resp.RefreshToken == "" || resp.RefreshToken == old {
+		t.Fatalf("refresh issued access %q and refresh %q, want new tokens", resp.AccessToken, resp.RefreshToken)
+	}
+
+	var oldToken, newToken models.RefreshToken
+	if err := db.Where("token = ?", old).First(&oldToken).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("token = ?", resp.RefreshToken).First(&newToken).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !oldToken.Revoked {
+		t.Error("rotated token was not revoked")
+	}
+	if newToken.Revoked || newToken.FamilyID != familyID || newToken.UserID != user.ID {
+		t.Errorf("new token = %+v, want a live token of family %s for user %s", newToken, familyID, user.ID)
+	}
+}
+
+func TestRefreshTokenReplayRevokesFamily(t *testing.T) {
+	db := testdb.Open(t)
+	user := createTestUser(t, db)
+	h := NewAuthHandler(db, testJWTSecret, nil, AuthOptions{})
+	old, err := h.generateRefreshToken(db, user.ID, uuid.New().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second login of the same user is a separate family
+	otherLogin, err := h.generateRefreshToken(db, user.ID, uuid.New().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := refresh(h, old)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh = %d: %s", rec.Code, rec.Body)
+	}
+	var rotated TokenResponse
+	decodeBody(t, rec, &rotated)
+
+	rec = refresh(h, old)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("replay = %d, want 401", rec.Code)
+	}
+	var body httputil.ErrorResponse
+	decodeBody(t, rec, &body)
+	if body.Error.Message != "Refresh token reuse detected" {
+		t.Errorf("replay message = %q", body.Error.Message)
+	}
+
+	// The replay ends the family, so the token it was rotated into is dead too
+	if rec := refresh(h, rotated.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with the rotated sibling = %d, want 401", rec.Code)
+	}
+	if rec := refresh(h, otherLogin); rec.Code != http.StatusOK {
+		t.Errorf("refresh of another login = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestConcurrentRefreshRevokesFamily(t *testing.T) {
+	db := testdb.Shared(t)
+	user := createTestUser(t, db)
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RefreshToken{})
+		db.Unscoped().Delete(&user)
+	})
+	h := NewAuthHandler(db, testJWTSecret, nil, AuthOptions{})
+	token, err := h.generateRefreshToken(db, user.ID, uuid.New().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both requests may read the token as live; the one that loses the
+	// revoke is reuse (errRefreshTokenReused) and ends the family
+	const refreshes = 2
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, refreshes)
+	start := make(chan struct{})
+	for i := range recs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			recs[i] = refresh(h, token)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var winner *httptest.ResponseRecorder
+	for i, rec := range recs {
+		switch rec.Code {
+		case http.StatusOK:
+			if winner != nil {
+				t.Fatal("one refresh token was rotated twice")
+			}
+			winner = rec
+		case http.StatusUnauthorized:
+		default:
+			t.Fatalf("refresh %d = %d: %s", i, rec.Code, rec.Body)
+		}
+	}
+	if winner == nil {
+		t.Fatal("no refresh succeeded")
+	}
+	var resp TokenResponse
+	decodeBody(t, winner, &resp)
+	if rec := refresh(h, resp.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with the winner's token = %d, want 401 after the family was revoked", rec.Code)
+	}
+}
+
+func TestVerifyExpiredOTP(t *testing.T) {
+	db := testdb.Open(t)
+	const phoneNumber = "+919876500102"
+	otp := models.OTPTransaction{
+		TxnID:     uuid.New().String(),
+		Phone:     phoneNumber,
+		OTP:       "123456",
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	if err := db.Create(&otp).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandler(db, testJWTSecret, nil, AuthOptions{})
+
+	body := `{"phone":"` + phoneNumber + `","otp":"123456","txn_id":"` + otp.TxnID + `"}`
+	rec := serve(h.VerifyOTP, http.MethodPost, "/auth/otp/verify", nil, "", body)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		httputil.ErrorResponse
+		TokenResponse
+	}
+	decodeBody(t, rec, &resp)
+	if resp.Error.Message != "OTP expired" {
+		t.Errorf("message = %q, want %q", resp.Error.Message, "OTP expired")
+	}
+	if resp.AccessToken != "" || resp.RefreshToken != "" {
+		t.Error("an expired OTP issued tokens")
+	}
+
+	var users, tokens int64
+	db.Unscoped().Model(&models.User{}).Where("phone = ?", phoneNumber).Count(&users)
+	db.Model(&models.RefreshToken{}).Joins("JOIN users ON users.id = refresh_tokens.user_id").
+		Where("users.phone = ?", phoneNumber).Count(&tokens)
+	if users != 0 || tokens != 0 {
+		t.Errorf("expired OTP left %d users and %d refresh tokens", users, tokens)
+	}
+}
+
+// recordingSMS keeps the last message sent to each phone
+type recordingSMS struct {
+	mu   sync.Mutex
+	last map[string]string
+}
+
+func (s *recordingSMS) Send(ctx context.Context, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		s.last = make(map[string]string)
+	}
+	s.last[phone] = message
+	return nil
+}
+
+// code returns the OTP in the last message sent to phone
+func (s *recordingSMS) code(t *testing.T, phone string) string {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := regexp.MustCompile(`\d{6}`).FindString(s.last[phone])
+	if code == "" {
+		t.Fatalf("no code in the message to %s: %q", phone, s.last[phone])
+	}
+	return code
+}
+
+func TestResendOTPInvalidatesPreviousCode(t *testing.T) {
+	db := testdb.Open(t)
+	const phoneNumber = "+919876500103"
+	sms := &recordingSMS{}
+	h := NewAuthHandler(db, testJWTSecret, sms, AuthOptions{})
+
+	send := func() (txnID, code string) {
+		t.Helper()
+		rec := serve(h.SendOTP, http.MethodPost, "/auth/otp/send", nil, "", `{"phone":"`+phoneNumber+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("send = %d: %s", rec.Code, rec.Body)
+		}
+		var resp PhoneOtpSendResponse
+		decodeBody(t, rec, &resp)
+		return resp.TxnID, sms.code(t, phoneNumber)
+	}
+	verify := func(txnID, code string) *httptest.ResponseRecorder {
+		return serve(h.VerifyOTP, http.MethodPost, "/auth/otp/verify", nil, "",
+			`{"phone":"`+phoneNumber+`","otp":"`+code+`","txn_id":"`+txnID+`"}`)
+	}
+
+	firstTxn, firstCode := send()
+	secondTxn, secondCode := send()
+
+	if rec := verify(firstTxn, firstCode); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("first code after a resend = %d, want 401", rec.Code)
+	}
+	if rec := verify(secondTxn, secondCode); rec.Code != http.StatusOK {
+		t.Fatalf("second code = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// captionsContentType is the only accepted media type for caption uploads
+const captionsContentType = "text/vtt"
+
+// captionLanguagePattern accepts BCP 47 style tags such as "en", "hi" or "pt-BR"
+var captionLanguagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+type CaptionRequest struct {
+	Language string `json:"language"`
+	// URL attaches an already hosted WebVTT file. When omitted, a presigned
+	// upload URL for a .vtt file is returned instead.
+	URL         *string `json:"url"`
+	ContentType string  `json:"content_type"`
+}
+
+type CaptionTrack struct {
+	Language string `json:"language"`
+	URL      string `json:"url"`
+}
+
+type CaptionResponse struct {
+	CaptionTrack
+	UploadURL     string            `json:"upload_url,omitempty"`
+	ExpiresIn     int               `json:"expires_in,omitempty"`
+	UploadHeaders map[string]string `json:"upload_headers,omitempty"`
+}
+
+// AddCaptions attaches a captions track for one language to an episode,
+// replacing any existing track for that language
+func (h *ContentHandler) AddCaptions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req CaptionRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if !captionLanguagePattern.MatchString(req.Language) {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "A valid language code is required (e.g. en, hi, pt-BR)")
+		return
+	}
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Preload("Series").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	isOwner, err := h.ownsSeries(r.Context(), userID, episode.Series)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if episode.Series.ID == "" || !isOwner {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
+		return
+	}
+
+	var response CaptionResponse
+	response.Language = req.Language
+
+	if req.URL != nil {
+		u, err := url.Parse(*req.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Captions URL must be an absolute http(s) URL")
+			return
+		}
+		if !strings.HasSuffix(strings.ToLower(u.Path), ".vtt") {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Captions must be a WebVTT (.vtt) file")
+			return
+		}
+		response.URL = *req.URL
+	} else {
+		mediaType, _, err := mime.ParseMediaType(req.ContentType)
+		if err != nil || mediaType != captionsContentType {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Content type must be text/vtt")
+			return
+		}
+		if h.storage == nil && !h.mockUploads {
+			httputil.WriteError(w, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Upload storage is not configured")
+			return
+		}
+
+		key := fmt.Sprintf("captions/%s/%s.vtt", episode.ID, req.Language)
+		var uploadURL string
+		if h.storage != nil {
+			uploadURL, err = h.storage.PresignPut(r.Context(), key, captionsContentType, 0, uploadURLExpiration)
+			if err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate upload URL")
+				return
+			}
+		} else {
+			// Local development without AWS credentials (S3_MOCK_UPLOADS=true)
+			uploadURL = fmt.Sprintf("https://s3.amazonaws.com/bucket/%s?AWSAccessKeyId=mock&Signature=mock", key)
+		}
+
+		response.URL = h.cdnURL(key)
+		response.UploadURL = uploadURL
+		response.ExpiresIn = int(uploadURLExpiration.Seconds())
+		response.UploadHeaders = map[string]string{"Content-Type": captionsContentType}
+	}
+
+	err = h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		caption := models.EpisodeCaption{
+			EpisodeID: episode.ID,
+			Language:  req.Language,
+			URL:       response.URL,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "episode_id"}, {Name: "language"}},
+			DoUpdates: clause.AssignmentColumns([]string{"url", "updated_at"}),
+		}).Create(&caption).Error; err != nil {
+			return err
+		}
+
+		// episodes.captions_url keeps pointing at a single default track for
+		// older clients: the series language when available, else the first added
+		if episode.CaptionsURL == nil || req.Language == episode.Series.Language {
+			return tx.Model(&models.Episode{}).Where("id = ?", episode.ID).
+				Update("captions_url", response.URL).Error
+		}
+		return nil
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save captions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// captionTracks lists an episode's captions tracks ordered by language
+func (h *ContentHandler) captionTracks(ctx context.Context, episodeID string) ([]CaptionTrack, error) {
+	var captions []models.EpisodeCaption
+	if err := h.db.WithContext(ctx).Where("episode_id = ?", episodeID).Order("language ASC").Find(&captions).Error; err != nil {
+		return nil, err
+	}
+
+	tracks := make([]CaptionTrack, len(captions))
+	for i, c := range captions {
+		tracks[i] = CaptionTrack{Language: c.Language, URL: c.URL}
+	}
+	return tracks, nil
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+)
+
+// storageCleanupTimeout bounds the background S3 cleanup of a deleted episode
+const storageCleanupTimeout = 2 * time.Minute
+
+// CleanupEpisodeStorage removes a deleted episode's master upload, HLS
+// renditions, thumbnails and captions from S3 in the background. It is best
+// effort: failures never affect the deletion and are recorded as orphaned
+// objects for a later reconciliation sweep. Nothing happens without S3.
+// Episodes of a deleted series are only cleaned up once the sweeper purges
+// the series, since until then it can be restored.
+func (h *ContentHandler) CleanupEpisodeStorage(episode models.Episode) {
+	if h.storage == nil {
+		return
+	}
+
+	var keys, prefixes []string
+	if episode.S3MasterPath != nil && *episode.S3MasterPath != "" {
+		if key, ok := h.storage.KeyFromPath(*episode.S3MasterPath); ok && key != "" {
+			keys = append(keys, key)
+		}
+	}
+	prefixes = append(prefixes,
+		fmt.Sprintf("hls/%s/", episode.ID),
+		fmt.Sprintf("thumbnails/episodes/%s/", episode.ID),
+		fmt.Sprintf("captions/%s/", episode.ID),
+	)
+	// Manifests stored elsewhere are only cleaned up when their directory is
+	// the episode's own, never a directory shared with other episodes
+	if prefix := h.manifestPrefix(episode); prefix != "" && strings.Contains(prefix, episode.ID) && prefix != prefixes[0] {
+		prefixes = append(prefixes, prefix)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), storageCleanupTimeout)
+		defer cancel()
+
+		for _, key := range keys {
+			if err := h.storage.DeleteObject(ctx, key); err != nil {
+				h.recordOrphanedObject(episode.ID, key, err)
+			}
+		}
+		for _, prefix := range prefixes {
+			deleted, err := h.storage.DeletePrefix(ctx, prefix)
+			if err != nil {
+				h.recordOrphanedObject(episode.ID, prefix, err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d objects under %s for deleted episode %s", deleted, prefix, episode.ID)
+			}
+		}
+	}()
+}
+
+// manifestPrefix is the object key directory holding the episode's HLS
+// manifest, whether it was stored as a key, an s3:// URI or a CDN URL
+func (h *ContentHandler) manifestPrefix(episode models.Episode) string {
+	if episode.HLSManifestURL == nil || *episode.HLSManifestURL == "" {
+		return ""
+	}
+	manifest := *episode.HLSManifestURL
+	if u, err := url.Parse(manifest); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		manifest = u.Path
+	}
+	key, ok := h.storage.KeyFromPath(manifest)
+	if !ok {
+		return ""
+	}
+	dir := path.Dir(key)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir + "/"
+}
+
+// recordOrphanedObject logs a failed cleanup and keeps it for reconciliation
+func (h *ContentHandler) recordOrphanedObject(episodeID, key string, cause error) {
+	log.Printf("Storage cleanup for deleted episode %s failed on %s: %v", episodeID, key, cause)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	orphan := models.OrphanedObject{EpisodeID: episodeID, Key: key, Error: cause.Error()}
+	if err := h.db.WithContext(ctx).Create(&orphan).Error; err != nil {
+		log.Printf("Failed to record orphaned object %s of episode %s: %v", key, episodeID, err)
+	}
+}
+
+// GetOrphanedObjects lists storage left behind by failed episode cleanups,
+// optionally within a from/to date, oldest first unless sort=newest
+func (h *AdminHandler) GetOrphanedObjects(w http.ResponseWriter, r *http.Request) {
+	query := h.db.WithContext(r.Context()).Model(&models.OrphanedObject{})
+	query, err := filterCreatedAt(r, query, "created_at")
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	direction, err := adminSortDirection(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count orphaned objects")
+		return
+	}
+
+	objects := make([]models.OrphanedObject, 0, perPage)
+	if err := query.Order("created_at " + direction + ", id " + direction).Offset(offset).Limit(perPage).Find(&objects).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch orphaned objects")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(objects, total, page, perPage))
+}
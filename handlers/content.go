@@ -1,36 +1,89 @@
 package handlers
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"streamshort/models"
+	"streamshort/pkg/cdn"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/metrics"
+	"streamshort/pkg/storage"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ContentHandler struct {
-	db *gorm.DB
+	db             *gorm.DB
+	storage        *storage.S3Client
+	mockUploads    bool
+	cdnBaseURL     string
+	signer         *cdn.Signer
+	thumbnailHosts []string
+	trendingOpts   TrendingOptions
+	trending       *trendingCache
+	uploadLimits   UploadLimits
+	seriesCache    *SeriesListCache
 }
 
-func NewContentHandler(db *gorm.DB) *ContentHandler {
-	return &ContentHandler{db: db}
+// UploadLimits bounds the video uploads creators may request
+type UploadLimits struct {
+	// MaxBytes is the largest upload accepted, in bytes
+	MaxBytes int64
+	// ContentTypes lists the accepted video media types
+	ContentTypes []string
 }
 
+// NewContentHandler creates a content handler. store may be nil when S3 is not
+// configured, in which case uploads fail unless mockUploads is enabled. signer
+// may be nil, in which case manifest URLs are returned unsigned. Thumbnail URLs
+// must be served from one of thumbnailHosts.
+func NewContentHandler(db *gorm.DB, store *storage.S3Client, mockUploads bool, cdnBaseURL string, signer *cdn.Signer, thumbnailHosts []string, trending TrendingOptions, uploads UploadLimits, seriesCache *SeriesListCache) *ContentHandler {
+	return &ContentHandler{
+		db:             db,
+		storage:        store,
+		mockUploads:    mockUploads,
+		cdnBaseURL:     cdnBaseURL,
+		signer:         signer,
+		thumbnailHosts: thumbnailHosts,
+		trendingOpts:   trending,
+		trending:       &trendingCache{},
+		uploadLimits:   uploads,
+		seriesCache:    seriesCache,
+	}
+}
+
+const (
+	uploadURLExpiration   = 1 * time.Hour
+	manifestURLExpiration = 1 * time.Hour
+	// SeriesRestoreWindow is how long a deleted series can still be restored
+	// by its owner; the sweeper purges it afterwards
+	SeriesRestoreWindow = 30 * 24 * time.Hour
+)
+
 // Request/Response structs matching OpenAPI schema
 type CreateSeriesRequest struct {
-	Title        string   `json:"title"`
-	Synopsis     string   `json:"synopsis"`
-	Language     string   `json:"language"`
-	CategoryTags []string `json:"category_tags"`
-	PriceType    string   `json:"price_type"`
+	Title        string   `json:"title" validate:"required"`
+	Synopsis     string   `json:"synopsis" validate:"required"`
+	Language     string   `json:"language" validate:"required"`
+	CategoryTags []string `json:"category_tags" validate:"dive,required"`
+	PriceType    string   `json:"price_type" validate:"oneof=free subscription one_time"`
 	PriceAmount  *float64 `json:"price_amount"`
 	ThumbnailURL *string  `json:"thumbnail_url"`
 }
@@ -44,12 +97,14 @@ type UpdateSeriesRequest struct {
 	PriceAmount  *float64  `json:"price_amount"`
 	ThumbnailURL *string   `json:"thumbnail_url"`
 	Status       *string   `json:"status"`
+	// Version is the version the client last read; the update fails with 409 if it is stale
+	Version *int `json:"version"`
 }
 
 type CreateEpisodeRequest struct {
-	Title           string `json:"title"`
-	EpisodeNumber   int    `json:"episode_number"`
-	DurationSeconds int    `json:"duration_seconds"`
+	Title           string `json:"title" validate:"required"`
+	EpisodeNumber   int    `json:"episode_number" validate:"gte=0"`
+	DurationSeconds int    `json:"duration_seconds" validate:"gt=0"`
 }
 
 type SeriesListItem struct {
@@ -66,25 +121,58 @@ type SeriesListItem struct {
 	Status       string         `json:"status"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
-	Episodes     []EpisodeBrief `json:"episodes"`
+	// AverageRating and RatingCount aggregate ratings of the series' published episodes
+	AverageRating float64        `json:"average_rating"`
+	RatingCount   int64          `json:"rating_count"`
+	Episodes      []EpisodeBrief `json:"episodes"`
 }
 
 type EpisodeBrief struct {
-	ID              string     `json:"id"`
-	Title           string     `json:"title"`
-	EpisodeNumber   int        `json:"episode_number"`
-	DurationSeconds int        `json:"duration_seconds"`
-	ThumbURL        *string    `json:"thumb_url"`
-	PublishedAt     *time.Time `json:"published_at"`
-	CreatedAt       time.Time  `json:"created_at"`
+	ID              string  `json:"id"`
+	Title           string  `json:"title"`
+	EpisodeNumber   int     `json:"episode_number"`
+	DurationSeconds int     `json:"duration_seconds"`
+	ThumbURL        *string `json:"thumb_url"`
+	ViewCount       int64   `json:"view_count"`
+	// Status is only included when the owning creator views their series
+	Status      string     `json:"status,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 type SeriesListResponse struct {
-	Total int64            `json:"total"`
-	Items []SeriesListItem `json:"items"`
+	httputil.PaginatedResponse[SeriesListItem]
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// seriesCursor is the keyset position encoded in the opaque next_cursor token
+type seriesCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+func encodeSeriesCursor(c seriesCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSeriesCursor(token string) (seriesCursor, error) {
+	var c seriesCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.ID == "" || c.CreatedAt.IsZero() {
+		return c, fmt.Errorf("incomplete cursor")
+	}
+	return c, nil
 }
 
 type UploadUrlRequest struct {
+	EpisodeID   *string                `json:"episode_id"`
 	Filename    string                 `json:"filename"`
 	ContentType string                 `json:"content_type"`
 	SizeBytes   int64                  `json:"size_bytes"`
@@ -104,12 +192,38 @@ type UploadNotifyRequest struct {
 }
 
 type UploadNotifyResponse struct {
-	Status string `json:"status"`
+	Status string  `json:"status"`
+	JobID  *string `json:"job_id,omitempty"`
+}
+
+type TranscodingJobResponse struct {
+	ID           string     `json:"id"`
+	EpisodeID    string     `json:"episode_id"`
+	Status       string     `json:"status"`
+	Progress     int        `json:"progress"`
+	ErrorMessage *string    `json:"error_message"`
+	StartedAt    *time.Time `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 type ManifestResponse struct {
-	ManifestURL string    `json:"manifest_url"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	// ManifestURL is the master playlist, which lets the player switch qualities itself
+	ManifestURL string         `json:"manifest_url"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	Renditions  []Rendition    `json:"renditions,omitempty"`
+	CaptionsURL *string        `json:"captions_url"`
+	Captions    []CaptionTrack `json:"captions"`
+	EpisodeNavigation
+}
+
+// Rendition is a single-quality variant playlist of an episode
+type Rendition struct {
+	Quality     string `json:"quality"`
+	Height      int    `json:"height"`
+	ManifestURL string `json:"manifest_url"`
+	Default     bool   `json:"default"`
 }
 
 // CreateSeries creates a new series
@@ -117,30 +231,48 @@ func (h *ContentHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	var req CreateSeriesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	// Validate required fields
-	if req.Title == "" || req.Synopsis == "" || req.Language == "" {
-		http.Error(w, "Title, synopsis, and language are required", http.StatusBadRequest)
+	language, ok := normalizeLanguage(req.Language)
+	if !ok {
+		writeUnsupportedLanguage(w)
+		return
+	}
+	req.Language = language
+
+	if err := validateSeriesPricing(req.PriceType, req.PriceAmount); err != nil {
+		httputil.WriteValidationError(w, map[string]httputil.FieldError{
+			"price_amount": {Rule: "pricing", Message: err.Error()},
+		})
+		return
+	}
+
+	if req.ThumbnailURL != nil && !validThumbnailURL(*req.ThumbnailURL, h.thumbnailHosts) {
+		httputil.WriteValidationError(w, map[string]httputil.FieldError{
+			"thumbnail_url": {Rule: "allowed_host", Message: "thumbnail_url must be an http(s) URL on an allowed host"},
+		})
 		return
 	}
 
 	// Check if user is a creator
 	var creatorProfile models.CreatorProfile
-	if err := h.db.Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "User must be onboarded as a creator first", http.StatusForbidden)
+			httputil.WriteError(w, http.StatusForbidden, httputil.CodeForbidden, "User must be onboarded as a creator first")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	if !checkPaidKYC(w, creatorProfile.KYCStatus, req.PriceType) {
 		return
 	}
 
@@ -157,8 +289,8 @@ func (h *ContentHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
 		Status:       "draft",
 	}
 
-	if err := h.db.Create(&series).Error; err != nil {
-		http.Error(w, "Failed to create series", http.StatusInternalServerError)
+	if err := h.db.WithContext(r.Context()).Create(&series).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create series")
 		return
 	}
 
@@ -170,29 +302,69 @@ func (h *ContentHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
 // ListSeries lists series with optional filters
 func (h *ContentHandler) ListSeries(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
-	language := r.URL.Query().Get("language")
+	language := strings.ToLower(r.URL.Query().Get("language"))
 	category := r.URL.Query().Get("category")
-	pageStr := r.URL.Query().Get("page")
-	perPageStr := r.URL.Query().Get("per_page")
-
-	// Set defaults
-	page := 1
-	perPage := 20
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	sort := r.URL.Query().Get("sort")
+	priceType := r.URL.Query().Get("price_type")
+	creatorID := r.URL.Query().Get("creator_id")
+	cursorStr := r.URL.Query().Get("cursor")
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	if sort != "" && seriesSortOrders[sort] == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid sort; expected one of newest, oldest, title, popularity")
+		return
+	}
 
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+	// Keyset pagination walks (created_at, id) in descending order, so it only
+	// applies to newest-first listings
+	var cursor *seriesCursor
+	if cursorStr != "" {
+		if sort != "" && sort != "newest" {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "cursor pagination only supports sort=newest")
+			return
 		}
+		c, err := decodeSeriesCursor(cursorStr)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid cursor")
+			return
+		}
+		cursor = &c
+		sort = "newest"
 	}
 
-	if perPageStr != "" {
-		if pp, err := strconv.Atoi(perPageStr); err == nil && pp > 0 && pp <= 100 {
-			perPage = pp
-		}
+	if priceType != "" && !validPriceTypes[priceType] {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid price_type; expected one of free, subscription, one_time")
+		return
+	}
+
+	// Postgres rejects a malformed uuid with an error, which must not surface as a 500
+	if creatorID != "" && uuid.Validate(creatorID) != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid creator_id; expected a UUID")
+		return
+	}
+
+	// Listings are the same for every caller, so they can be served from the
+	// shared cache. The key covers every filter so queries never share results.
+	cacheKey := h.seriesCache.key(r.Context(), url.Values{
+		"language":   {language},
+		"category":   {category},
+		"q":          {search},
+		"sort":       {sort},
+		"price_type": {priceType},
+		"creator_id": {creatorID},
+		"cursor":     {cursorStr},
+		"page":       {strconv.Itoa(page)},
+		"per_page":   {strconv.Itoa(perPage)},
+	})
+	if body, ok := h.seriesCache.get(r.Context(), cacheKey); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+		return
 	}
 
 	// Build query
-	query := h.db.Model(&models.Series{}).Where("status = ?", "published").
+	query := h.db.WithContext(r.Context()).Model(&models.Series{}).Where("status = ?", "published").
 		Preload("Creator").
 		Preload("Episodes", "status = ?", "published")
 
@@ -204,63 +376,239 @@ func (h *ContentHandler) ListSeries(w http.ResponseWriter, r *http.Request) {
 		query = query.Where("? = ANY(category_tags)", category)
 	}
 
+	if priceType != "" {
+		query = query.Where("price_type = ?", priceType)
+	}
+
+	if creatorID != "" {
+		query = query.Where("creator_id = ?", creatorID)
+	}
+
+	// Substring match catches partial words; full-text match handles multi-word queries in any order
+	if search != "" {
+		like := "%" + escapeLike(search) + "%"
+		query = query.Where("(title ILIKE ? OR synopsis ILIKE ? OR "+seriesSearchVector+" @@ plainto_tsquery('simple', ?))",
+			like, like, search)
+	}
+
 	// Get total count
 	var total int64
 	query.Count(&total)
 
+	// An explicit sort wins; otherwise searches are ordered by relevance and listings by recency
+	newestFirst := sort == "newest" || (sort == "" && search == "")
+	if sort != "" {
+		query = query.Order(seriesSortOrders[sort])
+	} else if search != "" {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(" + seriesSearchVector + ", plainto_tsquery('simple', ?)) DESC, (title ILIKE ?) DESC, created_at DESC",
+			Vars:               []interface{}{search, "%" + escapeLike(search) + "%"},
+			WithoutParentheses: true,
+		}})
+	} else {
+		query = query.Order(seriesSortOrders["newest"])
+	}
+
 	// Get paginated results
 	var seriesRows []models.Series
-	offset := (page - 1) * perPage
-	if err := query.Offset(offset).Limit(perPage).Find(&seriesRows).Error; err != nil {
-		http.Error(w, "Failed to fetch series", http.StatusInternalServerError)
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	} else {
+		query = query.Offset(offset)
+	}
+	if err := query.Limit(perPage).Find(&seriesRows).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch series")
 		return
 	}
 
+	// Offer a cursor for the next page whenever the listing is newest-first and this page is full
+	var nextCursor *string
+	if newestFirst && len(seriesRows) == perPage {
+		last := seriesRows[len(seriesRows)-1]
+		token := encodeSeriesCursor(seriesCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		nextCursor = &token
+	}
+
 	items := make([]SeriesListItem, 0, len(seriesRows))
 	for _, s := range seriesRows {
-		var creatorName *string
-		if s.Creator != nil {
-			creatorName = &s.Creator.DisplayName
-		}
-
-		eps := make([]EpisodeBrief, 0, len(s.Episodes))
-		for _, ep := range s.Episodes {
-			eps = append(eps, EpisodeBrief{
-				ID:              ep.ID,
-				Title:           ep.Title,
-				EpisodeNumber:   ep.EpisodeNumber,
-				DurationSeconds: ep.DurationSeconds,
-				ThumbURL:        ep.ThumbURL,
-				PublishedAt:     ep.PublishedAt,
-				CreatedAt:       ep.CreatedAt,
-			})
-		}
+		items = append(items, toSeriesListItem(s))
+	}
+	if err := attachSeriesRatings(h.db.WithContext(r.Context()), items); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ratings")
+		return
+	}
 
-		items = append(items, SeriesListItem{
-			ID:           s.ID,
-			CreatorID:    s.CreatorID,
-			CreatorName:  creatorName,
-			Title:        s.Title,
-			Synopsis:     s.Synopsis,
-			Language:     s.Language,
-			CategoryTags: s.CategoryTags,
-			PriceType:    s.PriceType,
-			PriceAmount:  s.PriceAmount,
-			ThumbnailURL: s.ThumbnailURL,
-			Status:       s.Status,
-			CreatedAt:    s.CreatedAt,
-			UpdatedAt:    s.UpdatedAt,
-			Episodes:     eps,
+	response := SeriesListResponse{
+		PaginatedResponse: httputil.NewPaginatedResponse(items, total, page, perPage),
+		NextCursor:        nextCursor,
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to encode series")
+		return
+	}
+	body = append(body, '\n')
+	h.seriesCache.set(r.Context(), cacheKey, body)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// toSeriesListItem flattens a series with its preloaded Creator and Episodes
+func toSeriesListItem(s models.Series) SeriesListItem {
+	var creatorName *string
+	if s.Creator != nil {
+		creatorName = &s.Creator.DisplayName
+	}
+
+	eps := make([]EpisodeBrief, 0, len(s.Episodes))
+	for _, ep := range s.Episodes {
+		eps = append(eps, EpisodeBrief{
+			ID:              ep.ID,
+			Title:           ep.Title,
+			EpisodeNumber:   ep.EpisodeNumber,
+			DurationSeconds: ep.DurationSeconds,
+			ThumbURL:        ep.ThumbURL,
+			ViewCount:       ep.ViewCount,
+			PublishedAt:     ep.PublishedAt,
+			CreatedAt:       ep.CreatedAt,
 		})
 	}
 
-	response := SeriesListResponse{
-		Total: total,
-		Items: items,
+	return SeriesListItem{
+		ID:           s.ID,
+		CreatorID:    s.CreatorID,
+		CreatorName:  creatorName,
+		Title:        s.Title,
+		Synopsis:     s.Synopsis,
+		Language:     s.Language,
+		CategoryTags: s.CategoryTags,
+		PriceType:    s.PriceType,
+		PriceAmount:  s.PriceAmount,
+		ThumbnailURL: s.ThumbnailURL,
+		Status:       s.Status,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		Episodes:     eps,
+	}
+}
+
+type seriesRating struct {
+	SeriesID      string
+	AverageRating float64
+	RatingCount   int64
+}
+
+// seriesRatings averages the ratings of each series' published episodes.
+// Series without ratings are absent from the result.
+func seriesRatings(db *gorm.DB, seriesIDs []string) (map[string]seriesRating, error) {
+	ratings := make(map[string]seriesRating, len(seriesIDs))
+	if len(seriesIDs) == 0 {
+		return ratings, nil
+	}
+
+	var rows []seriesRating
+	if err := db.Model(&models.EpisodeRating{}).
+		Select("episodes.series_id, ROUND(AVG(episode_ratings.score)::numeric, 1) AS average_rating, COUNT(*) AS rating_count").
+		Joins("JOIN episodes ON episodes.id = episode_ratings.episode_id AND episodes.deleted_at IS NULL").
+		Where("episodes.series_id IN ? AND episodes.status = ?", seriesIDs, "published").
+		Group("episodes.series_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		ratings[row.SeriesID] = row
+	}
+	return ratings, nil
+}
+
+// attachSeriesRatings fills in the rating fields of list items with one query
+func attachSeriesRatings(db *gorm.DB, items []SeriesListItem) error {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	ratings, err := seriesRatings(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].AverageRating = ratings[items[i].ID].AverageRating
+		items[i].RatingCount = ratings[items[i].ID].RatingCount
+	}
+	return nil
+}
+
+// seriesSortOrders maps the sort query param to ORDER BY clauses. id breaks
+// ties so pagination is stable across pages.
+var seriesSortOrders = map[string]string{
+	"newest": "created_at DESC, id DESC",
+	"oldest": "created_at ASC, id ASC",
+	"title":  "title ASC, id ASC",
+	"popularity": "((SELECT COUNT(*) FROM episode_likes JOIN episodes ON episodes.id = episode_likes.episode_id " +
+		"WHERE episodes.series_id = series.id AND episode_likes.deleted_at IS NULL) + " +
+		"(SELECT COUNT(*) FROM subscriptions WHERE subscriptions.series_id = series.id " +
+		"AND subscriptions.status = 'active' AND subscriptions.deleted_at IS NULL)) DESC, created_at DESC, id DESC",
+}
+
+var validPriceTypes = map[string]bool{
+	"free":         true,
+	"subscription": true,
+	"one_time":     true,
+}
+
+// validateSeriesPricing checks that price_type is known and price_amount fits it:
+// free series carry no price, paid ones a positive price
+func validateSeriesPricing(priceType string, priceAmount *float64) error {
+	if !validPriceTypes[priceType] {
+		return errors.New("price_type must be one of free, subscription, one_time")
+	}
+	if priceType == "free" {
+		if priceAmount != nil && *priceAmount != 0 {
+			return errors.New("price_amount must be empty or 0 for free series")
+		}
+		return nil
+	}
+	if priceAmount == nil || *priceAmount <= 0 {
+		return fmt.Errorf("price_amount must be greater than 0 for %s series", priceType)
+	}
+	return nil
+}
+
+// seriesSearchVector weights titles above synopses for ranking
+const seriesSearchVector = "(setweight(to_tsvector('simple', coalesce(title, '')), 'A') || setweight(to_tsvector('simple', coalesce(synopsis, '')), 'B'))"
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+type CategoryCount struct {
+	Name        string `json:"name"`
+	SeriesCount int64  `json:"series_count"`
+}
+
+type CategoriesResponse struct {
+	Categories []CategoryCount `json:"categories"`
+}
+
+// ListCategories returns the distinct category tags of published series with
+// how many series use each, most used first. Names match the category filter
+// of ListSeries exactly.
+func (h *ContentHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories := make([]CategoryCount, 0)
+	if err := h.db.WithContext(r.Context()).Raw(`SELECT tag AS name, COUNT(*) AS series_count
+		FROM series, unnest(series.category_tags) AS tag
+		WHERE series.status = ? AND series.deleted_at IS NULL AND tag <> ''
+		GROUP BY tag
+		ORDER BY series_count DESC, tag ASC`, "published").
+		Scan(&categories).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch categories")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(CategoriesResponse{Categories: categories})
 }
 
 // GetSeries gets a specific series by ID
@@ -269,30 +617,55 @@ func (h *ContentHandler) GetSeries(w http.ResponseWriter, r *http.Request) {
 	seriesID := vars["id"]
 
 	var series models.Series
-	if err := h.db.Preload("Creator").Preload("Episodes", "status = ?", "published").Where("id = ?", seriesID).First(&series).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Preload("Creator").Where("id = ?", seriesID).First(&series).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Series not found", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// The owning creator previews everything; everyone else sees the published view
+	userID, _ := r.Context().Value("user_id").(string)
+	isOwner, err := h.ownsSeries(r.Context(), userID, series)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if !isOwner && series.Status != "published" {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found")
+		return
+	}
+
+	episodeQuery := h.db.WithContext(r.Context()).Where("series_id = ?", series.ID).Order("episode_number")
+	if !isOwner {
+		episodeQuery = episodeQuery.Where("status = ?", "published")
+	}
+	if err := episodeQuery.Find(&series.Episodes).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
 	type SeriesDetailResponse struct {
-		ID           string         `json:"id"`
-		CreatorID    string         `json:"creator_id"`
-		CreatorName  *string        `json:"creator_name"`
-		Title        string         `json:"title"`
-		Synopsis     string         `json:"synopsis"`
-		Language     string         `json:"language"`
-		CategoryTags pq.StringArray `json:"category_tags"`
-		PriceType    string         `json:"price_type"`
-		PriceAmount  *float64       `json:"price_amount"`
-		ThumbnailURL *string        `json:"thumbnail_url"`
-		Status       string         `json:"status"`
-		CreatedAt    time.Time      `json:"created_at"`
-		UpdatedAt    time.Time      `json:"updated_at"`
-		Episodes     []EpisodeBrief `json:"episodes"`
+		ID            string         `json:"id"`
+		CreatorID     string         `json:"creator_id"`
+		CreatorName   *string        `json:"creator_name"`
+		Title         string         `json:"title"`
+		Synopsis      string         `json:"synopsis"`
+		Language      string         `json:"language"`
+		CategoryTags  pq.StringArray `json:"category_tags"`
+		PriceType     string         `json:"price_type"`
+		PriceAmount   *float64       `json:"price_amount"`
+		ThumbnailURL  *string        `json:"thumbnail_url"`
+		Status        string         `json:"status"`
+		CreatedAt     time.Time      `json:"created_at"`
+		UpdatedAt     time.Time      `json:"updated_at"`
+		Version       int            `json:"version"`
+		ViewCount     int64          `json:"view_count"`
+		AverageRating float64        `json:"average_rating"`
+		RatingCount   int64          `json:"rating_count"`
+		Episodes      []EpisodeBrief `json:"episodes"`
 	}
 
 	var creatorName *string
@@ -300,40 +673,165 @@ func (h *ContentHandler) GetSeries(w http.ResponseWriter, r *http.Request) {
 		creatorName = &series.Creator.DisplayName
 	}
 
+	var viewCount int64
 	eps := make([]EpisodeBrief, 0, len(series.Episodes))
 	for _, ep := range series.Episodes {
-		eps = append(eps, EpisodeBrief{
+		viewCount += ep.ViewCount
+		brief := EpisodeBrief{
 			ID:              ep.ID,
 			Title:           ep.Title,
 			EpisodeNumber:   ep.EpisodeNumber,
 			DurationSeconds: ep.DurationSeconds,
 			ThumbURL:        ep.ThumbURL,
+			ViewCount:       ep.ViewCount,
 			PublishedAt:     ep.PublishedAt,
 			CreatedAt:       ep.CreatedAt,
-		})
+		}
+		if isOwner {
+			brief.Status = ep.Status
+		}
+		eps = append(eps, brief)
+	}
+
+	ratings, err := seriesRatings(h.db.WithContext(r.Context()), []string{series.ID})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
 	}
 
 	resp := SeriesDetailResponse{
-		ID:           series.ID,
-		CreatorID:    series.CreatorID,
-		CreatorName:  creatorName,
-		Title:        series.Title,
-		Synopsis:     series.Synopsis,
-		Language:     series.Language,
-		CategoryTags: series.CategoryTags,
-		PriceType:    series.PriceType,
-		PriceAmount:  series.PriceAmount,
-		ThumbnailURL: series.ThumbnailURL,
-		Status:       series.Status,
-		CreatedAt:    series.CreatedAt,
-		UpdatedAt:    series.UpdatedAt,
-		Episodes:     eps,
+		ID:            series.ID,
+		CreatorID:     series.CreatorID,
+		CreatorName:   creatorName,
+		Title:         series.Title,
+		Synopsis:      series.Synopsis,
+		Language:      series.Language,
+		CategoryTags:  series.CategoryTags,
+		PriceType:     series.PriceType,
+		PriceAmount:   series.PriceAmount,
+		ThumbnailURL:  series.ThumbnailURL,
+		Status:        series.Status,
+		CreatedAt:     series.CreatedAt,
+		UpdatedAt:     series.UpdatedAt,
+		Version:       series.Version,
+		ViewCount:     viewCount,
+		AverageRating: ratings[series.ID].AverageRating,
+		RatingCount:   ratings[series.ID].RatingCount,
+		Episodes:      eps,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+type EpisodeDetailResponse struct {
+	ID              string         `json:"id"`
+	SeriesID        string         `json:"series_id"`
+	Series          SeriesSummary  `json:"series"`
+	Title           string         `json:"title"`
+	EpisodeNumber   int            `json:"episode_number"`
+	DurationSeconds int            `json:"duration_seconds"`
+	ThumbURL        *string        `json:"thumb_url"`
+	CaptionsURL     *string        `json:"captions_url"`
+	Captions        []CaptionTrack `json:"captions"`
+	Status          string         `json:"status"`
+	ViewCount       int64          `json:"view_count"`
+	Version         int            `json:"version"`
+	PublishedAt     *time.Time     `json:"published_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	EpisodeNavigation
+}
+
+// GetEpisode returns a single episode. Unpublished episodes are only visible
+// to the creator who owns the series.
+func (h *ContentHandler) GetEpisode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Preload("Series").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// A soft-deleted series hides its episodes too
+	if episode.Series.ID == "" {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+		return
+	}
+
+	if episode.Status != "published" || episode.Series.Status != "published" {
+		userID, _ := r.Context().Value("user_id").(string)
+		isOwner, err := h.ownsSeries(r.Context(), userID, episode.Series)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		if !isOwner {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+	}
+
+	captions, err := h.captionTracks(r.Context(), episode.ID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	nav, err := h.episodeNavigation(r.Context(), episode)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	response := EpisodeDetailResponse{
+		ID:       episode.ID,
+		SeriesID: episode.SeriesID,
+		Series: SeriesSummary{
+			ID:           episode.Series.ID,
+			Title:        episode.Series.Title,
+			ThumbnailURL: episode.Series.ThumbnailURL,
+		},
+		Title:             episode.Title,
+		EpisodeNumber:     episode.EpisodeNumber,
+		DurationSeconds:   episode.DurationSeconds,
+		ThumbURL:          episode.ThumbURL,
+		CaptionsURL:       episode.CaptionsURL,
+		Captions:          captions,
+		Status:            episode.Status,
+		ViewCount:         episode.ViewCount,
+		Version:           episode.Version,
+		PublishedAt:       episode.PublishedAt,
+		CreatedAt:         episode.CreatedAt,
+		UpdatedAt:         episode.UpdatedAt,
+		EpisodeNavigation: nav,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ownsSeries reports whether userID is the creator behind the series.
+// Anonymous requests (empty userID) never own anything.
+func (h *ContentHandler) ownsSeries(ctx context.Context, userID string, series models.Series) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+
+	var count int64
+	if err := h.db.WithContext(ctx).Model(&models.CreatorProfile{}).
+		Where("id = ? AND user_id = ?", series.CreatorID, userID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // UpdateSeries updates a series
 func (h *ContentHandler) UpdateSeries(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -342,26 +840,39 @@ func (h *ContentHandler) UpdateSeries(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	var req UpdateSeriesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
+	if req.Language != nil {
+		language, ok := normalizeLanguage(*req.Language)
+		if !ok {
+			writeUnsupportedLanguage(w)
+			return
+		}
+		req.Language = &language
+	}
 
 	// Check if series exists and user owns it
 	var series models.Series
-	if err := h.db.Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+	if err := h.db.WithContext(r.Context()).Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
 		Where("series.id = ? AND creator_profiles.user_id = ?", seriesID, userID).
 		First(&series).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Series not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	expected, err := expectedVersion(r, req.Version)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -379,244 +890,693 @@ func (h *ContentHandler) UpdateSeries(w http.ResponseWriter, r *http.Request) {
 	if req.CategoryTags != nil {
 		updates["category_tags"] = pq.StringArray(*req.CategoryTags)
 	}
-	if req.PriceType != nil {
-		updates["price_type"] = *req.PriceType
-	}
-	if req.PriceAmount != nil {
-		updates["price_amount"] = *req.PriceAmount
+	if req.PriceType != nil || req.PriceAmount != nil {
+		priceType, priceAmount := series.PriceType, series.PriceAmount
+		if req.PriceType != nil {
+			priceType = *req.PriceType
+			// Switching to free drops the old price unless one is given explicitly
+			if priceType == "free" && req.PriceAmount == nil {
+				priceAmount = nil
+			}
+		}
+		if req.PriceAmount != nil {
+			priceAmount = req.PriceAmount
+		}
+		if err := validateSeriesPricing(priceType, priceAmount); err != nil {
+			// Report the field CreateSeries would: its struct tags catch an unknown price_type
+			field := httputil.FieldError{Rule: "pricing", Message: err.Error()}
+			name := "price_amount"
+			if !validPriceTypes[priceType] {
+				field.Rule, field.Param, name = "oneof", "free subscription one_time", "price_type"
+			}
+			httputil.WriteValidationError(w, map[string]httputil.FieldError{name: field})
+			return
+		}
+		if !h.checkSeriesPaidKYC(r.Context(), w, series, priceType) {
+			return
+		}
+		updates["price_type"] = priceType
+		updates["price_amount"] = priceAmount
 	}
 	if req.ThumbnailURL != nil {
+		if !validThumbnailURL(*req.ThumbnailURL, h.thumbnailHosts) {
+			httputil.WriteValidationError(w, map[string]httputil.FieldError{
+				"thumbnail_url": {Rule: "allowed_host", Message: "thumbnail_url must be an http(s) URL on an allowed host"},
+			})
+			return
+		}
 		updates["thumbnail_url"] = *req.ThumbnailURL
 	}
 	if req.Status != nil {
+		if *req.Status == "published" && series.Status != "published" {
+			if !h.checkPublishable(r.Context(), w, series.ID) {
+				return
+			}
+			// A price change was checked above
+			if _, repriced := updates["price_type"]; !repriced && !h.checkSeriesPaidKYC(r.Context(), w, series, series.PriceType) {
+				return
+			}
+		}
 		updates["status"] = *req.Status
 	}
 
-	updates["updated_at"] = time.Now()
+	publishing := req.Status != nil && *req.Status == "published" && series.Status != "published"
+	updated, err := versionedUpdate(h.db.WithContext(r.Context()), &series, expected, updates)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update series")
+		return
+	}
+	if !updated {
+		writeStaleVersion(w, h.db.WithContext(r.Context()), &models.Series{}, series.ID)
+		return
+	}
+	h.seriesCache.invalidate(r.Context())
+	if publishing {
+		metrics.SeriesPublished.Inc()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Series updated successfully",
+		"id":      series.ID,
+		"version": series.Version,
+	})
+}
+
+var errEpisodeNumberTaken = errors.New("episode number taken")
+
+// CreateEpisode creates episode metadata for a series. Without an
+// episode_number the episode is numbered after the series' last episode.
+func (h *ContentHandler) CreateEpisode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesID := vars["id"]
+
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req CreateEpisodeRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	// Check if series exists and user owns it
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("series.id = ? AND creator_profiles.user_id = ?", seriesID, userID).
+		First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	episode := models.Episode{
+		SeriesID:               seriesID,
+		Title:                  req.Title,
+		EpisodeNumber:          req.EpisodeNumber,
+		DurationSeconds:        req.DurationSeconds,
+		ClaimedDurationSeconds: &req.DurationSeconds,
+		Status:                 "pending_upload",
+	}
+
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the series so concurrent creates cannot pick the same number
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", series.ID).First(&models.Series{}).Error; err != nil {
+			return err
+		}
+
+		if episode.EpisodeNumber == 0 {
+			if err := tx.Model(&models.Episode{}).Where("series_id = ?", series.ID).
+				Select("COALESCE(MAX(episode_number), 0) + 1").Scan(&episode.EpisodeNumber).Error; err != nil {
+				return err
+			}
+		} else {
+			var count int64
+			if err := tx.Model(&models.Episode{}).Where("series_id = ? AND episode_number = ?", series.ID, episode.EpisodeNumber).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errEpisodeNumberTaken
+			}
+		}
+
+		return tx.Create(&episode).Error
+	})
+	if err != nil {
+		if err == errEpisodeNumberTaken {
+			httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "Episode number already exists for this series")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create episode")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(episode)
+}
+
+// RequestUploadURL generates a pre-signed upload URL
+func (h *ContentHandler) RequestUploadURL(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	key, err := idempotencyKey(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// A retried request gets a fresh URL for the upload created by the first attempt
+	if key != "" {
+		uploadID, found, err := findIdempotentResource(h.db.WithContext(r.Context()), userID, idempotencyScopeUpload, key)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		if found {
+			var uploadReq models.UploadRequest
+			if err := h.db.WithContext(r.Context()).Where("id = ? AND user_id = ?", uploadID, userID).First(&uploadReq).Error; err == nil {
+				h.writeUploadURL(w, r, uploadReq)
+				return
+			}
+		}
+	}
+
+	var req UploadUrlRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	// Validate required fields
+	if req.Filename == "" || req.ContentType == "" || req.SizeBytes <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Filename, content type, and size are required")
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.ContentType)
+	if err != nil || !slices.Contains(h.uploadLimits.ContentTypes, mediaType) {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest,
+			fmt.Sprintf("Content type must be one of: %s", strings.Join(h.uploadLimits.ContentTypes, ", ")))
+		return
+	}
+	if h.uploadLimits.MaxBytes > 0 && req.SizeBytes > h.uploadLimits.MaxBytes {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest,
+			fmt.Sprintf("File is too large: uploads are limited to %d bytes", h.uploadLimits.MaxBytes))
+		return
+	}
+
+	// Check if user is a creator
+	var creatorProfile models.CreatorProfile
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusForbidden, httputil.CodeForbidden, "User must be onboarded as a creator first")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// Optionally tie the upload to one of the creator's episodes
+	if req.EpisodeID != nil {
+		var episode models.Episode
+		if err := h.db.WithContext(r.Context()).Joins("JOIN series ON episodes.series_id = series.id").
+			Where("episodes.id = ? AND series.creator_id = ?", *req.EpisodeID, creatorProfile.ID).
+			First(&episode).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
+				return
+			}
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+	}
+
+	if h.storage == nil && !h.mockUploads {
+		httputil.WriteError(w, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Upload storage is not configured")
+		return
+	}
+
+	// Generate upload ID up front so it can be part of the object key
+	uploadID := uuid.New().String()
+	s3Key := fmt.Sprintf("uploads/%s/%s/%s", userID, uploadID, path.Base(req.Filename))
+
+	// Create upload request record
+	uploadReq := models.UploadRequest{
+		ID:          uploadID,
+		UserID:      userID,
+		EpisodeID:   req.EpisodeID,
+		Filename:    req.Filename,
+		ContentType: mediaType,
+		SizeBytes:   req.SizeBytes,
+		Metadata:    req.Metadata,
+		S3Key:       s3Key,
+		Status:      "pending",
+	}
+
+	// The upload and its idempotency key are saved together, so a retry can
+	// never create a second upload for a key whose first attempt succeeded
+	err = h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if key == "" {
+			return tx.Create(&uploadReq).Error
+		}
+
+		// Lock the user so concurrent retries with the same key serialize here
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", userID).First(&models.User{}).Error; err != nil {
+			return err
+		}
+		existingID, found, err := findIdempotentResource(tx, userID, idempotencyScopeUpload, key)
+		if err != nil {
+			return err
+		}
+		if found {
+			// A concurrent attempt won: return its upload instead of a new one
+			err := tx.Where("id = ? AND user_id = ?", existingID, userID).First(&uploadReq).Error
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+		}
+
+		if err := tx.Create(&uploadReq).Error; err != nil {
+			return err
+		}
+		return saveIdempotentResource(tx, userID, idempotencyScopeUpload, key, uploadReq.ID)
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create upload request")
+		return
+	}
+
+	h.writeUploadURL(w, r, uploadReq)
+}
+
+// writeUploadURL presigns a PUT URL for the upload's object key and writes the response
+func (h *ContentHandler) writeUploadURL(w http.ResponseWriter, r *http.Request, uploadReq models.UploadRequest) {
+	var presignedURL string
+	if h.storage != nil {
+		url, err := h.storage.PresignPut(r.Context(), uploadReq.S3Key, uploadReq.ContentType, uploadReq.SizeBytes, uploadURLExpiration)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate upload URL")
+			return
+		}
+		presignedURL = url
+	} else {
+		// Local development without AWS credentials (S3_MOCK_UPLOADS=true)
+		presignedURL = fmt.Sprintf("https://s3.amazonaws.com/bucket/%s?AWSAccessKeyId=mock&Signature=mock", uploadReq.S3Key)
+	}
+
+	response := UploadUrlResponse{
+		UploadID:     uploadReq.ID,
+		PresignedURL: presignedURL,
+		ExpiresIn:    int(uploadURLExpiration.Seconds()),
+		UploadHeaders: map[string]string{
+			"Content-Type": uploadReq.ContentType,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// errUploadProcessed is returned when an upload was already reported complete or failed
+var errUploadProcessed = errors.New("upload already processed")
+
+// NotifyUploadComplete handles upload completion notification
+func (h *ContentHandler) NotifyUploadComplete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uploadID := vars["upload_id"]
+
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req UploadNotifyRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	// Validate required fields
+	if req.S3Path == "" || req.SizeBytes <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "S3 path and size are required")
+		return
+	}
+
+	var uploadReq models.UploadRequest
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND user_id = ?", uploadID, userID).First(&uploadReq).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Upload not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// The reported path must be the object we issued the upload URL for
+	bucket := ""
+	if h.storage != nil {
+		bucket = h.storage.Bucket()
+	}
+	if key, ok := storage.KeyFromPath(bucket, req.S3Path); !ok || key != uploadReq.S3Key {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "S3 path does not match the upload request")
+		return
+	}
+
+	var job *models.TranscodingJob
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Only one notify can move the upload on, so a repeat never enqueues twice
+		res := tx.Model(&models.UploadRequest{}).
+			Where("id = ? AND status NOT IN ?", uploadReq.ID, []string{"completed", "failed"}).
+			Update("status", "completed")
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errUploadProcessed
+		}
+
+		// Uploads that are not tied to an episode (e.g. documents) need no transcoding
+		if uploadReq.EpisodeID == nil {
+			return nil
+		}
+
+		job = &models.TranscodingJob{
+			EpisodeID: *uploadReq.EpisodeID,
+			UploadID:  uploadReq.ID,
+			InputPath: req.S3Path,
+			Status:    models.TranscodingStatusPending,
+		}
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+
+		// A published episode stays live on its current media until the new
+		// upload has been transcoded
+		return tx.Model(&models.Episode{}).Where("id = ?", *uploadReq.EpisodeID).
+			Updates(map[string]interface{}{
+				"status":         gorm.Expr("CASE WHEN status = 'published' THEN status ELSE 'queued_transcode' END"),
+				"s3_master_path": req.S3Path,
+			}).Error
+	})
+	if err == errUploadProcessed {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "Upload has already been processed")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update upload status")
+		return
+	}
+
+	response := UploadNotifyResponse{
+		Status: "completed",
+	}
+	if job != nil {
+		response.Status = "queued_for_transcoding"
+		response.JobID = &job.ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+type EpisodeStatusResponse struct {
+	EpisodeID           string  `json:"episode_id"`
+	Status              string  `json:"status"`
+	TranscodingStatus   *string `json:"transcoding_status"`
+	TranscodingProgress int     `json:"transcoding_progress"`
+	TranscodingError    *string `json:"transcoding_error,omitempty"`
+	ManifestAvailable   bool    `json:"manifest_available"`
+}
+
+// GetEpisodeStatus returns just enough for the owning creator to poll an
+// episode after upload: its status, the latest transcoding job's progress and
+// whether a manifest exists
+func (h *ContentHandler) GetEpisodeStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Select("episodes.id", "episodes.status", "episodes.hls_manifest_url").
+		Joins("JOIN series ON episodes.series_id = series.id").
+		Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("episodes.id = ? AND creator_profiles.user_id = ?", mux.Vars(r)["id"], userID).
+		First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	response := EpisodeStatusResponse{
+		EpisodeID:         episode.ID,
+		Status:            episode.Status,
+		ManifestAvailable: episode.HLSManifestURL != nil && *episode.HLSManifestURL != "",
+	}
 
-	if err := h.db.Model(&series).Updates(updates).Error; err != nil {
-		http.Error(w, "Failed to update series", http.StatusInternalServerError)
+	var jobs []models.TranscodingJob
+	if err := h.db.WithContext(r.Context()).Select("status", "progress", "error_message").
+		Where("episode_id = ?", episode.ID).Order("created_at DESC").Limit(1).Find(&jobs).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
+	if len(jobs) > 0 {
+		response.TranscodingStatus = &jobs[0].Status
+		response.TranscodingProgress = jobs[0].Progress
+		response.TranscodingError = jobs[0].ErrorMessage
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Series updated successfully"})
+	json.NewEncoder(w).Encode(response)
 }
 
-// CreateEpisode creates episode metadata for a series
-func (h *ContentHandler) CreateEpisode(w http.ResponseWriter, r *http.Request) {
+// GetTranscodingJob returns the progress of a transcoding job for the owning creator
+func (h *ContentHandler) GetTranscodingJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	seriesID := vars["id"]
+	jobID := vars["id"]
 
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
-		return
-	}
-
-	var req CreateEpisodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
-	// Validate required fields
-	if req.Title == "" || req.EpisodeNumber <= 0 || req.DurationSeconds <= 0 {
-		http.Error(w, "Title, episode number, and duration are required", http.StatusBadRequest)
-		return
-	}
-
-	// Check if series exists and user owns it
-	var series models.Series
-	if err := h.db.Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
-		Where("series.id = ? AND creator_profiles.user_id = ?", seriesID, userID).
-		First(&series).Error; err != nil {
+	// Verify ownership via episode -> series -> creator_profiles
+	var job models.TranscodingJob
+	if err := h.db.WithContext(r.Context()).Joins("JOIN episodes ON transcoding_jobs.episode_id = episodes.id").
+		Joins("JOIN series ON episodes.series_id = series.id").
+		Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("transcoding_jobs.id = ? AND creator_profiles.user_id = ?", jobID, userID).
+		First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Series not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Transcoding job not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-
-	// Check if episode number already exists
-	var existingEpisode models.Episode
-	if err := h.db.Where("series_id = ? AND episode_number = ?", seriesID, req.EpisodeNumber).First(&existingEpisode).Error; err == nil {
-		http.Error(w, "Episode number already exists for this series", http.StatusConflict)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
-	// Create episode
-	episode := models.Episode{
-		SeriesID:        seriesID,
-		Title:           req.Title,
-		EpisodeNumber:   req.EpisodeNumber,
-		DurationSeconds: req.DurationSeconds,
-		Status:          "pending_upload",
-	}
-
-	if err := h.db.Create(&episode).Error; err != nil {
-		http.Error(w, "Failed to create episode", http.StatusInternalServerError)
-		return
+	response := TranscodingJobResponse{
+		ID:           job.ID,
+		EpisodeID:    job.EpisodeID,
+		Status:       job.Status,
+		Progress:     job.Progress,
+		ErrorMessage: job.ErrorMessage,
+		StartedAt:    job.StartedAt,
+		CompletedAt:  job.CompletedAt,
+		CreatedAt:    job.CreatedAt,
+		UpdatedAt:    job.UpdatedAt,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(episode)
+	json.NewEncoder(w).Encode(response)
 }
 
-// RequestUploadURL generates a pre-signed upload URL
-func (h *ContentHandler) RequestUploadURL(w http.ResponseWriter, r *http.Request) {
+// GetEpisodeManifest gets signed HLS manifest URL for playback
+func (h *ContentHandler) GetEpisodeManifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
-	var req UploadUrlRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	episode, ok := playableEpisode(w, r, h.db, userID, episodeID)
+	if !ok {
 		return
 	}
 
-	// Validate required fields
-	if req.Filename == "" || req.ContentType == "" || req.SizeBytes <= 0 {
-		http.Error(w, "Filename, content type, and size are required", http.StatusBadRequest)
+	stream, err := h.episodeStream(r.Context(), episode)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
-	// Check if user is a creator
-	var creatorProfile models.CreatorProfile
-	if err := h.db.Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "User must be onboarded as a creator first", http.StatusForbidden)
+	// The manifest URLs carry a playback token covering the whole stream
+	token, err := h.issuePlaybackToken(stream.masterURL, manifestURLExpiration)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to sign manifest URL")
+		return
+	}
+	manifestURL, err := h.tokenURL(token, stream.masterURL)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to sign manifest URL")
+		return
+	}
+
+	renditions := make([]Rendition, 0, len(stream.outputs))
+	for quality, p := range stream.outputs {
+		height := renditionHeight(quality)
+		if height == 0 || p == "" {
+			continue
+		}
+		signed, err := h.tokenURL(token, h.cdnURL(p))
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to sign manifest URL")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+		renditions = append(renditions, Rendition{Quality: quality, Height: height, ManifestURL: signed})
 	}
+	sort.Slice(renditions, func(i, j int) bool { return renditions[i].Height < renditions[j].Height })
 
-	// Generate upload ID
-	uploadID := fmt.Sprintf("upl_%s", uuid.New().String()[:8])
+	if len(renditions) > 0 {
+		var prefs models.UserPreferences
+		quality := models.QualityAuto
+		if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&prefs).Error; err == nil {
+			quality = prefs.QualityPreference
+		}
+		renditions[defaultRendition(renditions, quality)].Default = true
+	}
 
-	// Create upload request record
-	uploadReq := models.UploadRequest{
-		UserID:      userID,
-		Filename:    req.Filename,
-		ContentType: req.ContentType,
-		SizeBytes:   req.SizeBytes,
-		Metadata:    req.Metadata,
-		Status:      "pending",
+	captions, err := h.captionTracks(r.Context(), episode.ID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
 	}
 
-	if err := h.db.Create(&uploadReq).Error; err != nil {
-		http.Error(w, "Failed to create upload request", http.StatusInternalServerError)
+	nav, err := h.episodeNavigation(r.Context(), episode)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
-	// TODO: In production, integrate with AWS S3 to generate actual pre-signed URL
-	// For now, return a mock response
-	response := UploadUrlResponse{
-		UploadID:     uploadID,
-		PresignedURL: fmt.Sprintf("https://s3.amazonaws.com/bucket/%s?AWSAccessKeyId=mock&Signature=mock", uploadID),
-		ExpiresIn:    3600,
-		UploadHeaders: map[string]string{
-			"Content-Type": req.ContentType,
-		},
+	response := ManifestResponse{
+		ManifestURL:       manifestURL,
+		ExpiresAt:         token.ExpiresAt,
+		Renditions:        renditions,
+		CaptionsURL:       episode.CaptionsURL,
+		Captions:          captions,
+		EpisodeNavigation: nav,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// NotifyUploadComplete handles upload completion notification
-func (h *ContentHandler) NotifyUploadComplete(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	uploadID := vars["upload_id"]
-
-	// Get user ID from context
-	userID, ok := r.Context().Value("user_id").(string)
-	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
-		return
+// cdnURL resolves a path relative to the CDN base URL; absolute URLs pass through
+func (h *ContentHandler) cdnURL(p string) string {
+	if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+		return p
 	}
+	return strings.TrimSuffix(h.cdnBaseURL, "/") + "/" + strings.TrimPrefix(p, "/")
+}
 
-	var req UploadNotifyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+// renditionHeight parses the vertical resolution from a label such as "720p".
+// It returns 0 for labels that are not renditions (e.g. "master").
+func renditionHeight(quality string) int {
+	height, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(quality), "p"))
+	if err != nil || height <= 0 {
+		return 0
 	}
+	return height
+}
 
-	// Validate required fields
-	if req.S3Path == "" || req.SizeBytes <= 0 {
-		http.Error(w, "S3 path and size are required", http.StatusBadRequest)
-		return
+// defaultRendition picks the index of the rendition matching the user's quality
+// preference. "auto" or an unavailable quality falls back to the best rendition
+// not above the preference, or the highest available.
+func defaultRendition(renditions []Rendition, preference string) int {
+	preferred, err := strconv.Atoi(preference)
+	if err != nil {
+		return len(renditions) - 1
 	}
 
-	// Update upload request status
-	if err := h.db.Model(&models.UploadRequest{}).
-		Where("id = ? AND user_id = ?", uploadID, userID).
-		Updates(map[string]interface{}{
-			"status":     "completed",
-			"updated_at": time.Now(),
-		}).Error; err != nil {
-		http.Error(w, "Failed to update upload status", http.StatusInternalServerError)
-		return
+	best := -1
+	for i, r := range renditions {
+		if r.Height <= preferred {
+			best = i
+		}
 	}
-
-	// TODO: In production, trigger transcoding job here
-	response := UploadNotifyResponse{
-		Status: "queued_for_transcoding",
+	if best < 0 {
+		return 0
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(response)
+	return best
 }
 
-// GetEpisodeManifest gets signed HLS manifest URL for playback
-func (h *ContentHandler) GetEpisodeManifest(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	episodeID := vars["id"]
-
-	// Get user ID from context (for future subscription checks)
-	_, ok := r.Context().Value("user_id").(string)
-	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
-		return
+// signURL signs a CDN URL when CloudFront keys are configured and returns it unchanged otherwise
+func (h *ContentHandler) signURL(rawURL string, expiresAt time.Time) (string, error) {
+	if h.signer == nil {
+		return rawURL, nil
 	}
+	return h.signer.SignURL(rawURL, expiresAt)
+}
 
-	// Get episode with series info
-	var episode models.Episode
-	if err := h.db.Preload("Series").Where("id = ?", episodeID).First(&episode).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Episode not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+// hasSeriesAccess reports whether the user may stream episodes of the series.
+// Free series are open to everyone; anything else needs a completed one-time
+// purchase, which never expires, or a subscription that is still within its
+// paid period (see models.IsSubscriptionActive). Both are honoured whatever
+// the current price type, so changing it does not revoke paid access.
+func hasSeriesAccess(ctx context.Context, db *gorm.DB, userID string, series models.Series) (bool, error) {
+	if series.PriceType == "" || series.PriceType == "free" {
+		return true, nil
 	}
 
-	// Check if episode is ready for playback
-	if episode.Status != "published" {
-		http.Error(w, "Episode not ready for playback", http.StatusBadRequest)
-		return
+	var purchases int64
+	if err := db.WithContext(ctx).Model(&models.Purchase{}).
+		Where("user_id = ? AND series_id = ? AND status = ?", userID, series.ID, models.PurchaseStatusCompleted).
+		Count(&purchases).Error; err != nil {
+		return false, err
+	}
+	if purchases > 0 {
+		return true, nil
 	}
 
-	// TODO: In production, check user subscription status
-	// For now, allow access to all authenticated users
-
-	// TODO: In production, generate actual signed URL with expiration
-	// For now, return a mock response
-	response := ManifestResponse{
-		ManifestURL: fmt.Sprintf("https://cdn.streamshort.com/hls/%s/index.m3u8?Expires=%d&Signature=mock", episodeID, time.Now().Add(1*time.Hour).Unix()),
-		ExpiresAt:   time.Now().Add(1 * time.Hour),
+	var subscriptions []models.Subscription
+	if err := db.WithContext(ctx).Where("user_id = ? AND series_id = ? AND status IN ?", userID, series.ID,
+		[]string{models.SubscriptionStatusActive, models.SubscriptionStatusCancelled}).
+		Find(&subscriptions).Error; err != nil {
+		return false, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	for i := range subscriptions {
+		if models.IsSubscriptionActive(&subscriptions[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // CreatorContentResponse represents the response for creator's content
@@ -636,6 +1596,7 @@ type CreatorSeriesResponse struct {
 	PriceAmount  *float64                 `json:"price_amount"`
 	ThumbnailURL *string                  `json:"thumbnail_url"`
 	Status       string                   `json:"status"`
+	Version      int                      `json:"version"`
 	CreatedAt    time.Time                `json:"created_at"`
 	UpdatedAt    time.Time                `json:"updated_at"`
 	Episodes     []CreatorEpisodeResponse `json:"episodes"`
@@ -644,14 +1605,17 @@ type CreatorSeriesResponse struct {
 
 // CreatorEpisodeResponse represents an episode for creator view
 type CreatorEpisodeResponse struct {
-	ID              string     `json:"id"`
-	Title           string     `json:"title"`
-	EpisodeNumber   int        `json:"episode_number"`
-	DurationSeconds int        `json:"duration_seconds"`
-	Status          string     `json:"status"`
-	PublishedAt     *time.Time `json:"published_at"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	ID                      string     `json:"id"`
+	Title                   string     `json:"title"`
+	EpisodeNumber           int        `json:"episode_number"`
+	DurationSeconds         int        `json:"duration_seconds"`
+	DetectedDurationSeconds *int       `json:"detected_duration_seconds"`
+	DurationMismatch        bool       `json:"duration_mismatch"`
+	Status                  string     `json:"status"`
+	Version                 int        `json:"version"`
+	PublishedAt             *time.Time `json:"published_at"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
 }
 
 // GetCreatorContent fetches all series and episodes created by the authenticated creator
@@ -659,25 +1623,25 @@ func (h *ContentHandler) GetCreatorContent(w http.ResponseWriter, r *http.Reques
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	// Check if user is a creator
 	var creatorProfile models.CreatorProfile
-	if err := h.db.Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "User must be onboarded as a creator first", http.StatusForbidden)
+			httputil.WriteError(w, http.StatusForbidden, httputil.CodeForbidden, "User must be onboarded as a creator first")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
 	// Get all series created by this creator
 	var series []models.Series
-	if err := h.db.Where("creator_id = ?", creatorProfile.ID).Find(&series).Error; err != nil {
-		http.Error(w, "Failed to fetch series", http.StatusInternalServerError)
+	if err := h.db.WithContext(r.Context()).Where("creator_id = ?", creatorProfile.ID).Find(&series).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch series")
 		return
 	}
 
@@ -688,8 +1652,8 @@ func (h *ContentHandler) GetCreatorContent(w http.ResponseWriter, r *http.Reques
 	for _, s := range series {
 		// Get episodes for this series
 		var episodes []models.Episode
-		if err := h.db.Where("series_id = ?", s.ID).Order("episode_number").Find(&episodes).Error; err != nil {
-			http.Error(w, "Failed to fetch episodes for series", http.StatusInternalServerError)
+		if err := h.db.WithContext(r.Context()).Where("series_id = ?", s.ID).Order("episode_number").Find(&episodes).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch episodes for series")
 			return
 		}
 
@@ -697,14 +1661,17 @@ func (h *ContentHandler) GetCreatorContent(w http.ResponseWriter, r *http.Reques
 		episodeResponses := make([]CreatorEpisodeResponse, 0, len(episodes))
 		for _, ep := range episodes {
 			episodeResponses = append(episodeResponses, CreatorEpisodeResponse{
-				ID:              ep.ID,
-				Title:           ep.Title,
-				EpisodeNumber:   ep.EpisodeNumber,
-				DurationSeconds: ep.DurationSeconds,
-				Status:          ep.Status,
-				PublishedAt:     ep.PublishedAt,
-				CreatedAt:       ep.CreatedAt,
-				UpdatedAt:       ep.UpdatedAt,
+				ID:                      ep.ID,
+				Title:                   ep.Title,
+				EpisodeNumber:           ep.EpisodeNumber,
+				DurationSeconds:         ep.DurationSeconds,
+				DetectedDurationSeconds: ep.DetectedDurationSeconds,
+				DurationMismatch:        ep.DurationMismatch,
+				Status:                  ep.Status,
+				Version:                 ep.Version,
+				PublishedAt:             ep.PublishedAt,
+				CreatedAt:               ep.CreatedAt,
+				UpdatedAt:               ep.UpdatedAt,
 			})
 		}
 
@@ -719,6 +1686,7 @@ func (h *ContentHandler) GetCreatorContent(w http.ResponseWriter, r *http.Reques
 			PriceAmount:  s.PriceAmount,
 			ThumbnailURL: s.ThumbnailURL,
 			Status:       s.Status,
+			Version:      s.Version,
 			CreatedAt:    s.CreatedAt,
 			UpdatedAt:    s.UpdatedAt,
 			Episodes:     episodeResponses,
@@ -746,31 +1714,30 @@ func (h *ContentHandler) UpdateEpisodeStatus(w http.ResponseWriter, r *http.Requ
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	// Verify ownership: episode belongs to a series owned by this creator
 	var episode models.Episode
-	if err := h.db.Joins("JOIN series ON episodes.series_id = series.id").
+	if err := h.db.WithContext(r.Context()).Joins("JOIN series ON episodes.series_id = series.id").
 		Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
 		Where("episodes.id = ? AND creator_profiles.user_id = ?", episodeID, userID).
 		First(&episode).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Episode not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
 	var req UpdateEpisodeStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 	if req.Status == "" {
-		http.Error(w, "status is required", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "status is required")
 		return
 	}
 
@@ -786,23 +1753,46 @@ func (h *ContentHandler) UpdateEpisodeStatus(w http.ResponseWriter, r *http.Requ
 		"published":        true,
 	}
 	if !allowed[status] {
-		http.Error(w, "invalid status", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid status")
 		return
 	}
 
 	updates := map[string]interface{}{
-		"status":     status,
-		"updated_at": time.Now(),
+		"status":  status,
+		"version": gorm.Expr("version + 1"),
 	}
-	if status == "published" {
+	// Publishing requires finished media; re-publishing keeps the original published_at
+	if status == "published" && episode.Status != "published" {
+		if episode.Status != "ready" {
+			httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict,
+				fmt.Sprintf("Episode cannot be published from %s; it must be ready first", episode.Status))
+			return
+		}
+		if episode.HLSManifestURL == nil || *episode.HLSManifestURL == "" {
+			httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict,
+				"Episode cannot be published until transcoding has produced a manifest")
+			return
+		}
+		var series models.Series
+		if err := h.db.WithContext(r.Context()).Select("id", "creator_id", "price_type").Where("id = ?", episode.SeriesID).First(&series).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		if !h.checkSeriesPaidKYC(r.Context(), w, series, series.PriceType) {
+			return
+		}
 		now := time.Now()
 		updates["published_at"] = &now
 	}
 
-	if err := h.db.Model(&episode).Updates(updates).Error; err != nil {
-		http.Error(w, "Failed to update episode status", http.StatusInternalServerError)
+	if err := h.db.WithContext(r.Context()).Model(&episode).Updates(updates).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update episode status")
 		return
 	}
+	h.seriesCache.invalidate(r.Context())
+	if status == "published" && episode.Status != "published" {
+		dispatchNewEpisodeNotifications(h.db, episode)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -824,30 +1814,29 @@ func (h *ContentHandler) UpdateSeriesStatus(w http.ResponseWriter, r *http.Reque
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	// Verify ownership: series belongs to this creator
 	var series models.Series
-	if err := h.db.Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+	if err := h.db.WithContext(r.Context()).Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
 		Where("series.id = ? AND creator_profiles.user_id = ?", seriesID, userID).
 		First(&series).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Series not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
 	var req UpdateSeriesStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 	if req.Status == "" {
-		http.Error(w, "status is required", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "status is required")
 		return
 	}
 
@@ -861,19 +1850,30 @@ func (h *ContentHandler) UpdateSeriesStatus(w http.ResponseWriter, r *http.Reque
 		"published": true,
 	}
 	if !allowed[status] {
-		http.Error(w, "invalid status", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid status")
 		return
 	}
 
+	if status == "published" && series.Status != "published" {
+		if !h.checkPublishable(r.Context(), w, series.ID) || !h.checkSeriesPaidKYC(r.Context(), w, series, series.PriceType) {
+			return
+		}
+	}
+
 	updates := map[string]interface{}{
-		"status":     status,
-		"updated_at": time.Now(),
+		"status":  status,
+		"version": gorm.Expr("version + 1"),
 	}
 
-	if err := h.db.Model(&series).Updates(updates).Error; err != nil {
-		http.Error(w, "Failed to update series status", http.StatusInternalServerError)
+	publishing := status == "published" && series.Status != "published"
+	if err := h.db.WithContext(r.Context()).Model(&series).Updates(updates).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update series status")
 		return
 	}
+	h.seriesCache.invalidate(r.Context())
+	if publishing {
+		metrics.SeriesPublished.Inc()
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -883,10 +1883,162 @@ func (h *ContentHandler) UpdateSeriesStatus(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// checkPaidKYC writes a 403 and returns false when a creator whose KYC is not
+// verified tries to sell content. Free content needs no KYC.
+func checkPaidKYC(w http.ResponseWriter, kycStatus, priceType string) bool {
+	if priceType == "free" || kycStatus == models.KYCStatusVerified {
+		return true
+	}
+	message := "Paid series require a verified KYC; your KYC is still under review"
+	if kycStatus == models.KYCStatusRejected {
+		message = "Paid series require a verified KYC; your KYC was rejected, so submit a new document from your creator profile"
+	}
+	httputil.WriteError(w, http.StatusForbidden, httputil.CodeForbidden, message)
+	return false
+}
+
+// checkSeriesPaidKYC is checkPaidKYC for the creator of series
+func (h *ContentHandler) checkSeriesPaidKYC(ctx context.Context, w http.ResponseWriter, series models.Series, priceType string) bool {
+	if priceType == "free" {
+		return true
+	}
+	var creator models.CreatorProfile
+	if err := h.db.WithContext(ctx).Select("kyc_status").Where("id = ?", series.CreatorID).First(&creator).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return false
+	}
+	return checkPaidKYC(w, creator.KYCStatus, priceType)
+}
+
+// checkPublishable writes a 409 and returns false unless the series has at
+// least one episode viewers could watch (ready or published)
+func (h *ContentHandler) checkPublishable(ctx context.Context, w http.ResponseWriter, seriesID string) bool {
+	var count int64
+	if err := h.db.WithContext(ctx).Model(&models.Episode{}).
+		Where("series_id = ? AND status IN ?", seriesID, []string{"ready", "published"}).
+		Count(&count).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return false
+	}
+	if count == 0 {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict,
+			"Series cannot be published until at least one episode is ready or published")
+		return false
+	}
+	return true
+}
+
+type ReorderEpisodesRequest struct {
+	EpisodeIDs []string `json:"episode_ids"`
+}
+
+type EpisodeOrder struct {
+	ID            string `json:"id"`
+	EpisodeNumber int    `json:"episode_number"`
+}
+
+// ReorderEpisodes renumbers all episodes of a series in the order given.
+// The list must contain exactly the series' current episodes.
+func (h *ContentHandler) ReorderEpisodes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesID := vars["id"]
+
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	// Verify ownership: series belongs to this creator
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("series.id = ? AND creator_profiles.user_id = ?", seriesID, userID).
+		First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	var req ReorderEpisodesRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.EpisodeIDs) == 0 {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "episode_ids is required")
+		return
+	}
+
+	var episodeIDs []string
+	if err := h.db.WithContext(r.Context()).Model(&models.Episode{}).Where("series_id = ?", series.ID).Pluck("id", &episodeIDs).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// The requested order must name every current episode exactly once
+	current := make(map[string]bool, len(episodeIDs))
+	for _, id := range episodeIDs {
+		current[id] = true
+	}
+	seen := make(map[string]bool, len(req.EpisodeIDs))
+	for _, id := range req.EpisodeIDs {
+		if !current[id] || seen[id] {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "episode_ids must list each episode of the series exactly once")
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(current) {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "episode_ids must list each episode of the series exactly once")
+		return
+	}
+
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Move every episode to a temporary negative number first so no
+		// intermediate state ever has two episodes sharing a number
+		if err := tx.Model(&models.Episode{}).Where("series_id = ?", series.ID).
+			Update("episode_number", gorm.Expr("-episode_number")).Error; err != nil {
+			return err
+		}
+		for i, id := range req.EpisodeIDs {
+			if err := tx.Model(&models.Episode{}).Where("id = ?", id).
+				Updates(map[string]interface{}{
+					"episode_number": i + 1,
+					"version":        gorm.Expr("version + 1"),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to reorder episodes")
+		return
+	}
+	h.seriesCache.invalidate(r.Context())
+
+	order := make([]EpisodeOrder, len(req.EpisodeIDs))
+	for i, id := range req.EpisodeIDs {
+		order[i] = EpisodeOrder{ID: id, EpisodeNumber: i + 1}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   "Episodes reordered successfully",
+		"series_id": series.ID,
+		"episodes":  order,
+	})
+}
+
 type UpdateEpisodeRequest struct {
 	Title           *string `json:"title"`
 	EpisodeNumber   *int    `json:"episode_number"`
 	DurationSeconds *int    `json:"duration_seconds"`
+	// Version is the version the client last read; the update fails with 409 if it is stale
+	Version *int `json:"version"`
 }
 
 // UpdateEpisode allows the creator to edit episode metadata (title, number, duration)
@@ -897,27 +2049,32 @@ func (h *ContentHandler) UpdateEpisode(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	// Load episode and verify ownership via series -> creator_profiles
 	var episode models.Episode
-	if err := h.db.Joins("JOIN series ON episodes.series_id = series.id").
+	if err := h.db.WithContext(r.Context()).Joins("JOIN series ON episodes.series_id = series.id").
 		Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
 		Where("episodes.id = ? AND creator_profiles.user_id = ?", episodeID, userID).
 		First(&episode).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Episode not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
 	var req UpdateEpisodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	expected, err := expectedVersion(r, req.Version)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -927,45 +2084,56 @@ func (h *ContentHandler) UpdateEpisode(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.DurationSeconds != nil {
 		if *req.DurationSeconds <= 0 {
-			http.Error(w, "duration_seconds must be > 0", http.StatusBadRequest)
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "duration_seconds must be > 0")
 			return
 		}
-		updates["duration_seconds"] = *req.DurationSeconds
+		updates["claimed_duration_seconds"] = *req.DurationSeconds
+		// The duration measured from the uploaded media wins over the creator's value
+		if episode.DetectedDurationSeconds == nil {
+			updates["duration_seconds"] = *req.DurationSeconds
+		}
 	}
 	if req.EpisodeNumber != nil {
 		if *req.EpisodeNumber <= 0 {
-			http.Error(w, "episode_number must be > 0", http.StatusBadRequest)
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "episode_number must be > 0")
 			return
 		}
 		// Ensure uniqueness within the same series
 		var count int64
-		if err := h.db.Model(&models.Episode{}).
+		if err := h.db.WithContext(r.Context()).Model(&models.Episode{}).
 			Where("series_id = ? AND episode_number = ? AND id <> ?", episode.SeriesID, *req.EpisodeNumber, episode.ID).
 			Count(&count).Error; err != nil {
-			http.Error(w, "Database error", http.StatusInternalServerError)
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 			return
 		}
 		if count > 0 {
-			http.Error(w, "Episode number already exists for this series", http.StatusConflict)
+			httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "Episode number already exists for this series")
 			return
 		}
 		updates["episode_number"] = *req.EpisodeNumber
 	}
 
 	if len(updates) == 0 {
-		http.Error(w, "No fields to update", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "No fields to update")
 		return
 	}
 
-	if err := h.db.Model(&episode).Updates(updates).Error; err != nil {
-		http.Error(w, "Failed to update episode", http.StatusInternalServerError)
+	updated, err := versionedUpdate(h.db.WithContext(r.Context()), &episode, expected, updates)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update episode")
 		return
 	}
+	if !updated {
+		writeStaleVersion(w, h.db.WithContext(r.Context()), &models.Episode{}, episode.ID)
+		return
+	}
+	h.seriesCache.invalidate(r.Context())
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Episode updated successfully",
 		"id":      episode.ID,
+		"version": episode.Version,
 	})
 }
 
@@ -977,28 +2145,30 @@ func (h *ContentHandler) DeleteEpisode(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	// Verify ownership
 	var episode models.Episode
-	if err := h.db.Joins("JOIN series ON episodes.series_id = series.id").
+	if err := h.db.WithContext(r.Context()).Joins("JOIN series ON episodes.series_id = series.id").
 		Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
 		Where("episodes.id = ? AND creator_profiles.user_id = ?", episodeID, userID).
 		First(&episode).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Episode not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
-	if err := h.db.Delete(&episode).Error; err != nil {
-		http.Error(w, "Failed to delete episode", http.StatusInternalServerError)
+	if err := h.db.WithContext(r.Context()).Delete(&episode).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete episode")
 		return
 	}
+	h.seriesCache.invalidate(r.Context())
+	h.CleanupEpisodeStorage(episode)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -1007,28 +2177,143 @@ func (h *ContentHandler) DeleteEpisode(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetEpisodes fetches all episodes for a specific series
+// DeleteSeries soft deletes a series together with its episodes
+func (h *ContentHandler) DeleteSeries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesID := vars["id"]
+
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	// Verify ownership
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("series.id = ? AND creator_profiles.user_id = ?", seriesID, userID).
+		First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// Series and episodes share one deletion timestamp so a restore can bring
+	// back exactly the episodes removed with the series
+	now := time.Now()
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Episode{}).Where("series_id = ?", series.ID).
+			Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&series).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete series")
+		return
+	}
+	h.seriesCache.invalidate(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Series deleted successfully",
+		"id":            series.ID,
+		"restore_until": now.Add(SeriesRestoreWindow),
+	})
+}
+
+// RestoreSeries undeletes a series and the episodes deleted with it, within the restore window
+func (h *ContentHandler) RestoreSeries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesID := vars["id"]
+
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	// Verify ownership of the deleted series
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Unscoped().Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("series.id = ? AND creator_profiles.user_id = ? AND series.deleted_at IS NOT NULL", seriesID, userID).
+		First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Deleted series not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	deletedAt := series.DeletedAt.Time
+	if time.Since(deletedAt) > SeriesRestoreWindow {
+		httputil.WriteError(w, http.StatusGone, httputil.CodeGone, "Restore window has expired")
+		return
+	}
+
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Episode{}).
+			Where("series_id = ? AND deleted_at = ?", series.ID, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&series).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to restore series")
+		return
+	}
+	h.seriesCache.invalidate(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Series restored successfully",
+		"id":      series.ID,
+	})
+}
+
+// GetEpisodes lists a series' episodes: all of them for the owning creator,
+// only the published ones of a published series for everyone else
 func (h *ContentHandler) GetEpisodes(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	seriesID := vars["seriesId"]
 
-	// Check if series exists and is published
 	var series models.Series
-	if err := h.db.Where("id = ? AND status = ?", seriesID, "published").First(&series).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Where("id = ?", seriesID).First(&series).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Series not found or not published", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or not published")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// The owning creator sees every episode of their series, drafts included;
+	// everyone else only sees published episodes of a published series
+	userID, _ := r.Context().Value("user_id").(string)
+	isOwner, err := h.ownsSeries(r.Context(), userID, series)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if !isOwner && series.Status != "published" {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or not published")
 		return
 	}
 
-	// Get all published episodes for this series
 	var episodes []models.Episode
-	if err := h.db.Where("series_id = ? AND status = ?", seriesID, "published").
-		Order("episode_number").
-		Find(&episodes).Error; err != nil {
-		http.Error(w, "Failed to fetch episodes", http.StatusInternalServerError)
+	episodeQuery := h.db.WithContext(r.Context()).Where("series_id = ?", series.ID).Order("episode_number")
+	if !isOwner {
+		episodeQuery = episodeQuery.Where("status = ?", "published")
+	}
+	if err := episodeQuery.Find(&episodes).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch episodes")
 		return
 	}
 
@@ -1039,6 +2324,8 @@ func (h *ContentHandler) GetEpisodes(w http.ResponseWriter, r *http.Request) {
 		EpisodeNumber   int        `json:"episode_number"`
 		DurationSeconds int        `json:"duration_seconds"`
 		ThumbURL        *string    `json:"thumb_url"`
+		ViewCount       int64      `json:"view_count"`
+		Status          string     `json:"status"`
 		PublishedAt     *time.Time `json:"published_at"`
 		CreatedAt       time.Time  `json:"created_at"`
 	}
@@ -1051,6 +2338,8 @@ func (h *ContentHandler) GetEpisodes(w http.ResponseWriter, r *http.Request) {
 			EpisodeNumber:   ep.EpisodeNumber,
 			DurationSeconds: ep.DurationSeconds,
 			ThumbURL:        ep.ThumbURL,
+			ViewCount:       ep.ViewCount,
+			Status:          ep.Status,
 			PublishedAt:     ep.PublishedAt,
 			CreatedAt:       ep.CreatedAt,
 		})
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestListSeriesRejectsMalformedCreatorID(t *testing.T) {
+	h := NewContentHandler(nil, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+	for _, id := range []string{"42", "not-a-uuid", "7d9f3c1e-2b4a-4f6e-9a1b-3c5d7e9f1a2", "'; DROP TABLE series; --"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/content/series", nil)
+		q := req.URL.Query()
+		q.Set("creator_id", id)
+		req.URL.RawQuery = q.Encode()
+
+		h.ListSeries(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("creator_id=%q: status = %d, want 400", id, rec.Code)
+		}
+	}
+}
+
+func TestConcurrentCreateEpisodeNumbersUniquely(t *testing.T) {
+	db := testdb.Shared(t)
+	owner, creator := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	t.Cleanup(func() {
+		db.Unscoped().Where("series_id = ?", series.ID).Delete(&models.Episode{})
+		db.Unscoped().Delete(&series)
+		db.Unscoped().Delete(&creator)
+		db.Unscoped().Delete(&owner)
+	})
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	// Every request leaves episode_number out, so each takes the next number
+	const creates = 8
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, creates)
+	start := make(chan struct{})
+	for i := range recs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			recs[i] = serve(h.CreateEpisode, http.MethodPost, "/api/content/series/"+series.ID+"/episodes",
+				map[string]string{"id": series.ID}, owner.ID, `{"title":"Episode","duration_seconds":60}`)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, rec := range recs {
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %d = %d: %s", i, rec.Code, rec.Body)
+		}
+	}
+
+	var numbers []int
+	if err := db.Model(&models.Episode{}).Where("series_id = ?", series.ID).
+		Order("episode_number").Pluck("episode_number", &numbers).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != creates {
+		t.Fatalf("%d episodes created, want %d", len(numbers), creates)
+	}
+	for i, n := range numbers {
+		if n != i+1 {
+			t.Fatalf("episode numbers = %v, want 1..%d without duplicates", numbers, creates)
+		}
+	}
+}
+
+func TestListSeriesSearch(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	seed := func(title, synopsis string) string {
+		return createTestSeries(t, db, creator.ID, func(s *models.Series) {
+			s.Title = title
+			s.Synopsis = synopsis
+		}).ID
+	}
+	express := seed("Midnight Express", "A train crosses the desert")
+	seed("Desert Bloom", "Flowers after the rain")
+	seed("Ocean Tales", "Stories from the sea")
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	tests := []struct {
+		name string
+		q    string
+		want []string
+	}{
+		{"partial title", "Midn", []string{express}},
+		{"words across title and synopsis", "express desert", []string{express}},
+		{"no match", "zebra", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/content/series?" + url.Values{"creator_id": {creator.ID}, "q": {tt.q}}.Encode()
+			rec := serve(h.ListSeries, http.MethodGet, target, nil, "", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			var resp SeriesListResponse
+			decodeBody(t, rec, &resp)
+			var got []string
+			for _, item := range resp.Items {
+				got = append(got, item.ID)
+			}
+			if !slices.Equal(got, tt.want) || resp.Total != int64(len(tt.want)) {
+				t.Errorf("q=%q matched %v (total %d), want %v", tt.q, got, resp.Total, tt.want)
+			}
+		})
+	}
+}
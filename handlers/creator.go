@@ -1,35 +1,158 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"streamshort/models"
+	"streamshort/pkg/httputil"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// CreatorOptions holds tunable limits for creator operations
+type CreatorOptions struct {
+	// MinPayoutAmount is the smallest payout a creator may request
+	MinPayoutAmount float64
+	// S3Bucket is the bucket KYC documents must be uploaded to
+	S3Bucket string
+	// ImageHosts are the hosts avatar URLs may point at
+	ImageHosts []string
+}
+
 type CreatorHandler struct {
-	db *gorm.DB
+	db   *gorm.DB
+	opts CreatorOptions
 }
 
-func NewCreatorHandler(db *gorm.DB) *CreatorHandler {
-	return &CreatorHandler{db: db}
+func NewCreatorHandler(db *gorm.DB, opts CreatorOptions) *CreatorHandler {
+	return &CreatorHandler{db: db, opts: opts}
 }
 
 // Request/Response structs matching OpenAPI schema
 type CreatorOnboardRequest struct {
-	DisplayName     string `json:"display_name"`
-	Bio             string `json:"bio"`
-	KYCDocumentPath string `json:"kyc_document_s3_path"`
+	DisplayName     string  `json:"display_name" validate:"required"`
+	Bio             string  `json:"bio"`
+	AvatarURL       *string `json:"avatar_url"`
+	KYCDocumentPath string  `json:"kyc_document_s3_path" validate:"required"`
 }
 
+// CreatorPublicProfile is the viewer-facing part of a creator profile
+type CreatorPublicProfile struct {
+	ID          string                                     `json:"id"`
+	DisplayName string                                     `json:"display_name"`
+	Bio         string                                     `json:"bio"`
+	AvatarURL   *string                                    `json:"avatar_url"`
+	Rating      *float64                                   `json:"rating"`
+	Followers   int64                                      `json:"follower_count"`
+	Series      httputil.PaginatedResponse[SeriesListItem] `json:"series"`
+}
+
+// CreatorListItem is a verified creator in the public creator directory
+type CreatorListItem struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   *string   `json:"avatar_url"`
+	SeriesCount int64     `json:"series_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	// AverageRating and RatingCount aggregate ratings across the creator's published episodes
+	AverageRating float64 `json:"average_rating"`
+	RatingCount   int64   `json:"rating_count"`
+}
+
+// creatorSortOrders maps the creator directory's sort query param to ORDER BY
+// clauses over the CreatorListItem columns. id breaks ties so pagination is stable.
+var creatorSortOrders = map[string]string{
+	"top_rated":    "average_rating DESC, rating_count DESC, id ASC",
+	"most_content": "series_count DESC, created_at DESC, id DESC",
+	"newest":       "created_at DESC, id DESC",
+}
+
+// creatorRatingsFrom joins a creator's ratings through published episodes of published series
+const creatorRatingsFrom = "FROM episode_ratings " +
+	"JOIN episodes ON episodes.id = episode_ratings.episode_id AND episodes.deleted_at IS NULL AND episodes.status = 'published' " +
+	"JOIN series ON series.id = episodes.series_id AND series.deleted_at IS NULL AND series.status = 'published' " +
+	"WHERE series.creator_id = creator_profiles.id AND episode_ratings.deleted_at IS NULL"
+
+// creatorListColumns selects a CreatorListItem from creator_profiles
+const creatorListColumns = "creator_profiles.id, creator_profiles.display_name, creator_profiles.avatar_url, creator_profiles.created_at, " +
+	"(SELECT COUNT(*) FROM series WHERE series.creator_id = creator_profiles.id " +
+	"AND series.status = 'published' AND series.deleted_at IS NULL) AS series_count, " +
+	"COALESCE((SELECT ROUND(AVG(episode_ratings.score)::numeric, 1) " + creatorRatingsFrom + "), 0) AS average_rating, " +
+	"(SELECT COUNT(*) " + creatorRatingsFrom + ") AS rating_count"
+
 type CreatorDashboardResponse struct {
-	Views            int64   `json:"views"`
-	WatchTimeSeconds int64   `json:"watch_time_seconds"`
-	Earnings         float64 `json:"earnings"`
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	Views            int64     `json:"views"`
+	WatchTimeSeconds int64     `json:"watch_time_seconds"`
+	// Earnings is the creator's net share of payments within the window
+	Earnings float64 `json:"earnings"`
+	// AvailableEarnings is the all-time balance that can still be paid out
+	AvailableEarnings float64 `json:"available_earnings"`
+}
+
+type EpisodeAnalyticsResponse struct {
+	EpisodeID string    `json:"episode_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Views     int64     `json:"views"`
+	// Viewers is the number of users with watch progress on the episode
+	Viewers int64 `json:"viewers"`
+	// CompletionRate is the share of viewers who watched at least episodeCompletionThreshold of it
+	CompletionRate float64 `json:"completion_rate"`
+	// AverageWatchFraction is how much of the episode viewers watched on average (0-1)
+	AverageWatchFraction float64 `json:"average_watch_fraction"`
+	Likes                int64   `json:"likes"`
+	AverageRating        float64 `json:"average_rating"`
+	RatingCount          int64   `json:"rating_count"`
+}
+
+// episodeCompletionThreshold is the fraction of an episode that counts as finishing it
+const episodeCompletionThreshold = 0.9
+
+type PayoutDetailsRequest struct {
+	BankName      string `json:"bank_name"`
+	AccountNumber string `json:"account_number"`
+	IFSCCode      string `json:"ifsc_code"`
+	AccountHolder string `json:"account_holder"`
+}
+
+type PayoutDetailsResponse struct {
+	BankName            string    `json:"bank_name"`
+	AccountNumberMasked string    `json:"account_number_masked"`
+	IFSCCode            string    `json:"ifsc_code"`
+	AccountHolder       string    `json:"account_holder"`
+	Verified            bool      `json:"verified"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+var (
+	// ifscPattern is a bank code, a literal 0, then a six character branch code
+	ifscPattern          = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	accountNumberPattern = regexp.MustCompile(`^[0-9]{9,18}$`)
+)
+
+type PayoutRequest struct {
+	Amount float64 `json:"amount"`
+}
+
+type PayoutResponse struct {
+	ID          string     `json:"id"`
+	Amount      float64    `json:"amount"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status"`
+	Reference   *string    `json:"reference"`
+	ProcessedAt *time.Time `json:"processed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 // Creator onboarding endpoint
@@ -37,54 +160,83 @@ func (h *CreatorHandler) OnboardCreator(w http.ResponseWriter, r *http.Request)
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	var req CreatorOnboardRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate required fields
-	if req.DisplayName == "" {
-		http.Error(w, "Display name is required", http.StatusBadRequest)
+	if !httputil.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	if req.KYCDocumentPath == "" {
-		http.Error(w, "KYC document path is required", http.StatusBadRequest)
+	if !validUserUploadPath(req.KYCDocumentPath, h.opts.S3Bucket, userID) {
+		httputil.WriteValidationError(w, map[string]httputil.FieldError{
+			"kyc_document_s3_path": {Rule: "own_upload", Message: "KYC document path must point to one of your uploads in our bucket"},
+		})
 		return
 	}
-
-	// Check if user already has a creator profile
-	var existingProfile models.CreatorProfile
-	if err := h.db.Where("user_id = ?", userID).First(&existingProfile).Error; err == nil {
-		http.Error(w, "Creator profile already exists for this user", http.StatusConflict)
-		return
-	} else if err != gorm.ErrRecordNotFound {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+	if req.AvatarURL != nil && !validThumbnailURL(*req.AvatarURL, h.opts.ImageHosts) {
+		httputil.WriteValidationError(w, map[string]httputil.FieldError{
+			"avatar_url": {Rule: "allowed_host", Message: "avatar_url must be an http(s) URL on an allowed host"},
+		})
 		return
 	}
 
-	// Create creator profile
-	creatorProfile := models.CreatorProfile{
-		UserID:          userID,
-		DisplayName:     req.DisplayName,
-		Bio:             req.Bio,
-		KYCDocumentPath: req.KYCDocumentPath,
-		KYCStatus:       "pending",
-	}
+	// Onboarding is an upsert: a repeat call updates the existing profile. The
+	// profile is locked while it is updated so concurrent calls cannot
+	// overwrite each other's changes, or a KYC review, with stale fields.
+	var creatorProfile models.CreatorProfile
+	created := false
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&creatorProfile).Error
+		if err == gorm.ErrRecordNotFound {
+			creatorProfile = models.CreatorProfile{
+				UserID:          userID,
+				DisplayName:     req.DisplayName,
+				Bio:             req.Bio,
+				AvatarURL:       req.AvatarURL,
+				KYCDocumentPath: req.KYCDocumentPath,
+				KYCStatus:       models.KYCStatusPending,
+			}
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&creatorProfile)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 1 {
+				created = true
+				return nil
+			}
+
+			// A concurrent onboarding request created the profile first; update it instead
+			creatorProfile = models.CreatorProfile{}
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&creatorProfile).Error
+		}
+		if err != nil {
+			return err
+		}
 
-	if err := h.db.Create(&creatorProfile).Error; err != nil {
-		http.Error(w, "Failed to create creator profile", http.StatusInternalServerError)
+		creatorProfile.DisplayName = req.DisplayName
+		creatorProfile.Bio = req.Bio
+		if req.AvatarURL != nil {
+			creatorProfile.AvatarURL = req.AvatarURL
+		}
+		if creatorProfile.KYCDocumentPath != req.KYCDocumentPath {
+			creatorProfile.KYCDocumentPath = req.KYCDocumentPath
+			// A new document has to be verified again
+			creatorProfile.KYCStatus = models.KYCStatusPending
+			creatorProfile.KYCReason = nil
+		}
+		return tx.Save(&creatorProfile).Error
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save creator profile")
 		return
 	}
 
-	// Return the created profile
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
 	json.NewEncoder(w).Encode(creatorProfile)
 }
 
@@ -93,7 +245,7 @@ func (h *CreatorHandler) GetCreatorDashboard(w http.ResponseWriter, r *http.Requ
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
@@ -103,48 +255,67 @@ func (h *CreatorHandler) GetCreatorDashboard(w http.ResponseWriter, r *http.Requ
 
 	// Verify that the user is accessing their own dashboard
 	var creatorProfile models.CreatorProfile
-	if err := h.db.Where("id = ? AND user_id = ?", creatorID, userID).First(&creatorProfile).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND user_id = ?", creatorID, userID).First(&creatorProfile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Creator profile not found or access denied", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator profile not found or access denied")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// Date window defaults to the last 30 days
+	from, to, err := parseDateRange(r, 30*24*time.Hour)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
-	// Get analytics for the last 30 days
-	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
+	creatorSeries := h.db.WithContext(r.Context()).Model(&models.Series{}).Select("id").Where("creator_id = ?", creatorProfile.ID)
+	creatorEpisodes := h.db.WithContext(r.Context()).Model(&models.Episode{}).Select("id").Where("series_id IN (?)", creatorSeries)
 
-	var analytics []models.CreatorAnalytics
-	if err := h.db.Where("creator_id = ? AND date >= ?", creatorID, thirtyDaysAgo).
-		Find(&analytics).Error; err != nil {
-		http.Error(w, "Failed to fetch analytics", http.StatusInternalServerError)
+	// Views from viewers' playback progress
+	var views int64
+	if err := h.db.WithContext(r.Context()).Model(&models.WatchProgress{}).
+		Where("episode_id IN (?) AND updated_at >= ? AND updated_at < ?", creatorEpisodes, from, to).
+		Count(&views).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
 		return
 	}
 
-	// Aggregate analytics
-	var totalViews int64
-	var totalWatchTime int64
-	var totalEarnings float64
+	// Watch time from player heartbeats, counted on the day playback started
+	var watchTime int64
+	if err := h.db.WithContext(r.Context()).Model(&models.PlaybackSession{}).
+		Select("COALESCE(SUM(watched_seconds), 0)").
+		Where("episode_id IN (?) AND created_at >= ? AND created_at < ?", creatorEpisodes, from, to).
+		Scan(&watchTime).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+		return
+	}
 
-	for _, analytic := range analytics {
-		totalViews += analytic.Views
-		totalWatchTime += analytic.WatchTimeSeconds
-		totalEarnings += analytic.Earnings
+	// Earnings credited to the creator's ledger, net of platform commission
+	var totalEarnings float64
+	if err := h.db.WithContext(r.Context()).Model(&models.CreatorEarning{}).
+		Select("COALESCE(SUM(net_amount), 0)").
+		Where("creator_id = ? AND created_at >= ? AND created_at < ?", creatorProfile.ID, from, to).
+		Scan(&totalEarnings).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+		return
 	}
 
-	// Create mock analytics if none exist (for development)
-	if len(analytics) == 0 {
-		// In production, you would calculate real analytics
-		totalViews = 1245
-		totalWatchTime = 456780
-		totalEarnings = 1299.50
+	available, err := availableEarnings(h.db.WithContext(r.Context()), creatorProfile.ID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+		return
 	}
 
 	response := CreatorDashboardResponse{
-		Views:            totalViews,
-		WatchTimeSeconds: totalWatchTime,
-		Earnings:         totalEarnings,
+		From:              from,
+		To:                to,
+		Views:             views,
+		WatchTimeSeconds:  watchTime,
+		Earnings:          totalEarnings,
+		AvailableEarnings: available,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -156,18 +327,18 @@ func (h *CreatorHandler) GetCreatorProfile(w http.ResponseWriter, r *http.Reques
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	// Get creator profile for the authenticated user
 	var creatorProfile models.CreatorProfile
-	if err := h.db.Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Creator profile not found", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator profile not found")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
@@ -180,24 +351,36 @@ func (h *CreatorHandler) UpdateCreatorProfile(w http.ResponseWriter, r *http.Req
 	// Get user ID from context
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
 	var req CreatorOnboardRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.KYCDocumentPath != "" && !validUserUploadPath(req.KYCDocumentPath, h.opts.S3Bucket, userID) {
+		httputil.WriteValidationError(w, map[string]httputil.FieldError{
+			"kyc_document_s3_path": {Rule: "own_upload", Message: "KYC document path must point to one of your uploads in our bucket"},
+		})
+		return
+	}
+	if req.AvatarURL != nil && !validThumbnailURL(*req.AvatarURL, h.opts.ImageHosts) {
+		httputil.WriteValidationError(w, map[string]httputil.FieldError{
+			"avatar_url": {Rule: "allowed_host", Message: "avatar_url must be an http(s) URL on an allowed host"},
+		})
 		return
 	}
 
 	// Get existing creator profile
 	var creatorProfile models.CreatorProfile
-	if err := h.db.Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Creator profile not found", http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator profile not found")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
@@ -208,15 +391,19 @@ func (h *CreatorHandler) UpdateCreatorProfile(w http.ResponseWriter, r *http.Req
 	if req.Bio != "" {
 		creatorProfile.Bio = req.Bio
 	}
+	if req.AvatarURL != nil {
+		creatorProfile.AvatarURL = req.AvatarURL
+	}
 	if req.KYCDocumentPath != "" {
 		creatorProfile.KYCDocumentPath = req.KYCDocumentPath
 		// Reset KYC status to pending when document is updated
-		creatorProfile.KYCStatus = "pending"
+		creatorProfile.KYCStatus = models.KYCStatusPending
+		creatorProfile.KYCReason = nil
 	}
 
 	// Save changes
-	if err := h.db.Save(&creatorProfile).Error; err != nil {
-		http.Error(w, "Failed to update creator profile", http.StatusInternalServerError)
+	if err := h.db.WithContext(r.Context()).Save(&creatorProfile).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update creator profile")
 		return
 	}
 
@@ -224,8 +411,482 @@ func (h *CreatorHandler) UpdateCreatorProfile(w http.ResponseWriter, r *http.Req
 	json.NewEncoder(w).Encode(creatorProfile)
 }
 
+// GetPublicProfile returns a creator's public profile and their published series.
+// KYC and payout information are never included.
+func (h *CreatorHandler) GetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	creatorID := mux.Vars(r)["id"]
+
+	var creatorProfile models.CreatorProfile
+	if err := h.db.WithContext(r.Context()).Where("id = ?", creatorID).First(&creatorProfile).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Model(&models.Series{}).Where("creator_id = ? AND status = ?", creatorProfile.ID, "published")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch series")
+		return
+	}
+
+	var seriesRows []models.Series
+	if err := query.
+		Preload("Creator").
+		Preload("Episodes", "status = ?", "published").
+		Order("created_at DESC, id DESC").
+		Offset(offset).Limit(perPage).
+		Find(&seriesRows).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch series")
+		return
+	}
+
+	items := make([]SeriesListItem, 0, len(seriesRows))
+	for _, s := range seriesRows {
+		items = append(items, toSeriesListItem(s))
+	}
+	if err := attachSeriesRatings(h.db.WithContext(r.Context()), items); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ratings")
+		return
+	}
+
+	var followers int64
+	if err := h.db.WithContext(r.Context()).Model(&models.CreatorFollow{}).Where("creator_id = ?", creatorProfile.ID).Count(&followers).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch followers")
+		return
+	}
+
+	response := CreatorPublicProfile{
+		ID:          creatorProfile.ID,
+		DisplayName: creatorProfile.DisplayName,
+		Bio:         creatorProfile.Bio,
+		AvatarURL:   creatorProfile.AvatarURL,
+		Rating:      creatorProfile.Rating,
+		Followers:   followers,
+		Series:      httputil.NewPaginatedResponse(items, total, page, perPage),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListCreators lists KYC-verified creators with their published series count
+// and aggregate rating. sort is one of top_rated (default), most_content or newest.
+func (h *CreatorHandler) ListCreators(w http.ResponseWriter, r *http.Request) {
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "top_rated"
+	}
+	order, ok := creatorSortOrders[sort]
+	if !ok {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid sort; expected one of top_rated, most_content, newest")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Model(&models.CreatorProfile{}).Where("kyc_status = ?", models.KYCStatusVerified)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch creators")
+		return
+	}
+
+	items := []CreatorListItem{}
+	if err := query.
+		Select(creatorListColumns).
+		Order(order).
+		Offset(offset).Limit(perPage).
+		Scan(&items).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch creators")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(items, total, page, perPage))
+}
+
+// GetEpisodeAnalytics reports engagement for one of the creator's episodes
+// within a date range (default: the last 30 days)
+func (h *CreatorHandler) GetEpisodeAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	episodeID := mux.Vars(r)["id"]
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Joins("JOIN series ON episodes.series_id = series.id").
+		Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("episodes.id = ? AND creator_profiles.user_id = ?", episodeID, userID).
+		First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	from, to, err := parseDateRange(r, 30*24*time.Hour)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	response := EpisodeAnalyticsResponse{EpisodeID: episode.ID, From: from, To: to}
+
+	if err := h.db.WithContext(r.Context()).Model(&models.EpisodeView{}).
+		Where("episode_id = ? AND viewed_at >= ? AND viewed_at < ?", episode.ID, from, to).
+		Count(&response.Views).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+		return
+	}
+
+	// Watch fractions are capped at 1 so seeking past the end doesn't inflate them
+	var playback struct {
+		Viewers       int64
+		Completed     int64
+		WatchFraction float64
+	}
+	if episode.DurationSeconds > 0 {
+		fraction := "LEAST(position_seconds::numeric / ?, 1)"
+		if err := h.db.WithContext(r.Context()).Model(&models.WatchProgress{}).
+			Select("COUNT(*) AS viewers, "+
+				"COUNT(*) FILTER (WHERE completed OR "+fraction+" >= ?) AS completed, "+
+				"COALESCE(AVG("+fraction+"), 0) AS watch_fraction",
+				episode.DurationSeconds, episodeCompletionThreshold, episode.DurationSeconds).
+			Where("episode_id = ? AND updated_at >= ? AND updated_at < ?", episode.ID, from, to).
+			Scan(&playback).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+			return
+		}
+	}
+	response.Viewers = playback.Viewers
+	response.AverageWatchFraction = playback.WatchFraction
+	if playback.Viewers > 0 {
+		response.CompletionRate = float64(playback.Completed) / float64(playback.Viewers)
+	}
+
+	if err := h.db.WithContext(r.Context()).Model(&models.EpisodeLike{}).
+		Where("episode_id = ? AND created_at >= ? AND created_at < ?", episode.ID, from, to).
+		Count(&response.Likes).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+		return
+	}
+
+	var ratings struct {
+		AverageRating float64
+		RatingCount   int64
+	}
+	if err := h.db.WithContext(r.Context()).Model(&models.EpisodeRating{}).
+		Select("COALESCE(ROUND(AVG(score)::numeric, 1), 0) AS average_rating, COUNT(*) AS rating_count").
+		Where("episode_id = ? AND updated_at >= ? AND updated_at < ?", episode.ID, from, to).
+		Scan(&ratings).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analytics")
+		return
+	}
+	response.AverageRating = ratings.AverageRating
+	response.RatingCount = ratings.RatingCount
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdatePayoutDetails saves the bank account payouts are sent to
+func (h *CreatorHandler) UpdatePayoutDetails(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req PayoutDetailsRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	req.BankName = strings.TrimSpace(req.BankName)
+	req.AccountHolder = strings.TrimSpace(req.AccountHolder)
+	req.IFSCCode = strings.ToUpper(strings.TrimSpace(req.IFSCCode))
+	req.AccountNumber = strings.ReplaceAll(strings.TrimSpace(req.AccountNumber), " ", "")
+
+	if req.BankName == "" || req.AccountHolder == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "bank_name and account_holder are required")
+		return
+	}
+	if !ifscPattern.MatchString(req.IFSCCode) {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "ifsc_code must be 4 letters, a 0, then 6 letters or digits")
+		return
+	}
+	if !accountNumberPattern.MatchString(req.AccountNumber) {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "account_number must be 9 to 18 digits")
+		return
+	}
+
+	var creatorProfile models.CreatorProfile
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator profile not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	// New bank details must be verified again before payouts resume
+	details := models.PayoutDetails{
+		CreatorID:     creatorProfile.ID,
+		BankName:      req.BankName,
+		AccountNumber: req.AccountNumber,
+		IFSCCode:      req.IFSCCode,
+		AccountHolder: req.AccountHolder,
+		Verified:      false,
+	}
+	if err := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "creator_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bank_name", "account_number", "ifsc_code", "account_holder", "verified", "updated_at", "deleted_at"}),
+	}).Create(&details).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save payout details")
+		return
+	}
+
+	response := PayoutDetailsResponse{
+		BankName:            details.BankName,
+		AccountNumberMasked: maskAccountNumber(details.AccountNumber),
+		IFSCCode:            details.IFSCCode,
+		AccountHolder:       details.AccountHolder,
+		Verified:            details.Verified,
+		UpdatedAt:           details.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// maskAccountNumber hides all but the last four digits
+func maskAccountNumber(n string) string {
+	if len(n) <= 4 {
+		return n
+	}
+	return strings.Repeat("X", len(n)-4) + n[len(n)-4:]
+}
+
+// RequestPayout creates a pending payout against the creator's available earnings
+func (h *CreatorHandler) RequestPayout(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req PayoutRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Amount <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "amount must be greater than 0")
+		return
+	}
+	if req.Amount < h.opts.MinPayoutAmount {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest,
+			fmt.Sprintf("amount must be at least %.2f", h.opts.MinPayoutAmount))
+		return
+	}
+
+	var payout models.CreatorPayout
+	var status int
+	var message string
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the profile so concurrent requests cannot both spend the same earnings
+		var creatorProfile models.CreatorProfile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				status, message = http.StatusNotFound, "Creator profile not found"
+			}
+			return err
+		}
+
+		var details models.PayoutDetails
+		if err := tx.Where("creator_id = ?", creatorProfile.ID).First(&details).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				status, message = http.StatusBadRequest, "Payout bank details must be added before requesting a payout"
+			}
+			return err
+		}
+		if !details.Verified {
+			status, message = http.StatusConflict, "Payout bank details must be verified before requesting a payout"
+			return errPayoutRejected
+		}
+
+		available, err := availableEarnings(tx, creatorProfile.ID)
+		if err != nil {
+			return err
+		}
+		if req.Amount > available {
+			status, message = http.StatusBadRequest, fmt.Sprintf("amount exceeds available earnings of %.2f", available)
+			return errPayoutRejected
+		}
+
+		payout = models.CreatorPayout{
+			CreatorID: creatorProfile.ID,
+			Amount:    req.Amount,
+			Currency:  "INR",
+			Status:    models.PayoutStatusPending,
+		}
+		return tx.Create(&payout).Error
+	})
+	if err != nil {
+		if status != 0 {
+			httputil.WriteError(w, status, httputil.CodeForStatus(status), message)
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create payout")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toPayoutResponse(payout))
+}
+
+// GetPayouts lists the creator's payouts, newest first
+func (h *CreatorHandler) GetPayouts(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var creatorProfile models.CreatorProfile
+	if err := h.db.WithContext(r.Context()).Where("user_id = ?", userID).First(&creatorProfile).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator profile not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Model(&models.CreatorPayout{}).Where("creator_id = ?", creatorProfile.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count payouts")
+		return
+	}
+
+	var payouts []models.CreatorPayout
+	if err := query.Order("created_at DESC").Offset(offset).Limit(perPage).Find(&payouts).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch payouts")
+		return
+	}
+
+	items := make([]PayoutResponse, 0, len(payouts))
+	for _, p := range payouts {
+		items = append(items, toPayoutResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(items, total, page, perPage))
+}
+
+var errPayoutRejected = errors.New("payout rejected")
+
+// availableEarnings is the creator's net earnings ledger balance minus
+// payouts that have not failed. Pending and processing payouts count too, so
+// the same earnings cannot be requested twice.
+func availableEarnings(db *gorm.DB, creatorID string) (float64, error) {
+	var earned float64
+	if err := db.Model(&models.CreatorEarning{}).
+		Select("COALESCE(SUM(net_amount), 0)").
+		Where("creator_id = ?", creatorID).
+		Scan(&earned).Error; err != nil {
+		return 0, err
+	}
+
+	var paidOut float64
+	if err := db.Model(&models.CreatorPayout{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("creator_id = ? AND status <> ?", creatorID, models.PayoutStatusFailed).
+		Scan(&paidOut).Error; err != nil {
+		return 0, err
+	}
+
+	return earned - paidOut, nil
+}
+
+func toPayoutResponse(p models.CreatorPayout) PayoutResponse {
+	return PayoutResponse{
+		ID:          p.ID,
+		Amount:      p.Amount,
+		Currency:    p.Currency,
+		Status:      p.Status,
+		Reference:   p.Reference,
+		ProcessedAt: p.ProcessedAt,
+		CreatedAt:   p.CreatedAt,
+	}
+}
+
+// parseDateRange reads optional from/to query params (RFC3339 or YYYY-MM-DD).
+// to defaults to now and from defaults to to minus defaultWindow.
+func parseDateRange(r *http.Request, defaultWindow time.Duration) (time.Time, time.Time, error) {
+	to := time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, dateOnly, err := parseDateParam(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'to' date: %s", v)
+		}
+		// A bare date means "through the end of that day"
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultWindow)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, _, err := parseDateParam(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'from' date: %s", v)
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("'from' must be before 'to'")
+	}
+
+	return from, to, nil
+}
+
+// parseDateParam accepts RFC3339 timestamps or YYYY-MM-DD dates and reports which form was used
+func parseDateParam(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	return t, true, err
+}
+
 // Helper function to create mock analytics for testing
-func (h *CreatorHandler) CreateMockAnalytics(creatorID string) error {
+func (h *CreatorHandler) CreateMockAnalytics(ctx context.Context, creatorID string) error {
 	// Create analytics for the last 7 days
 	for i := 6; i >= 0; i-- {
 		date := time.Now().AddDate(0, 0, -i)
@@ -243,7 +904,7 @@ func (h *CreatorHandler) CreateMockAnalytics(creatorID string) error {
 			Earnings:         earnings,
 		}
 
-		if err := h.db.Create(&analytic).Error; err != nil {
+		if err := h.db.WithContext(ctx).Create(&analytic).Error; err != nil {
 			return err
 		}
 	}
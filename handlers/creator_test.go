@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/testdb"
+)
+
+func TestListCreatorsShowsOnlyVerifiedCreators(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewCreatorHandler(db, CreatorOptions{})
+	list := func() httputil.PaginatedResponse[CreatorListItem] {
+		t.Helper()
+		rec := serve(h.ListCreators, http.MethodGet, "/creators?sort=newest&per_page=100", nil, "", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list = %d: %s", rec.Code, rec.Body)
+		}
+		var resp httputil.PaginatedResponse[CreatorListItem]
+		decodeBody(t, rec, &resp)
+		return resp
+	}
+	before := list().Total
+
+	seeded := make(map[string]string)
+	for _, status := range []string{models.KYCStatusPending, models.KYCStatusRejected, models.KYCStatusVerified, "deleted"} {
+		_, creator := createTestCreator(t, db)
+		kycStatus := status
+		if status == "deleted" {
+			kycStatus = models.KYCStatusVerified
+		}
+		if err := db.Model(&creator).Update("kyc_status", kycStatus).Error; err != nil {
+			t.Fatal(err)
+		}
+		if status == "deleted" {
+			if err := db.Delete(&creator).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		seeded[creator.ID] = status
+	}
+
+	resp := list()
+	if resp.Total != before+1 {
+		t.Errorf("total = %d, want %d: only the verified creator counts", resp.Total, before+1)
+	}
+	var listed []string
+	for _, item := range resp.Items {
+		if status, ok := seeded[item.ID]; ok {
+			listed = append(listed, status)
+		}
+	}
+	if len(listed) != 1 || listed[0] != models.KYCStatusVerified {
+		t.Errorf("listed seeded creators %v, want only the verified one", listed)
+	}
+}
+
+func TestPayoutsRequireVerifiedBankDetails(t *testing.T) {
+	db := testdb.Open(t)
+	owner, creator := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	buyer := createTestUser(t, db)
+	transaction := models.PaymentTransaction{
+		UserID:   buyer.ID,
+		SeriesID: series.ID,
+		Amount:   125,
+		Currency: "INR",
+		Status:   models.PaymentStatusSucceeded,
+	}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPaymentHandler(db, "", PaymentOptions{CommissionRate: 0.2}).creditCreator(db, transaction); err != nil {
+		t.Fatal(err)
+	}
+	h := NewCreatorHandler(db, CreatorOptions{})
+	admin := NewAdminHandler(db)
+
+	saveDetails := func(accountNumber string) {
+		t.Helper()
+		rec := serve(h.UpdatePayoutDetails, http.MethodPut, "/api/creators/payout-details", nil, owner.ID,
+			`{"bank_name":"Test Bank","account_number":"`+accountNumber+`","ifsc_code":"TEST0123456","account_holder":"Test Creator"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("save details = %d: %s", rec.Code, rec.Body)
+		}
+		var resp PayoutDetailsResponse
+		decodeBody(t, rec, &resp)
+		if resp.Verified {
+			t.Error("newly saved payout details are already verified")
+		}
+	}
+	requestPayout := func() int {
+		return serve(h.RequestPayout, http.MethodPost, "/api/creators/payouts", nil, owner.ID, `{"amount":10}`).Code
+	}
+
+	saveDetails("123456789012")
+	if code := requestPayout(); code != http.StatusConflict {
+		t.Fatalf("payout to unverified details = %d, want 409", code)
+	}
+
+	rec := serve(admin.VerifyPayoutDetails, http.MethodPost, "/api/admin/creators/"+creator.ID+"/payout-details/verify",
+		map[string]string{"id": creator.ID}, "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify = %d: %s", rec.Code, rec.Body)
+	}
+	if code := requestPayout(); code != http.StatusCreated {
+		t.Fatalf("payout to verified details = %d, want 201", code)
+	}
+
+	// A new account must be verified again
+	saveDetails("987654321098")
+	var details models.PayoutDetails
+	if err := db.Where("creator_id = ?", creator.ID).First(&details).Error; err != nil {
+		t.Fatal(err)
+	}
+	if details.Verified {
+		t.Error("changing the bank account kept it verified")
+	}
+	if code := requestPayout(); code != http.StatusConflict {
+		t.Errorf("payout after changing details = %d, want 409", code)
+	}
+}
+
+func TestUpdateCreatorProfileFieldErrors(t *testing.T) {
+	// Both checks run before the profile is loaded
+	h := NewCreatorHandler(nil, CreatorOptions{S3Bucket: "streamshort-uploads", ImageHosts: []string{"images.example.com"}})
+	tests := []struct {
+		body  string
+		field string
+		rule  string
+	}{
+		{`{"kyc_document_s3_path":"s3://streamshort-uploads/uploads/someone-else/kyc.pdf"}`, "kyc_document_s3_path", "own_upload"},
+		{`{"avatar_url":"https://evil.example.net/avatar.png"}`, "avatar_url", "allowed_host"},
+	}
+	for _, tt := range tests {
+		rec := serve(h.UpdateCreatorProfile, http.MethodPut, "/api/creators/profile", nil, "user-1", tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", tt.body, rec.Code)
+		}
+		assertFieldError(t, rec, tt.field, tt.rule)
+	}
+}
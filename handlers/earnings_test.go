@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestCreatorEarningsLedger(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	buyer := createTestUser(t, db)
+	h := NewPaymentHandler(db, "", PaymentOptions{CommissionRate: 0.2})
+
+	for _, amount := range []float64{99.99, 10.01} {
+		transaction := models.PaymentTransaction{
+			UserID:   buyer.ID,
+			SeriesID: series.ID,
+			Amount:   amount,
+			Currency: "INR",
+			Status:   models.PaymentStatusSucceeded,
+		}
+		if err := db.Create(&transaction).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := h.creditCreator(db, transaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var entries []models.CreatorEarning
+	if err := db.Where("creator_id = ?", creator.ID).Order("gross_amount DESC").Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ledger has %d entries, want 2", len(entries))
+	}
+	// 99.99 * 0.8 = 79.992 and 10.01 * 0.8 = 8.008, rounded to the paisa
+	if entries[0].NetAmount != 79.99 || entries[1].NetAmount != 8.01 {
+		t.Errorf("net amounts = %v, %v; want 79.99, 8.01", entries[0].NetAmount, entries[1].NetAmount)
+	}
+	if entries[0].CommissionRate != 0.2 || entries[0].GrossAmount != 99.99 {
+		t.Errorf("entry = %+v, want gross 99.99 at rate 0.2", entries[0])
+	}
+
+	for _, payout := range []models.CreatorPayout{
+		{CreatorID: creator.ID, Amount: 20, Status: models.PayoutStatusPaid},
+		{CreatorID: creator.ID, Amount: 10, Status: models.PayoutStatusPending},
+		{CreatorID: creator.ID, Amount: 5, Status: models.PayoutStatusProcessing},
+		{CreatorID: creator.ID, Amount: 50, Status: models.PayoutStatusFailed},
+	} {
+		if err := db.Create(&payout).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	available, err := availableEarnings(db, creator.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 87.99 earned less 35 paid, pending or processing; the failed payout is returned
+	if got := float64(int64(available*100+0.5)) / 100; got != 52.99 {
+		t.Errorf("availableEarnings = %v, want 52.99", available)
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"gorm.io/gorm"
+)
+
+type ExportProfile struct {
+	ID        string    `json:"id"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportCreatorProfile struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   *string   `json:"avatar_url"`
+	KYCStatus   string    `json:"kyc_status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ExportSubscription struct {
+	ID          string     `json:"id"`
+	SeriesID    string     `json:"series_id"`
+	SeriesTitle string     `json:"series_title"`
+	PlanID      string     `json:"plan_id"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	AutoRenew   bool       `json:"auto_renew"`
+	StartedAt   *time.Time `json:"started_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ExportPurchase struct {
+	ID          string     `json:"id"`
+	SeriesID    string     `json:"series_id"`
+	SeriesTitle string     `json:"series_title"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ExportWatchProgress struct {
+	EpisodeID       string    `json:"episode_id"`
+	EpisodeTitle    string    `json:"episode_title"`
+	PositionSeconds int       `json:"position_seconds"`
+	Completed       bool      `json:"completed"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type ExportView struct {
+	EpisodeID    string    `json:"episode_id"`
+	EpisodeTitle string    `json:"episode_title"`
+	ViewedAt     time.Time `json:"viewed_at"`
+}
+
+type ExportComment struct {
+	ID        string    `json:"id"`
+	EpisodeID string    `json:"episode_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExportRating struct {
+	EpisodeID string    `json:"episode_id"`
+	Score     int       `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportEngagement struct {
+	EpisodeID string    `json:"episode_id,omitempty"`
+	SeriesID  string    `json:"series_id,omitempty"`
+	CreatorID string    `json:"creator_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DataExport is everything the service stores about a user, without other
+// users' data or internal bookkeeping fields
+type DataExport struct {
+	ExportedAt     time.Time             `json:"exported_at"`
+	Profile        ExportProfile         `json:"profile"`
+	CreatorProfile *ExportCreatorProfile `json:"creator_profile"`
+	Preferences    *PreferencesResponse  `json:"preferences"`
+	Subscriptions  []ExportSubscription  `json:"subscriptions"`
+	Purchases      []ExportPurchase      `json:"purchases"`
+	WatchProgress  []ExportWatchProgress `json:"watch_progress"`
+	WatchHistory   []ExportView          `json:"watch_history"`
+	Comments       []ExportComment       `json:"comments"`
+	Ratings        []ExportRating        `json:"ratings"`
+	Likes          []ExportEngagement    `json:"likes"`
+	Favorites      []ExportEngagement    `json:"favorites"`
+	Following      []ExportEngagement    `json:"following"`
+}
+
+// ExportData returns the authenticated user's data as a downloadable JSON
+// document. It reads every table the user has rows in, so the route is rate
+// limited separately from the rest of the API.
+func (h *UserHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	db := h.db.WithContext(r.Context())
+	export := DataExport{
+		ExportedAt:    time.Now(),
+		Subscriptions: []ExportSubscription{},
+		Purchases:     []ExportPurchase{},
+		WatchProgress: []ExportWatchProgress{},
+		WatchHistory:  []ExportView{},
+		Comments:      []ExportComment{},
+		Ratings:       []ExportRating{},
+		Likes:         []ExportEngagement{},
+		Favorites:     []ExportEngagement{},
+		Following:     []ExportEngagement{},
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	export.Profile = ExportProfile{ID: user.ID, Phone: user.Phone, CreatedAt: user.CreatedAt}
+
+	var creator models.CreatorProfile
+	if err := db.Where("user_id = ?", userID).First(&creator).Error; err == nil {
+		export.CreatorProfile = &ExportCreatorProfile{
+			ID:          creator.ID,
+			DisplayName: creator.DisplayName,
+			Bio:         creator.Bio,
+			AvatarURL:   creator.AvatarURL,
+			KYCStatus:   creator.KYCStatus,
+			CreatedAt:   creator.CreatedAt,
+		}
+	} else if err != gorm.ErrRecordNotFound {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	var prefs models.UserPreferences
+	if err := db.Where("user_id = ?", userID).First(&prefs).Error; err == nil {
+		response := toPreferencesResponse(prefs)
+		export.Preferences = &response
+	} else if err != gorm.ErrRecordNotFound {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	queries := []struct {
+		name string
+		run  func() error
+	}{
+		{"subscriptions", func() error {
+			return db.Table("subscriptions").
+				Select("subscriptions.id, subscriptions.series_id, series.title AS series_title, subscriptions.plan_id, subscriptions.amount, "+
+					"subscriptions.status, subscriptions.auto_renew, subscriptions.started_at, subscriptions.expires_at, subscriptions.created_at").
+				Joins("LEFT JOIN series ON series.id = subscriptions.series_id").
+				Where("subscriptions.user_id = ? AND subscriptions.deleted_at IS NULL", userID).
+				Order("subscriptions.created_at").Scan(&export.Subscriptions).Error
+		}},
+		{"purchases", func() error {
+			return db.Table("purchases").
+				Select("purchases.id, purchases.series_id, series.title AS series_title, purchases.amount, "+
+					"purchases.status, purchases.completed_at, purchases.created_at").
+				Joins("LEFT JOIN series ON series.id = purchases.series_id").
+				Where("purchases.user_id = ? AND purchases.deleted_at IS NULL", userID).
+				Order("purchases.created_at").Scan(&export.Purchases).Error
+		}},
+		{"watch progress", func() error {
+			return db.Table("watch_progress").
+				Select("watch_progress.episode_id, episodes.title AS episode_title, watch_progress.position_seconds, watch_progress.completed, watch_progress.updated_at").
+				Joins("LEFT JOIN episodes ON episodes.id = watch_progress.episode_id").
+				Where("watch_progress.user_id = ? AND watch_progress.deleted_at IS NULL", userID).
+				Order("watch_progress.updated_at").Scan(&export.WatchProgress).Error
+		}},
+		{"watch history", func() error {
+			return db.Table("episode_views").
+				Select("episode_views.episode_id, episodes.title AS episode_title, episode_views.viewed_at").
+				Joins("LEFT JOIN episodes ON episodes.id = episode_views.episode_id").
+				Where("episode_views.user_id = ?", userID).
+				Order("episode_views.viewed_at").Scan(&export.WatchHistory).Error
+		}},
+		{"comments", func() error {
+			return db.Model(&models.EpisodeComment{}).Select("id, episode_id, text, created_at, updated_at").
+				Where("user_id = ?", userID).Order("created_at").Scan(&export.Comments).Error
+		}},
+		{"ratings", func() error {
+			return db.Model(&models.EpisodeRating{}).Select("episode_id, score, created_at").
+				Where("user_id = ?", userID).Order("created_at").Scan(&export.Ratings).Error
+		}},
+		{"likes", func() error {
+			return db.Model(&models.EpisodeLike{}).Select("episode_id, created_at").
+				Where("user_id = ?", userID).Order("created_at").Scan(&export.Likes).Error
+		}},
+		{"favorites", func() error {
+			return db.Model(&models.UserFavorite{}).Select("series_id, created_at").
+				Where("user_id = ?", userID).Order("created_at").Scan(&export.Favorites).Error
+		}},
+		{"following", func() error {
+			return db.Model(&models.CreatorFollow{}).Select("creator_id, created_at").
+				Where("user_id = ?", userID).Order("created_at").Scan(&export.Following).Error
+		}},
+	}
+	for _, q := range queries {
+		if err := q.run(); err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, fmt.Sprintf("Failed to export %s", q.name))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="streamshort-export-%s.json"`, export.ExportedAt.Format("2006-01-02")))
+	json.NewEncoder(w).Encode(export)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// createTestUser inserts a user with a random phone number
+func createTestUser(t *testing.T, db *gorm.DB) models.User {
+	t.Helper()
+	user := models.User{Phone: fmt.Sprintf("+919%09d", rand.Intn(1e9))}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createTestCreator inserts a user onboarded as a creator
+func createTestCreator(t *testing.T, db *gorm.DB) (models.User, models.CreatorProfile) {
+	t.Helper()
+	user := createTestUser(t, db)
+	creator := models.CreatorProfile{UserID: user.ID, DisplayName: "Test Creator"}
+	if err := db.Create(&creator).Error; err != nil {
+		t.Fatalf("create creator: %v", err)
+	}
+	return user, creator
+}
+
+// createTestSeries inserts a free, published series for the creator; mutate
+// may adjust it before the insert
+func createTestSeries(t *testing.T, db *gorm.DB, creatorID string, mutate func(*models.Series)) models.Series {
+	t.Helper()
+	series := models.Series{
+		CreatorID: creatorID,
+		Title:     "Test Series",
+		Synopsis:  "A series for tests",
+		Language:  "en",
+		PriceType: "free",
+		Status:    "published",
+	}
+	if mutate != nil {
+		mutate(&series)
+	}
+	if err := db.Create(&series).Error; err != nil {
+		t.Fatalf("create series: %v", err)
+	}
+	return series
+}
+
+// createTestEpisode inserts an episode of the series with the given number and status
+func createTestEpisode(t *testing.T, db *gorm.DB, seriesID string, number int, status string) models.Episode {
+	t.Helper()
+	episode := models.Episode{
+		SeriesID:        seriesID,
+		Title:           fmt.Sprintf("Episode %d", number),
+		EpisodeNumber:   number,
+		DurationSeconds: 60,
+		Status:          status,
+	}
+	if status == "published" {
+		now := time.Now()
+		episode.PublishedAt = &now
+	}
+	if err := db.Create(&episode).Error; err != nil {
+		t.Fatalf("create episode: %v", err)
+	}
+	return episode
+}
+
+// asUser returns r as if AuthMiddleware had authenticated userID
+func asUser(r *http.Request, userID string) *http.Request {
+	ctx := context.WithValue(r.Context(), "user_id", userID)
+	ctx = context.WithValue(ctx, "role", models.RoleUser)
+	return r.WithContext(ctx)
+}
+
+// serve calls handler with the route variables vars, authenticated as userID
+// unless it is empty, and returns the recorded response
+func serve(handler http.HandlerFunc, method, target string, vars map[string]string, userID, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := mux.SetURLVars(httptest.NewRequest(method, target, reader), vars)
+	if userID != "" {
+		req = asUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// decodeBody decodes the recorded JSON response into v
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body, err)
+	}
+}
+
+// assertFieldError checks that rec is a validation error reporting field
+// as failing rule
+func assertFieldError(t *testing.T, rec *httptest.ResponseRecorder, field, rule string) {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				Fields map[string]httputil.FieldError `json:"fields"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	decodeBody(t, rec, &body)
+	if body.Error.Code != httputil.CodeInvalidRequest {
+		t.Errorf("code = %q, want %q", body.Error.Code, httputil.CodeInvalidRequest)
+	}
+	if got, ok := body.Error.Details.Fields[field]; !ok || got.Rule != rule {
+		t.Errorf("fields = %+v, want %s failing %s", body.Error.Details.Fields, field, rule)
+	}
+}
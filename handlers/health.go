@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// healthCheckTimeout bounds each dependency check so a hung dependency
+// cannot hang the probe
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is an extra dependency probed by the health endpoints
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	db             *gorm.DB
+	checks         []HealthCheck
+	migratedModels []interface{}
+}
+
+// NewHealthHandler creates a health handler. The database is always checked;
+// checks adds optional dependencies such as Redis. migratedModels are the
+// models whose tables must exist for the instance to be ready.
+func NewHealthHandler(db *gorm.DB, migratedModels []interface{}, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{db: db, checks: checks, migratedModels: migratedModels}
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Health reports whether the server can reach its dependencies.
+// It responds 503 when any of them is down.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	h.writeChecks(w, r, h.dependencyChecks())
+}
+
+// Ready reports whether the instance can serve traffic: dependencies are
+// reachable and every migrated table exists.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	checks := append(h.dependencyChecks(), HealthCheck{Name: "migrations", Check: h.checkMigrations})
+	h.writeChecks(w, r, checks)
+}
+
+func (h *HealthHandler) dependencyChecks() []HealthCheck {
+	return append([]HealthCheck{{Name: "database", Check: h.pingDB}}, h.checks...)
+}
+
+func (h *HealthHandler) pingDB(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (h *HealthHandler) checkMigrations(ctx context.Context) error {
+	migrator := h.db.WithContext(ctx).Migrator()
+	for _, model := range h.migratedModels {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table for %T is missing", model)
+		}
+	}
+	return nil
+}
+
+func (h *HealthHandler) writeChecks(w http.ResponseWriter, r *http.Request, checks []HealthCheck) {
+	response := HealthResponse{Status: "ok", Checks: make(map[string]CheckResult, len(checks))}
+	for _, c := range checks {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		start := time.Now()
+		err := c.Check(ctx)
+		cancel()
+
+		result := CheckResult{
+			Status:    "up",
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Status = "down"
+			result.Error = err.Error()
+			response.Status = "unavailable"
+		}
+		response.Checks[c.Name] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// heartbeatTolerance absorbs clock and network jitter between heartbeats
+// when checking that playback advanced no faster than real time
+const heartbeatTolerance = 2 * time.Second
+
+var errSessionEpisodeMismatch = errors.New("session belongs to another episode")
+
+type HeartbeatRequest struct {
+	SessionID       string `json:"session_id" validate:"required,max=64"`
+	PositionSeconds int    `json:"position_seconds" validate:"gte=0"`
+}
+
+type HeartbeatResponse struct {
+	SessionID string `json:"session_id"`
+	// CreditedSeconds is the watch time this heartbeat added to the session
+	CreditedSeconds int `json:"credited_seconds"`
+	WatchedSeconds  int `json:"watched_seconds"`
+}
+
+// RecordHeartbeat accumulates watch time for a playback session from the
+// player's periodic position pings. Only forward playback is credited, never
+// faster than wall-clock time, and a session never counts more than the
+// episode's duration, so seeking and looping cannot inflate it. Repeating a
+// heartbeat credits nothing, and users without access to a paid series
+// cannot record any.
+func (h *SocialHandler) RecordHeartbeat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req HeartbeatRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	// Watch time only counts on episodes the user may actually play
+	episode, ok := playableEpisode(w, r, h.db, userID, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	if req.PositionSeconds > episode.DurationSeconds {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "position_seconds must be between 0 and the episode duration")
+		return
+	}
+
+	now := time.Now()
+	response := HeartbeatResponse{SessionID: req.SessionID}
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// The first heartbeat only marks where playback started
+		session := models.PlaybackSession{
+			UserID:              userID,
+			SessionID:           req.SessionID,
+			EpisodeID:           episode.ID,
+			LastPositionSeconds: req.PositionSeconds,
+			LastHeartbeatAt:     now,
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&session)
+		if res.Error != nil || res.RowsAffected == 1 {
+			return res.Error
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND session_id = ?", userID, req.SessionID).First(&session).Error; err != nil {
+			return err
+		}
+		if session.EpisodeID != episode.ID {
+			return errSessionEpisodeMismatch
+		}
+
+		elapsed := int((now.Sub(session.LastHeartbeatAt) + heartbeatTolerance).Seconds())
+		credited := min(req.PositionSeconds-session.LastPositionSeconds, elapsed, episode.DurationSeconds-session.WatchedSeconds)
+		credited = max(credited, 0)
+
+		response.CreditedSeconds = credited
+		response.WatchedSeconds = session.WatchedSeconds + credited
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"last_position_seconds": req.PositionSeconds,
+			"last_heartbeat_at":     now,
+			"watched_seconds":       response.WatchedSeconds,
+		}).Error
+	})
+	if err == errSessionEpisodeMismatch {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "session_id is already in use for another episode")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to record heartbeat")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
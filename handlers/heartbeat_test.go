@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRecordHeartbeatRequiresSeriesAccess(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	price := 99.0
+	series := createTestSeries(t, db, creator.ID, func(s *models.Series) {
+		s.PriceType = "one_time"
+		s.PriceAmount = &price
+	})
+	episode := createTestEpisode(t, db, series.ID, 1, "published")
+	viewer := createTestUser(t, db)
+	h := NewSocialHandler(db)
+
+	heartbeat := func(sessionID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/episodes/"+episode.ID+"/heartbeat",
+			strings.NewReader(`{"session_id":"`+sessionID+`","position_seconds":0}`))
+		req = mux.SetURLVars(asUser(req, viewer.ID), map[string]string{"id": episode.ID})
+		rec := httptest.NewRecorder()
+		h.RecordHeartbeat(rec, req)
+		return rec.Code
+	}
+
+	if code := heartbeat("no-access"); code != http.StatusForbidden {
+		t.Fatalf("heartbeat without a purchase = %d, want 403", code)
+	}
+	var sessions int64
+	db.Model(&models.PlaybackSession{}).Where("user_id = ?", viewer.ID).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("%d sessions recorded without access", sessions)
+	}
+
+	now := time.Now()
+	purchase := models.Purchase{UserID: viewer.ID, SeriesID: series.ID, Amount: price,
+		Status: models.PurchaseStatusCompleted, CompletedAt: &now}
+	if err := db.Create(&purchase).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code := heartbeat("paid"); code != http.StatusOK {
+		t.Fatalf("heartbeat after purchase = %d, want 200", code)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"streamshort/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyKeyTTL is how long a key keeps returning its original result
+const idempotencyKeyTTL = 24 * time.Hour
+
+// Scopes keep keys for different endpoints from colliding
+const (
+	idempotencyScopeSubscription = "create_subscription"
+	idempotencyScopePurchase     = "create_purchase"
+	idempotencyScopeUpload       = "upload_url"
+)
+
+var errIdempotencyKeyTooLong = errors.New("Idempotency-Key must be at most 255 characters")
+
+// idempotencyKey returns the client-supplied Idempotency-Key header, or "" if none was sent
+func idempotencyKey(r *http.Request) (string, error) {
+	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(key) > 255 {
+		return "", errIdempotencyKeyTooLong
+	}
+	return key, nil
+}
+
+// findIdempotentResource returns the resource ID previously stored for the key, if it has not expired
+func findIdempotentResource(db *gorm.DB, userID, scope, key string) (string, bool, error) {
+	var record models.IdempotencyKey
+	err := db.Where("user_id = ? AND scope = ? AND key = ? AND expires_at > ?", userID, scope, key, time.Now()).
+		First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return record.ResourceID, true, nil
+}
+
+// saveIdempotentResource records the resource produced for the key. An expired
+// record for the same key is replaced.
+func saveIdempotentResource(db *gorm.DB, userID, scope, key, resourceID string) error {
+	record := models.IdempotencyKey{
+		UserID:     userID,
+		Scope:      scope,
+		Key:        key,
+		ResourceID: resourceID,
+		ExpiresAt:  time.Now().Add(idempotencyKeyTTL),
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "scope"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"resource_id", "expires_at", "updated_at"}),
+	}).Create(&record).Error
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"streamshort/pkg/httputil"
+)
+
+// Language is a content language clients can offer in pickers and filters
+type Language struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	NativeName string `json:"native_name"`
+}
+
+// supportedLanguages are the languages series can be published in and users
+// can prefer, keyed by lowercase ISO 639-1 code
+var supportedLanguages = []Language{
+	{Code: "en", Name: "English", NativeName: "English"},
+	{Code: "hi", Name: "Hindi", NativeName: "हिन्दी"},
+	{Code: "bn", Name: "Bengali", NativeName: "বাংলা"},
+	{Code: "ta", Name: "Tamil", NativeName: "தமிழ்"},
+	{Code: "te", Name: "Telugu", NativeName: "తెలుగు"},
+	{Code: "mr", Name: "Marathi", NativeName: "मराठी"},
+	{Code: "gu", Name: "Gujarati", NativeName: "ગુજરાતી"},
+	{Code: "kn", Name: "Kannada", NativeName: "ಕನ್ನಡ"},
+	{Code: "ml", Name: "Malayalam", NativeName: "മലയാളം"},
+	{Code: "pa", Name: "Punjabi", NativeName: "ਪੰਜਾਬੀ"},
+	{Code: "or", Name: "Odia", NativeName: "ଓଡ଼ିଆ"},
+	{Code: "ur", Name: "Urdu", NativeName: "اردو"},
+}
+
+// normalizeLanguage returns the supported code for lang, matched
+// case-insensitively, and whether there is one
+func normalizeLanguage(lang string) (string, bool) {
+	code := strings.ToLower(strings.TrimSpace(lang))
+	for _, l := range supportedLanguages {
+		if l.Code == code {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// writeUnsupportedLanguage rejects a language code that is not supported
+func writeUnsupportedLanguage(w http.ResponseWriter) {
+	httputil.WriteValidationError(w, map[string]httputil.FieldError{
+		"language": {Rule: "language", Message: "language must be a supported language code (see GET /content/languages)"},
+	})
+}
+
+type LanguagesResponse struct {
+	Languages []Language `json:"languages"`
+}
+
+// ListLanguages returns the supported content languages
+func (h *ContentHandler) ListLanguages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LanguagesResponse{Languages: supportedLanguages})
+}
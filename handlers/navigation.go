@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+
+	"streamshort/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EpisodeNavigation links an episode to its neighbours among the series'
+// published episodes so players can offer autoplay. NextSeriesID is only
+// suggested once the series has no next episode.
+type EpisodeNavigation struct {
+	PrevEpisodeID *string `json:"prev_episode_id"`
+	NextEpisodeID *string `json:"next_episode_id"`
+	NextSeriesID  *string `json:"next_series_id,omitempty"`
+}
+
+// episodeNavigation finds the published episodes numbered immediately before
+// and after episode within its series
+func (h *ContentHandler) episodeNavigation(ctx context.Context, episode models.Episode) (EpisodeNavigation, error) {
+	var nav EpisodeNavigation
+	published := h.db.WithContext(ctx).Model(&models.Episode{}).
+		Where("series_id = ? AND status = ?", episode.SeriesID, "published")
+
+	prev, err := firstID(published.Session(&gorm.Session{}).
+		Where("episode_number < ?", episode.EpisodeNumber).Order("episode_number DESC"))
+	if err != nil {
+		return nav, err
+	}
+	next, err := firstID(published.Session(&gorm.Session{}).
+		Where("episode_number > ?", episode.EpisodeNumber).Order("episode_number ASC"))
+	if err != nil {
+		return nav, err
+	}
+	nav.PrevEpisodeID, nav.NextEpisodeID = prev, next
+
+	if next == nil {
+		// Suggest the creator's newest other series, else one sharing a category
+		query := h.db.WithContext(ctx).Model(&models.Series{}).
+			Where("id <> ? AND status = ?", episode.Series.ID, "published")
+		if len(episode.Series.CategoryTags) > 0 {
+			query = query.Where("creator_id = ? OR category_tags && ?", episode.Series.CreatorID, episode.Series.CategoryTags)
+		} else {
+			query = query.Where("creator_id = ?", episode.Series.CreatorID)
+		}
+		nav.NextSeriesID, err = firstID(query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "creator_id = ? DESC, created_at DESC",
+			Vars:               []interface{}{episode.Series.CreatorID},
+			WithoutParentheses: true,
+		}}))
+		if err != nil {
+			return nav, err
+		}
+	}
+	return nav, nil
+}
+
+// firstID returns the id of the query's first row, or nil when it has none
+func firstID(query *gorm.DB) (*string, error) {
+	var ids []string
+	if err := query.Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
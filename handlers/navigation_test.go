@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestEpisodeNavigation(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	_, stranger := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	first := createTestEpisode(t, db, series.ID, 1, "published")
+	createTestEpisode(t, db, series.ID, 2, "ready")
+	middle := createTestEpisode(t, db, series.ID, 3, "published")
+	last := createTestEpisode(t, db, series.ID, 4, "published")
+
+	// Only the creator's other published series is a candidate for next_series_id
+	createTestSeries(t, db, creator.ID, func(s *models.Series) { s.Status = "draft" })
+	createTestSeries(t, db, stranger.ID, nil)
+	nextSeries := createTestSeries(t, db, creator.ID, nil)
+
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+	id := func(s *string) string {
+		if s == nil {
+			return "<nil>"
+		}
+		return *s
+	}
+
+	tests := []struct {
+		name                   string
+		episode                models.Episode
+		prev, next, nextSeries string
+	}{
+		{"first", first, "<nil>", middle.ID, "<nil>"},
+		{"middle skips unpublished episodes", middle, first.ID, last.ID, "<nil>"},
+		{"last suggests another series", last, middle.ID, "<nil>", nextSeries.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.episode.Series = series
+			nav, err := h.episodeNavigation(context.Background(), tt.episode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := id(nav.PrevEpisodeID); got != tt.prev {
+				t.Errorf("prev = %s, want %s", got, tt.prev)
+			}
+			if got := id(nav.NextEpisodeID); got != tt.next {
+				t.Errorf("next = %s, want %s", got, tt.next)
+			}
+			if got := id(nav.NextSeriesID); got != tt.nextSeries {
+				t.Errorf("next series = %s, want %s", got, tt.nextSeries)
+			}
+		})
+	}
+}
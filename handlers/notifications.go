@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// notificationDispatchTimeout bounds the background fan-out after a publish
+const notificationDispatchTimeout = 30 * time.Second
+
+// dispatchNewEpisodeNotifications notifies everyone who subscribes to or
+// favorited the episode's published series or follows its creator, except
+// users who turned new-episode notifications off. It runs in the background and
+// only logs failures, so publishing never depends on it. Re-publishing an
+// episode does not notify twice.
+func dispatchNewEpisodeNotifications(db *gorm.DB, episode models.Episode) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationDispatchTimeout)
+		defer cancel()
+
+		var series models.Series
+		if err := db.WithContext(ctx).Select("id", "creator_id", "title", "status").Where("id = ?", episode.SeriesID).First(&series).Error; err != nil {
+			log.Printf("Failed to load series %s for episode notifications: %v", episode.SeriesID, err)
+			return
+		}
+		// Episodes of an unpublished series aren't visible to viewers yet
+		if series.Status != "published" {
+			return
+		}
+
+		title := fmt.Sprintf("New episode of %s", series.Title)
+		body := fmt.Sprintf("Episode %d: %s", episode.EpisodeNumber, episode.Title)
+
+		// Subscribers with access match models.IsSubscriptionActive
+		res := db.WithContext(ctx).Exec(`
+			INSERT INTO notifications (user_id, type, title, body, series_id, episode_id, created_at)
+			SELECT audience.user_id, ?, ?, ?, ?, ?, NOW()
+			FROM (
+				SELECT user_id FROM subscriptions
+				WHERE series_id = ? AND status IN (?, ?) AND expires_at > NOW() AND deleted_at IS NULL
+				UNION
+				SELECT user_id FROM user_favorites WHERE series_id = ?
+				UNION
+				SELECT user_id FROM creator_follows WHERE creator_id = ?
+			) audience
+			LEFT JOIN user_preferences ON user_preferences.user_id = audience.user_id AND user_preferences.deleted_at IS NULL
+			WHERE COALESCE(user_preferences.notify_new_episodes, TRUE)
+			ON CONFLICT (user_id, type, episode_id) DO NOTHING`,
+			models.NotificationTypeNewEpisode, title, body, series.ID, episode.ID,
+			series.ID, models.SubscriptionStatusActive, models.SubscriptionStatusCancelled,
+			series.ID, series.CreatorID)
+		if res.Error != nil {
+			log.Printf("Failed to dispatch notifications for episode %s: %v", episode.ID, res.Error)
+			return
+		}
+		log.Printf("Queued %d new-episode notifications for episode %s", res.RowsAffected, episode.ID)
+	}()
+}
+
+type NotificationsResponse struct {
+	httputil.PaginatedResponse[models.Notification]
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// GetNotifications lists the user's notifications, newest first. Pass
+// unread=true to list only unread ones.
+func (h *UserHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	var unread int64
+	if err := h.db.WithContext(r.Context()).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch notifications")
+		return
+	}
+
+	query := h.db.WithContext(r.Context()).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if r.URL.Query().Get("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch notifications")
+		return
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Offset(offset).Limit(perPage).Find(&notifications).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NotificationsResponse{
+		PaginatedResponse: httputil.NewPaginatedResponse(notifications, total, page, perPage),
+		UnreadCount:       unread,
+	})
+}
+
+// MarkNotificationRead marks one of the user's notifications as read.
+// Marking an already read notification keeps its original read time.
+func (h *UserHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var notification models.Notification
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND user_id = ?", mux.Vars(r)["id"], userID).
+		First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Notification not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := h.db.WithContext(r.Context()).Model(&notification).Update("read_at", now).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update notification")
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notification)
+}
@@ -1,23 +1,46 @@
 package handlers
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
-	"github.com/google/uuid"
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/metrics"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// PaymentOptions holds billing settings for the payment handler
+type PaymentOptions struct {
+	// SubscriptionPeriod is how long each successful subscription payment extends access
+	SubscriptionPeriod time.Duration
+	// CommissionRate is the share of each payment the platform keeps (0-1)
+	CommissionRate float64
+}
+
 type PaymentHandler struct {
-	// In a real implementation, you'd have payment service clients here
+	db            *gorm.DB
+	webhookSecret string
+	opts          PaymentOptions
 }
 
-func NewPaymentHandler() *PaymentHandler {
-	return &PaymentHandler{}
+func NewPaymentHandler(db *gorm.DB, webhookSecret string, opts PaymentOptions) *PaymentHandler {
+	return &PaymentHandler{db: db, webhookSecret: webhookSecret, opts: opts}
 }
 
 // Request/Response structs matching OpenAPI schema
 type CreateSubscriptionRequest struct {
+	SeriesID      string `json:"series_id"`
 	PlanID        string `json:"plan_id"`
 	PaymentMethod string `json:"payment_method"`
 	AutoRenew     bool   `json:"auto_renew"`
@@ -26,105 +49,488 @@ type CreateSubscriptionRequest struct {
 type CreateSubscriptionResponse struct {
 	SubscriptionID string    `json:"subscription_id"`
 	Status         string    `json:"status"`
+	SeriesID       string    `json:"series_id"`
 	PlanID         string    `json:"plan_id"`
-	StartDate      time.Time `json:"start_date"`
-	EndDate        time.Time `json:"end_date"`
-	NextBilling    time.Time `json:"next_billing"`
+	Amount         float64   `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type SubscriptionResponse struct {
+	ID        string         `json:"id"`
+	SeriesID  string         `json:"series_id"`
+	PlanID    string         `json:"plan_id"`
+	Amount    float64        `json:"amount"`
+	AutoRenew bool           `json:"auto_renew"`
+	Status    string         `json:"status"`
+	Active    bool           `json:"active"`
+	StartedAt *time.Time     `json:"started_at"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	Series    *SeriesSummary `json:"series,omitempty"`
+}
+
+type SubscriptionListResponse struct {
+	Items []SubscriptionResponse `json:"items"`
 }
 
 type WebhookRequest struct {
 	EventType string                 `json:"event_type"`
 	Data      map[string]interface{} `json:"data"`
-	Signature string                 `json:"signature"`
 }
 
 type WebhookResponse struct {
 	Status string `json:"status"`
 }
 
+// errActiveSubscription is returned when the user already subscribes to the series
+var errActiveSubscription = errors.New("active subscription exists")
+
 // CreateSubscription handles subscription creation
 func (h *PaymentHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	key, err := idempotencyKey(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
+	// A retried request returns the subscription created by the first attempt
+	if key != "" {
+		subscriptionID, found, err := findIdempotentResource(h.db.WithContext(r.Context()), userID, idempotencyScopeSubscription, key)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		if found {
+			var subscription models.Subscription
+			if err := h.db.WithContext(r.Context()).Where("id = ? AND user_id = ?", subscriptionID, userID).First(&subscription).Error; err == nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(newCreateSubscriptionResponse(subscription))
+				return
+			}
+		}
+	}
+
 	var req CreateSubscriptionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate required fields
-	if req.PlanID == "" {
-		http.Error(w, "Plan ID is required", http.StatusBadRequest)
+	if req.SeriesID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Series ID is required")
 		return
 	}
 
-	// Mock subscription creation (in real implementation, integrate with payment provider)
-	subscriptionID := uuid.New().String()
-	now := time.Now()
+	// Look up the series price
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", req.SeriesID, "published").First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	if series.PriceType != "subscription" || series.PriceAmount == nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Series is not available by subscription")
+		return
+	}
+
+	var subscription models.Subscription
+	replayed := false
+	err = h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the user so their concurrent requests, retries included, cannot
+		// each pass the checks below before the other commits
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", userID).First(&models.User{}).Error; err != nil {
+			return err
+		}
+
+		if key != "" {
+			subscriptionID, found, err := findIdempotentResource(tx, userID, idempotencyScopeSubscription, key)
+			if err != nil {
+				return err
+			}
+			if found {
+				err := tx.Where("id = ? AND user_id = ?", subscriptionID, userID).First(&subscription).Error
+				if err == nil {
+					replayed = true
+					return nil
+				}
+				if err != gorm.ErrRecordNotFound {
+					return err
+				}
+			}
+		}
 
-	// In real implementation, you'd save this to database with userID
-	_ = userID // Use userID to avoid linter warning
+		// A user may hold only one active subscription per series
+		var activeCount int64
+		if err := tx.Model(&models.Subscription{}).
+			Where("user_id = ? AND series_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)",
+				userID, series.ID, models.SubscriptionStatusActive, time.Now()).
+			Count(&activeCount).Error; err != nil {
+			return err
+		}
+		if activeCount > 0 {
+			return errActiveSubscription
+		}
 
-	response := CreateSubscriptionResponse{
-		SubscriptionID: subscriptionID,
-		Status:         "active",
-		PlanID:         req.PlanID,
-		StartDate:      now,
-		EndDate:        now.AddDate(0, 1, 0), // 1 month from now
-		NextBilling:    now.AddDate(0, 1, 0),
+		// Subscription stays pending until the payment provider confirms the charge
+		subscription = models.Subscription{
+			UserID:    userID,
+			SeriesID:  series.ID,
+			PlanID:    req.PlanID,
+			Amount:    *series.PriceAmount,
+			AutoRenew: req.AutoRenew,
+			Status:    models.SubscriptionStatusPending,
+		}
+		if err := tx.Create(&subscription).Error; err != nil {
+			return err
+		}
+		if key != "" {
+			return saveIdempotentResource(tx, userID, idempotencyScopeSubscription, key, subscription.ID)
+		}
+		return nil
+	})
+	if err == errActiveSubscription {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "An active subscription already exists for this series")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create subscription")
+		return
+	}
+	if !replayed {
+		metrics.SubscriptionsCreated.Inc()
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newCreateSubscriptionResponse(subscription))
+}
+
+func newCreateSubscriptionResponse(subscription models.Subscription) CreateSubscriptionResponse {
+	return CreateSubscriptionResponse{
+		SubscriptionID: subscription.ID,
+		Status:         subscription.Status,
+		SeriesID:       subscription.SeriesID,
+		PlanID:         subscription.PlanID,
+		Amount:         subscription.Amount,
+		CreatedAt:      subscription.CreatedAt,
+	}
+}
+
+// CancelSubscription stops a subscription from renewing. Access is kept until
+// the end of the current billing period.
+func (h *PaymentHandler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	subscriptionID := vars["id"]
+
+	var subscription models.Subscription
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND user_id = ?", subscriptionID, userID).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Subscription not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	if subscription.Status == models.SubscriptionStatusCancelled || subscription.Status == models.SubscriptionStatusExpired {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "Subscription is already "+subscription.Status)
+		return
+	}
+
+	// A subscription that was never paid for has no period to honour
+	now := time.Now()
+	expiresAt := subscription.ExpiresAt
+	if expiresAt == nil || expiresAt.Before(now) {
+		expiresAt = &now
+	}
+
+	if err := h.db.WithContext(r.Context()).Model(&subscription).Updates(map[string]interface{}{
+		"status":     models.SubscriptionStatusCancelled,
+		"auto_renew": false,
+		"expires_at": expiresAt,
+	}).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to cancel subscription")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toSubscriptionResponse(subscription))
+}
+
+// GetUserSubscriptions lists the current user's subscriptions, newest first
+func (h *PaymentHandler) GetUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var subscriptions []models.Subscription
+	if err := h.db.WithContext(r.Context()).Preload("Series").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&subscriptions).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	response := SubscriptionListResponse{Items: make([]SubscriptionResponse, 0, len(subscriptions))}
+	for _, sub := range subscriptions {
+		response.Items = append(response.Items, toSubscriptionResponse(sub))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+func toSubscriptionResponse(sub models.Subscription) SubscriptionResponse {
+	response := SubscriptionResponse{
+		ID:        sub.ID,
+		SeriesID:  sub.SeriesID,
+		PlanID:    sub.PlanID,
+		Amount:    sub.Amount,
+		AutoRenew: sub.AutoRenew,
+		Status:    sub.Status,
+		Active:    models.IsSubscriptionActive(&sub),
+		StartedAt: sub.StartedAt,
+		ExpiresAt: sub.ExpiresAt,
+		CreatedAt: sub.CreatedAt,
+	}
+	if sub.Series != nil {
+		response.Series = &SeriesSummary{
+			ID:           sub.Series.ID,
+			Title:        sub.Series.Title,
+			ThumbnailURL: sub.Series.ThumbnailURL,
+		}
+	}
+	return response
+}
+
 // Webhook handles payment webhooks from payment providers
 func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	// Read the raw body once: the signature is computed over the exact bytes received
+	body, ok := httputil.ReadBody(w, r)
+	if !ok {
+		return
+	}
+
 	var req WebhookRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := json.Unmarshal(body, &req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request body")
 		return
 	}
 
-	// Validate webhook signature (in real implementation)
-	if req.Signature == "" {
-		http.Error(w, "Missing signature", http.StatusUnauthorized)
+	signature := r.Header.Get("X-Razorpay-Signature")
+	valid := h.verifySignature(body, signature)
+
+	// Record every delivery, including spoofed ones, for auditing. Only verified
+	// deliveries claim an event ID, so a forged event can't block the real one.
+	audit := models.PaymentWebhook{
+		EventType:      req.EventType,
+		Data:           string(body),
+		Signature:      signature,
+		SignatureValid: valid,
+	}
+	if !valid {
+		if err := h.db.WithContext(r.Context()).Create(&audit).Error; err != nil {
+			log.Printf("Warning: failed to record payment webhook: %v", err)
+		}
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid signature")
+		return
+	}
+
+	eventID := webhookEventID(r, body)
+	audit.EventID = &eventID
+	result := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&audit)
+	if result.Error != nil {
+		// Not persisted yet, so let the provider retry the delivery
+		log.Printf("Failed to record payment webhook %s: %v", eventID, result.Error)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to record webhook")
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		// Redelivery of a known event: acknowledge it, and retry it if it never completed
+		if err := h.db.WithContext(r.Context()).Where("event_id = ?", eventID).First(&audit).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		if audit.ProcessedAt != nil {
+			writeWebhookResponse(w, "duplicate")
+			return
+		}
+	}
+
+	// The event is safely stored; a failure here is retried in the background
+	if err := h.processWebhook(r.Context(), audit.ID); err != nil {
+		log.Printf("Deferred processing of payment webhook %s: %v", eventID, err)
+		writeWebhookResponse(w, "queued")
 		return
 	}
 
-	// Process webhook based on event type
+	writeWebhookResponse(w, "processed")
+}
+
+func writeWebhookResponse(w http.ResponseWriter, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(WebhookResponse{Status: status})
+}
+
+// webhookEventID identifies a delivery for deduplication. Razorpay sends a
+// stable event ID header on every retry; without it the body hash is used.
+func webhookEventID(r *http.Request, body []byte) string {
+	if id := r.Header.Get("X-Razorpay-Event-Id"); id != "" {
+		return id
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// processWebhook applies a stored event and marks it processed in the same
+// transaction, so an event is either fully applied or left for a retry.
+// Failures are counted on the event.
+func (h *PaymentHandler) processWebhook(ctx context.Context, id string) error {
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the event so the inline attempt and the retry loop never both apply it
+		var event models.PaymentWebhook
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&event).Error; err != nil {
+			return err
+		}
+		if event.ProcessedAt != nil {
+			return nil
+		}
+
+		var req WebhookRequest
+		if err := json.Unmarshal([]byte(event.Data), &req); err != nil {
+			return err
+		}
+		// A savepoint lets a flagged event be closed without its partial effects
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return h.applyWebhookEvent(tx, req)
+		})
+		var flag *webhookFlag
+		if errors.As(err, &flag) {
+			log.Printf("Flagged payment webhook %s: %s", event.ID, flag.reason)
+			return tx.Model(&event).Updates(map[string]interface{}{
+				"processed_at": time.Now(),
+				"flag_reason":  flag.reason,
+			}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&event).Update("processed_at", time.Now()).Error
+	})
+	if err != nil {
+		if uerr := h.db.WithContext(ctx).Model(&models.PaymentWebhook{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": err.Error(),
+		}).Error; uerr != nil {
+			log.Printf("Failed to record webhook %s failure: %v", id, uerr)
+		}
+	}
+	return err
+}
+
+// applyWebhookEvent performs the side effects of a verified event inside tx
+func (h *PaymentHandler) applyWebhookEvent(tx *gorm.DB, req WebhookRequest) error {
+	refs := parseWebhookRefs(req.Data)
 	switch req.EventType {
-	case "subscription.created":
-		// Handle subscription creation
-		break
-	case "subscription.updated":
-		// Handle subscription updates
-		break
 	case "subscription.cancelled":
-		// Handle subscription cancellation
-		break
+		return h.cancelProviderSubscription(tx, refs)
 	case "payment.succeeded":
-		// Handle successful payment
-		break
+		return h.applyPaymentSucceeded(tx, refs)
 	case "payment.failed":
-		// Handle failed payment
-		break
+		return h.applyPaymentFailed(tx, refs)
 	default:
-		// Unknown event type
-		break
+		// Other event types are acknowledged and ignored. subscription.created
+		// links nothing: the provider ID is linked by the first verified payment.
 	}
+	return nil
+}
+
+const (
+	// webhookRetryInterval is how often unprocessed webhook events are retried
+	webhookRetryInterval = time.Minute
+	// webhookMaxAttempts stops retrying events that keep failing; they stay recorded for inspection
+	webhookMaxAttempts = 10
+	webhookRetryBatch  = 100
+)
+
+// StartWebhookRetries reprocesses verified webhook events that have not been
+// applied yet, every webhookRetryInterval until ctx is cancelled
+func (h *PaymentHandler) StartWebhookRetries(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(webhookRetryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				var ids []string
+				// Skip fresh events, which the request that stored them is still handling
+				if err := h.db.WithContext(ctx).Model(&models.PaymentWebhook{}).
+					Where("signature_valid = ? AND processed_at IS NULL AND attempts < ? AND created_at < ?",
+						true, webhookMaxAttempts, now.Add(-webhookRetryInterval)).
+					Order("created_at ASC").
+					Limit(webhookRetryBatch).
+					Pluck("id", &ids).Error; err != nil {
+					log.Printf("Failed to load pending payment webhooks: %v", err)
+					continue
+				}
+				for _, id := range ids {
+					if err := h.processWebhook(ctx, id); err != nil {
+						log.Printf("Retry of payment webhook %s failed: %v", id, err)
+					}
+				}
+			}
+		}
+	}()
+}
 
-	response := WebhookResponse{
-		Status: "processed",
+// verifySignature checks the Razorpay HMAC-SHA256 signature of the raw webhook body
+func (h *PaymentHandler) verifySignature(body []byte, signature string) bool {
+	return validHMACSignature(h.webhookSecret, body, signature)
+}
+
+// validHMACSignature reports whether signature is the hex HMAC-SHA256 of body
+// under secret. An empty secret never validates.
+func validHMACSignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
 }
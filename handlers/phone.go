@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/phone"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errPhoneTaken is returned when a phone change targets a number that
+// belongs to another account, including a deleted one still holding it
+var errPhoneTaken = errors.New("phone number taken")
+
+type PhoneChangeResponse struct {
+	UserID string `json:"user_id"`
+	Phone  string `json:"phone"`
+	// SessionsRevoked is set when the change signed out every device; Tokens
+	// then hold a new session for the caller
+	SessionsRevoked bool           `json:"sessions_revoked"`
+	Tokens          *TokenResponse `json:"tokens,omitempty"`
+}
+
+// ChangePhone starts a phone number change by sending a code to the new
+// number. The account keeps its current number until VerifyPhoneChange.
+func (h *AuthHandler) ChangePhone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req PhoneOtpRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	normalized, err := phone.Normalize(req.Phone, phone.DefaultRegion)
+	if err != nil {
+		writeInvalidPhone(w)
+		return
+	}
+
+	var user models.User
+	if err := h.db.WithContext(r.Context()).Where("id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if normalized == user.Phone {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "This is already your phone number")
+		return
+	}
+
+	// Whether the number is taken is only revealed once the caller proves
+	// they own it, so this cannot be used to probe for registered numbers
+	response, ok := h.issueOTP(w, r, normalized)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// VerifyPhoneChange completes a phone number change with the code sent by
+// ChangePhone. When RevokeOnPhoneChange is set every session is signed out
+// and the caller gets a new one; otherwise existing tokens keep working and
+// pick up the new number on their next refresh.
+func (h *AuthHandler) VerifyPhoneChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req PhoneOtpVerifyRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	normalized, err := phone.Normalize(req.Phone, phone.DefaultRegion)
+	if err != nil {
+		writeInvalidPhone(w)
+		return
+	}
+
+	var user models.User
+	var refreshToken string
+	err = h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := h.consumeOTP(r.Context(), tx, normalized, req.TxnID, req.OTP); err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		var taken int64
+		if err := tx.Unscoped().Model(&models.User{}).
+			Where("phone = ? AND id <> ?", normalized, user.ID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return errPhoneTaken
+		}
+
+		if err := tx.Model(&user).Update("phone", normalized).Error; err != nil {
+			// A concurrent signup or change can still claim the number first
+			if isUniqueViolation(err) {
+				return errPhoneTaken
+			}
+			return err
+		}
+		user.Phone = normalized
+
+		if !h.opts.RevokeOnPhoneChange {
+			return nil
+		}
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked = ?", user.ID, false).
+			Update("revoked", true).Error; err != nil {
+			return err
+		}
+		var err error
+		refreshToken, err = h.generateRefreshToken(tx, user.ID, uuid.New().String())
+		return err
+	})
+	if writeOTPError(w, err) {
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+	if err == errPhoneTaken {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "This phone number is already in use by another account")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to change phone number")
+		return
+	}
+
+	response := PhoneChangeResponse{
+		UserID:          user.ID,
+		Phone:           user.Phone,
+		SessionsRevoked: h.opts.RevokeOnPhoneChange,
+	}
+	if h.opts.RevokeOnPhoneChange {
+		accessToken, expiresAt, err := h.generateAccessToken(user)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
+			return
+		}
+		response.Tokens = &TokenResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int(h.opts.AccessTokenTTL.Seconds()),
+			ExpiresAt:    expiresAt,
+			UserID:       user.ID,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
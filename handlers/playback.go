@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// playbackTokenTTL is how long a token from the playback-token endpoint is
+// valid. Clients request a new one before it expires to keep watching.
+const playbackTokenTTL = 15 * time.Minute
+
+// PlaybackToken authorizes requests for every playlist and segment under BaseURL
+type PlaybackToken struct {
+	// Token is a query string to append to each request under BaseURL. It is
+	// empty when CloudFront signing is not configured.
+	Token     string
+	BaseURL   string
+	ExpiresAt time.Time
+	TTL       time.Duration
+}
+
+type PlaybackTokenResponse struct {
+	Token       string    `json:"token"`
+	BaseURL     string    `json:"base_url"`
+	ManifestURL string    `json:"manifest_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	TTLSeconds  int       `json:"ttl_seconds"`
+	// RefreshAfterSeconds is when the client should request a new token so
+	// playback continues without interruption
+	RefreshAfterSeconds int `json:"refresh_after_seconds"`
+}
+
+// episodeStream is where an episode's HLS output lives on the CDN
+type episodeStream struct {
+	masterURL string
+	// outputs maps rendition labels such as "720p" to playlist paths
+	outputs map[string]string
+}
+
+// playableEpisode loads a published episode the user may watch. On failure it
+// writes the error response and returns false. Every endpoint that serves or
+// records playback goes through it.
+func playableEpisode(w http.ResponseWriter, r *http.Request, db *gorm.DB, userID, episodeID string) (models.Episode, bool) {
+	var episode models.Episode
+	if err := db.WithContext(r.Context()).Preload("Series").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return episode, false
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return episode, false
+	}
+
+	// Check if episode is ready for playback
+	if episode.Status != "published" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Episode not ready for playback")
+		return episode, false
+	}
+
+	// Paid series require an active subscription or a completed purchase
+	allowed, err := hasSeriesAccess(r.Context(), db, userID, episode.Series)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return episode, false
+	}
+	if !allowed {
+		message := "An active subscription is required to watch this episode"
+		if episode.Series.PriceType == "one_time" {
+			message = "Purchase this series to watch this episode"
+		}
+		httputil.WriteErrorDetails(w, http.StatusForbidden, httputil.CodePaymentRequired,
+			message, map[string]interface{}{
+				"series_id":    episode.SeriesID,
+				"price_type":   episode.Series.PriceType,
+				"price_amount": episode.Series.PriceAmount,
+			})
+		return episode, false
+	}
+	return episode, true
+}
+
+// episodeStream resolves the master playlist and renditions from the
+// transcoder's most recent successful output
+func (h *ContentHandler) episodeStream(ctx context.Context, episode models.Episode) (episodeStream, error) {
+	var outputs map[string]string
+	var job models.TranscodingJob
+	err := h.db.WithContext(ctx).Where("episode_id = ? AND status = ?", episode.ID, models.TranscodingStatusCompleted).
+		Order("completed_at DESC").First(&job).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return episodeStream{}, err
+	}
+	if err == nil && job.OutputPaths != nil {
+		if err := json.Unmarshal([]byte(*job.OutputPaths), &outputs); err != nil {
+			log.Printf("Ignoring malformed output paths on transcoding job %s: %v", job.ID, err)
+		}
+	}
+
+	masterPath := fmt.Sprintf("hls/%s/index.m3u8", episode.ID)
+	if episode.HLSManifestURL != nil && *episode.HLSManifestURL != "" {
+		masterPath = *episode.HLSManifestURL
+	}
+	if p := outputs["master"]; p != "" {
+		masterPath = p
+	}
+
+	return episodeStream{masterURL: h.cdnURL(masterPath), outputs: outputs}, nil
+}
+
+// issuePlaybackToken signs a token for the directory holding the master
+// playlist, which also holds its renditions and segments
+func (h *ContentHandler) issuePlaybackToken(masterURL string, ttl time.Duration) (PlaybackToken, error) {
+	token := PlaybackToken{
+		BaseURL:   masterURL[:strings.LastIndex(masterURL, "/")+1],
+		ExpiresAt: time.Now().Add(ttl),
+		TTL:       ttl,
+	}
+	if h.signer == nil {
+		return token, nil
+	}
+
+	signed, err := h.signer.SignPrefix(token.BaseURL, token.ExpiresAt)
+	if err != nil {
+		return PlaybackToken{}, err
+	}
+	token.Token = signed
+	return token, nil
+}
+
+// tokenURL authorizes rawURL with the playback token. URLs outside the
+// token's base (e.g. renditions stored elsewhere) are signed individually.
+func (h *ContentHandler) tokenURL(token PlaybackToken, rawURL string) (string, error) {
+	if !strings.HasPrefix(rawURL, token.BaseURL) {
+		return h.signURL(rawURL, token.ExpiresAt)
+	}
+	if token.Token == "" {
+		return rawURL, nil
+	}
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + token.Token, nil
+}
+
+// CreatePlaybackToken issues a short-lived token for streaming an episode.
+// The token goes on every playlist and segment request under base_url.
+// Calling the endpoint again before expires_at renews it, re-checking that
+// the user still has access.
+func (h *ContentHandler) CreatePlaybackToken(w http.ResponseWriter, r *http.Request) {
+	episodeID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	episode, ok := playableEpisode(w, r, h.db, userID, episodeID)
+	if !ok {
+		return
+	}
+
+	stream, err := h.episodeStream(r.Context(), episode)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	token, err := h.issuePlaybackToken(stream.masterURL, playbackTokenTTL)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to sign playback token")
+		return
+	}
+	manifestURL, err := h.tokenURL(token, stream.masterURL)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to sign playback token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(PlaybackTokenResponse{
+		Token:       token.Token,
+		BaseURL:     token.BaseURL,
+		ManifestURL: manifestURL,
+		ExpiresAt:   token.ExpiresAt,
+		TTLSeconds:  int(token.TTL.Seconds()),
+		// Leave a fifth of the lifetime to fetch the next token
+		RefreshAfterSeconds: int(token.TTL.Seconds() * 4 / 5),
+	})
+}
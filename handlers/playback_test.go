@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/testdb"
+)
+
+func TestGetEpisodeManifestRequiresPayment(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	viewer := createTestUser(t, db)
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	tests := []struct {
+		priceType string
+		message   string
+	}{
+		{"subscription", "An active subscription is required to watch this episode"},
+		{"one_time", "Purchase this series to watch this episode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.priceType, func(t *testing.T) {
+			price := 49.0
+			series := createTestSeries(t, db, creator.ID, func(s *models.Series) {
+				s.PriceType = tt.priceType
+				s.PriceAmount = &price
+			})
+			episode := createTestEpisode(t, db, series.ID, 1, "published")
+
+			rec := serve(h.GetEpisodeManifest, http.MethodGet, "/api/content/episodes/"+episode.ID+"/manifest",
+				map[string]string{"id": episode.ID}, viewer.ID, "")
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body httputil.ErrorResponse
+			decodeBody(t, rec, &body)
+			if body.Error.Code != httputil.CodePaymentRequired || body.Error.Message != tt.message {
+				t.Errorf("error = %q %q, want %q %q", body.Error.Code, body.Error.Message, httputil.CodePaymentRequired, tt.message)
+			}
+			details := body.Error.Details
+			if details["series_id"] != series.ID || details["price_type"] != tt.priceType || details["price_amount"] != price {
+				t.Errorf("details = %v, want the series id, price type and amount", details)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"gorm.io/gorm/clause"
+)
+
+// playbackQualities are the accepted quality preferences
+var playbackQualities = map[string]bool{
+	models.QualityAuto: true,
+	models.Quality480:  true,
+	models.Quality720:  true,
+	models.Quality1080: true,
+}
+
+type NotificationSettingsRequest struct {
+	NewEpisodes   *bool `json:"new_episodes"`
+	Subscriptions *bool `json:"subscriptions"`
+	Promotions    *bool `json:"promotions"`
+}
+
+// UpdatePreferencesRequest changes only the fields that are present
+type UpdatePreferencesRequest struct {
+	QualityPreference *string                      `json:"quality_preference"`
+	Language          *string                      `json:"language"`
+	Notifications     *NotificationSettingsRequest `json:"notifications"`
+}
+
+type PreferencesResponse struct {
+	QualityPreference string                      `json:"quality_preference"`
+	Language          *string                     `json:"language"`
+	Notifications     models.NotificationSettings `json:"notifications"`
+	UpdatedAt         time.Time                   `json:"updated_at"`
+}
+
+func toPreferencesResponse(prefs models.UserPreferences) PreferencesResponse {
+	response := PreferencesResponse{
+		QualityPreference: prefs.QualityPreference,
+		Notifications:     prefs.Notifications,
+		UpdatedAt:         prefs.UpdatedAt,
+	}
+	if prefs.Language != "" {
+		response.Language = &prefs.Language
+	}
+	return response
+}
+
+// loadPreferences returns the user's preferences, creating the row with
+// defaults on first access
+func (h *UserHandler) loadPreferences(ctx context.Context, userID string) (models.UserPreferences, error) {
+	defaults := models.UserPreferences{
+		UserID:            userID,
+		QualityPreference: models.QualityAuto,
+		Notifications:     models.DefaultNotificationSettings(),
+	}
+	if err := h.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(&defaults).Error; err != nil {
+		return models.UserPreferences{}, err
+	}
+
+	var prefs models.UserPreferences
+	err := h.db.WithContext(ctx).Where("user_id = ?", userID).First(&prefs).Error
+	return prefs, err
+}
+
+// GetPreferences returns the user's playback, language and notification settings
+func (h *UserHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	prefs, err := h.loadPreferences(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to load preferences")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toPreferencesResponse(prefs))
+}
+
+// UpdatePreferences changes the given settings and returns the full preferences
+func (h *UserHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.QualityPreference != nil {
+		if !playbackQualities[*req.QualityPreference] {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Quality preference must be one of auto, 480, 720, 1080")
+			return
+		}
+		updates["quality_preference"] = *req.QualityPreference
+	}
+	if req.Language != nil {
+		language, ok := normalizeLanguage(*req.Language)
+		if !ok {
+			writeUnsupportedLanguage(w)
+			return
+		}
+		updates["language"] = language
+	}
+	if n := req.Notifications; n != nil {
+		// Updated through a map so that false is written rather than skipped
+		if n.NewEpisodes != nil {
+			updates["notify_new_episodes"] = *n.NewEpisodes
+		}
+		if n.Subscriptions != nil {
+			updates["notify_subscriptions"] = *n.Subscriptions
+		}
+		if n.Promotions != nil {
+			updates["notify_promotions"] = *n.Promotions
+		}
+	}
+
+	prefs, err := h.loadPreferences(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to load preferences")
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.WithContext(r.Context()).Model(&prefs).Updates(updates).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update preferences")
+			return
+		}
+		if err := h.db.WithContext(r.Context()).First(&prefs, "id = ?", prefs.ID).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to load preferences")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toPreferencesResponse(prefs))
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PublishedEpisode struct {
+	ID            string `json:"id"`
+	EpisodeNumber int    `json:"episode_number"`
+}
+
+type SkippedEpisode struct {
+	ID            string `json:"id"`
+	EpisodeNumber int    `json:"episode_number"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason"`
+}
+
+type PublishEpisodesResponse struct {
+	SeriesID  string             `json:"series_id"`
+	Published []PublishedEpisode `json:"published"`
+	Skipped   []SkippedEpisode   `json:"skipped"`
+}
+
+// PublishEpisodes publishes every ready episode of a series at once, in one
+// transaction. Episodes that cannot be published yet are reported as skipped
+// with the reason; episodes that are already published are left out.
+func (h *ContentHandler) PublishEpisodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	// Verify ownership: series belongs to this creator
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Joins("JOIN creator_profiles ON series.creator_id = creator_profiles.id").
+		Where("series.id = ? AND creator_profiles.user_id = ?", mux.Vars(r)["id"], userID).
+		First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found or access denied")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	if !h.checkSeriesPaidKYC(r.Context(), w, series, series.PriceType) {
+		return
+	}
+
+	response := PublishEpisodesResponse{
+		SeriesID:  series.ID,
+		Published: []PublishedEpisode{},
+		Skipped:   []SkippedEpisode{},
+	}
+	var published []models.Episode
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the episodes so a concurrent status change cannot slip in between
+		var episodes []models.Episode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("series_id = ? AND status <> ?", series.ID, "published").
+			Order("episode_number").Find(&episodes).Error; err != nil {
+			return err
+		}
+
+		var ids []string
+		for _, episode := range episodes {
+			switch {
+			case episode.Status != "ready":
+				response.Skipped = append(response.Skipped, SkippedEpisode{
+					ID: episode.ID, EpisodeNumber: episode.EpisodeNumber, Status: episode.Status,
+					Reason: fmt.Sprintf("Episode cannot be published from %s; it must be ready first", episode.Status),
+				})
+			case episode.HLSManifestURL == nil || *episode.HLSManifestURL == "":
+				response.Skipped = append(response.Skipped, SkippedEpisode{
+					ID: episode.ID, EpisodeNumber: episode.EpisodeNumber, Status: episode.Status,
+					Reason: "Episode cannot be published until transcoding has produced a manifest",
+				})
+			default:
+				ids = append(ids, episode.ID)
+				published = append(published, episode)
+				response.Published = append(response.Published, PublishedEpisode{ID: episode.ID, EpisodeNumber: episode.EpisodeNumber})
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.Episode{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":       "published",
+			"published_at": time.Now(),
+			"version":      gorm.Expr("version + 1"),
+		}).Error
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to publish episodes")
+		return
+	}
+	if len(published) > 0 {
+		h.seriesCache.invalidate(r.Context())
+	}
+
+	for _, episode := range published {
+		dispatchNewEpisodeNotifications(h.db, episode)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errAlreadyPurchased is returned when the user has already bought the series
+var errAlreadyPurchased = errors.New("series already purchased")
+
+type CreatePurchaseRequest struct {
+	SeriesID string `json:"series_id" validate:"required"`
+}
+
+type PurchaseResponse struct {
+	ID          string     `json:"id"`
+	SeriesID    string     `json:"series_id"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreatePurchase starts a one-time purchase of a series. The purchase stays
+// pending until the payment provider confirms the charge; an Idempotency-Key
+// header makes retries return the original purchase.
+func (h *PaymentHandler) CreatePurchase(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	key, err := idempotencyKey(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	var req CreatePurchaseRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", req.SeriesID, "published").First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if series.PriceType != "one_time" || series.PriceAmount == nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Series is not available for one-time purchase")
+		return
+	}
+
+	var purchase models.Purchase
+	err = h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the user so their concurrent requests, retries included, cannot
+		// each pass the checks below before the other commits
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", userID).First(&models.User{}).Error; err != nil {
+			return err
+		}
+
+		if key != "" {
+			purchaseID, found, err := findIdempotentResource(tx, userID, idempotencyScopePurchase, key)
+			if err != nil {
+				return err
+			}
+			if found {
+				// A retried request returns the purchase created by the first attempt
+				err := tx.Where("id = ? AND user_id = ?", purchaseID, userID).First(&purchase).Error
+				if err != gorm.ErrRecordNotFound {
+					return err
+				}
+			}
+		}
+
+		var completed int64
+		if err := tx.Model(&models.Purchase{}).
+			Where("user_id = ? AND series_id = ? AND status = ?", userID, series.ID, models.PurchaseStatusCompleted).
+			Count(&completed).Error; err != nil {
+			return err
+		}
+		if completed > 0 {
+			return errAlreadyPurchased
+		}
+
+		purchase = models.Purchase{
+			UserID:   userID,
+			SeriesID: series.ID,
+			Amount:   *series.PriceAmount,
+			Status:   models.PurchaseStatusPending,
+		}
+		if err := tx.Create(&purchase).Error; err != nil {
+			return err
+		}
+		if key != "" {
+			return saveIdempotentResource(tx, userID, idempotencyScopePurchase, key, purchase.ID)
+		}
+		return nil
+	})
+	if err == errAlreadyPurchased {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "You have already purchased this series")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create purchase")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toPurchaseResponse(purchase))
+}
+
+func toPurchaseResponse(purchase models.Purchase) PurchaseResponse {
+	return PurchaseResponse{
+		ID:          purchase.ID,
+		SeriesID:    purchase.SeriesID,
+		Amount:      purchase.Amount,
+		Status:      purchase.Status,
+		CompletedAt: purchase.CompletedAt,
+		CreatedAt:   purchase.CreatedAt,
+	}
+}
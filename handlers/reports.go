@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportRequest struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam harassment hate_speech sexual_content violence copyright other"`
+	Details string `json:"details" validate:"max=1000"`
+}
+
+type ResolveReportRequest struct {
+	// Action "resolve" means the report was acted on, "dismiss" that it was not
+	Action string `json:"action" validate:"oneof=resolve dismiss"`
+	Notes  string `json:"notes" validate:"max=1000"`
+}
+
+type ContentReportResponse struct {
+	ID         string     `json:"id"`
+	ReporterID string     `json:"reporter_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   string     `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details"`
+	Status     string     `json:"status"`
+	ResolvedBy *string    `json:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	Resolution string     `json:"resolution"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ReportEpisode flags a published episode for moderation
+func (h *SocialHandler) ReportEpisode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req ReportRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", mux.Vars(r)["id"], "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	h.createReport(w, r, userID, models.ReportTargetEpisode, episode.ID, req)
+}
+
+// ReportComment flags another user's comment for moderation
+func (h *SocialHandler) ReportComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req ReportRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	var comment models.EpisodeComment
+	if err := h.db.WithContext(r.Context()).Where("id = ?", mux.Vars(r)["id"]).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Comment not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if comment.UserID == userID {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "You cannot report your own comment")
+		return
+	}
+
+	h.createReport(w, r, userID, models.ReportTargetComment, comment.ID, req)
+}
+
+// createReport queues a report unless the user already has one pending for
+// the same target
+func (h *SocialHandler) createReport(w http.ResponseWriter, r *http.Request, userID, targetType, targetID string, req ReportRequest) {
+	report := models.ContentReport{
+		ReporterID: userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     req.Reason,
+		Details:    strings.TrimSpace(req.Details),
+		Status:     models.ReportStatusPending,
+	}
+	res := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+	if res.Error != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create report")
+		return
+	}
+	if res.RowsAffected == 0 {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "You have already reported this "+targetType)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toReportResponse(report))
+}
+
+// GetReports lists content reports for moderation, filtered by status
+// (default "pending") and optionally target_type and a from/to report date,
+// oldest first unless sort=newest
+func (h *AdminHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ReportStatusPending
+	}
+	allowedStatuses := map[string]bool{
+		models.ReportStatusPending:   true,
+		models.ReportStatusResolved:  true,
+		models.ReportStatusDismissed: true,
+	}
+	if !allowedStatuses[status] {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "invalid status")
+		return
+	}
+
+	query := h.db.WithContext(r.Context()).Model(&models.ContentReport{}).Where("status = ?", status)
+	if targetType := r.URL.Query().Get("target_type"); targetType != "" {
+		if targetType != models.ReportTargetEpisode && targetType != models.ReportTargetComment {
+			httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "target_type must be 'episode' or 'comment'")
+			return
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+	query, err := filterCreatedAt(r, query, "created_at")
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	direction, err := adminSortDirection(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count reports")
+		return
+	}
+
+	var reports []models.ContentReport
+	if err := query.Order("created_at " + direction + ", id " + direction).Offset(offset).Limit(perPage).Find(&reports).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch reports")
+		return
+	}
+
+	items := make([]ContentReportResponse, len(reports))
+	for i, report := range reports {
+		items[i] = toReportResponse(report)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(items, total, page, perPage))
+}
+
+// ResolveReport closes a pending report as resolved or dismissed, recording
+// the admin and their notes
+func (h *AdminHandler) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req ResolveReportRequest
+	if !httputil.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	var report models.ContentReport
+	if err := h.db.WithContext(r.Context()).Where("id = ?", mux.Vars(r)["id"]).First(&report).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Report not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	status := models.ReportStatusResolved
+	if req.Action == "dismiss" {
+		status = models.ReportStatusDismissed
+	}
+	now := time.Now()
+	notes := strings.TrimSpace(req.Notes)
+
+	// Only a pending report can be closed, so two admins cannot both act on it
+	res := h.db.WithContext(r.Context()).Model(&models.ContentReport{}).
+		Where("id = ? AND status = ?", report.ID, models.ReportStatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_by": adminID,
+			"resolved_at": now,
+			"resolution":  notes,
+		})
+	if res.Error != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to resolve report")
+		return
+	}
+	if res.RowsAffected == 0 {
+		httputil.WriteError(w, http.StatusConflict, httputil.CodeConflict, "Report has already been closed")
+		return
+	}
+
+	report.Status = status
+	report.ResolvedBy = &adminID
+	report.ResolvedAt = &now
+	report.Resolution = notes
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReportResponse(report))
+}
+
+func toReportResponse(report models.ContentReport) ContentReportResponse {
+	return ContentReportResponse{
+		ID:         report.ID,
+		ReporterID: report.ReporterID,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Reason:     report.Reason,
+		Details:    report.Details,
+		Status:     report.Status,
+		ResolvedBy: report.ResolvedBy,
+		ResolvedAt: report.ResolvedAt,
+		Resolution: report.Resolution,
+		CreatedAt:  report.CreatedAt,
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/testdb"
+)
+
+func TestDeletedSeriesIsHiddenUntilRestored(t *testing.T) {
+	db := testdb.Open(t)
+	owner, creator := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	createTestEpisode(t, db, series.ID, 1, "published")
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+	vars := map[string]string{"id": series.ID}
+	path := "/content/series/" + series.ID
+
+	if rec := serve(h.DeleteSeries, http.MethodDelete, "/api"+path, vars, owner.ID, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h.GetSeries, http.MethodGet, path, vars, "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("public view of a deleted series = %d, want 404", rec.Code)
+	}
+	if rec := serve(h.GetSeries, http.MethodGet, path, vars, owner.ID, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("owner view of a deleted series = %d, want 404", rec.Code)
+	}
+
+	stranger := createTestUser(t, db)
+	if rec := serve(h.RestoreSeries, http.MethodPost, "/api"+path+"/restore", vars, stranger.ID, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("restore by another user = %d, want 404", rec.Code)
+	}
+	if rec := serve(h.RestoreSeries, http.MethodPost, "/api"+path+"/restore", vars, owner.ID, ""); rec.Code != http.StatusOK {
+		t.Fatalf("restore by the owner = %d: %s", rec.Code, rec.Body)
+	}
+
+	var detail struct {
+		Episodes []EpisodeBrief `json:"episodes"`
+	}
+	rec := serve(h.GetSeries, http.MethodGet, path, vars, "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public view after restore = %d, want 200", rec.Code)
+	}
+	decodeBody(t, rec, &detail)
+	if len(detail.Episodes) != 1 {
+		t.Errorf("restored series has %d episodes, want the 1 deleted with it", len(detail.Episodes))
+	}
+}
+
+func TestGetSeriesViews(t *testing.T) {
+	db := testdb.Open(t)
+	owner, creator := createTestCreator(t, db)
+	viewer := createTestUser(t, db)
+	published := createTestSeries(t, db, creator.ID, nil)
+	createTestEpisode(t, db, published.ID, 1, "published")
+	createTestEpisode(t, db, published.ID, 2, "ready")
+	createTestEpisode(t, db, published.ID, 3, "pending_upload")
+	draft := createTestSeries(t, db, creator.ID, func(s *models.Series) { s.Status = "draft" })
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	type detail struct {
+		Status   string `json:"status"`
+		Episodes []struct {
+			EpisodeNumber int     `json:"episode_number"`
+			Status        *string `json:"status"`
+		} `json:"episodes"`
+	}
+	get := func(seriesID, userID string) (int, detail) {
+		t.Helper()
+		rec := serve(h.GetSeries, http.MethodGet, "/content/series/"+seriesID, map[string]string{"id": seriesID}, userID, "")
+		var d detail
+		if rec.Code == http.StatusOK {
+			decodeBody(t, rec, &d)
+		}
+		return rec.Code, d
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		wantEpisodes []int
+		wantStatus   bool
+		draftCode    int
+	}{
+		{"public", "", []int{1}, false, http.StatusNotFound},
+		{"authenticated non-owner", viewer.ID, []int{1}, false, http.StatusNotFound},
+		{"owner", owner.ID, []int{1, 2, 3}, true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, d := get(published.ID, tt.userID)
+			if code != http.StatusOK {
+				t.Fatalf("published series = %d, want 200", code)
+			}
+			var numbers []int
+			for _, ep := range d.Episodes {
+				numbers = append(numbers, ep.EpisodeNumber)
+				if (ep.Status != nil) != tt.wantStatus {
+					t.Errorf("episode %d status shown = %v, want %v", ep.EpisodeNumber, ep.Status != nil, tt.wantStatus)
+				}
+			}
+			if !slices.Equal(numbers, tt.wantEpisodes) {
+				t.Errorf("episodes = %v, want %v", numbers, tt.wantEpisodes)
+			}
+
+			if code, _ := get(draft.ID, tt.userID); code != tt.draftCode {
+				t.Errorf("draft series = %d, want %d", code, tt.draftCode)
+			}
+		})
+	}
+}
+
+func TestCheckPublishable(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	tests := []struct {
+		name     string
+		episodes []string
+		deleted  bool
+		want     bool
+	}{
+		{"empty series", nil, false, false},
+		{"only pending uploads", []string{"pending_upload", "pending_upload"}, false, false},
+		{"still transcoding", []string{"pending_upload", "queued_transcode"}, false, false},
+		{"only ready episode deleted", []string{"ready"}, true, false},
+		{"one ready episode", []string{"pending_upload", "ready"}, false, true},
+		{"published episode", []string{"published"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series := createTestSeries(t, db, creator.ID, func(s *models.Series) { s.Status = "draft" })
+			for i, status := range tt.episodes {
+				episode := createTestEpisode(t, db, series.ID, i+1, status)
+				if tt.deleted {
+					db.Delete(&episode)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			if got := h.checkPublishable(context.Background(), rec, series.ID); got != tt.want {
+				t.Fatalf("checkPublishable = %v, want %v", got, tt.want)
+			}
+			if !tt.want && rec.Code != http.StatusConflict {
+				t.Errorf("status = %d, want 409", rec.Code)
+			}
+		})
+	}
+}
+
+func TestPaidSeriesRequireVerifiedKYC(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	tests := []struct {
+		kycStatus string
+		paidCode  int
+	}{
+		{models.KYCStatusPending, http.StatusForbidden},
+		{models.KYCStatusRejected, http.StatusForbidden},
+		{models.KYCStatusVerified, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kycStatus, func(t *testing.T) {
+			owner, creator := createTestCreator(t, db)
+			if err := db.Model(&creator).Update("kyc_status", tt.kycStatus).Error; err != nil {
+				t.Fatal(err)
+			}
+			check := func(action string, rec *httptest.ResponseRecorder, want int) {
+				t.Helper()
+				if rec.Code != want {
+					t.Errorf("%s = %d, want %d: %s", action, rec.Code, want, rec.Body)
+					return
+				}
+				if want == http.StatusForbidden && tt.kycStatus == models.KYCStatusRejected {
+					var body httputil.ErrorResponse
+					decodeBody(t, rec, &body)
+					if !strings.Contains(body.Error.Message, "rejected") {
+						t.Errorf("%s message = %q, want it to say the KYC was rejected", action, body.Error.Message)
+					}
+				}
+			}
+			createdCode := tt.paidCode
+			if createdCode == http.StatusOK {
+				createdCode = http.StatusCreated
+			}
+
+			const newSeries = `{"title":"New Series","synopsis":"A synopsis","language":"en",`
+			check("create paid", serve(h.CreateSeries, http.MethodPost, "/api/content/series", nil, owner.ID,
+				newSeries+`"price_type":"subscription","price_amount":99}`), createdCode)
+			check("create free", serve(h.CreateSeries, http.MethodPost, "/api/content/series", nil, owner.ID,
+				newSeries+`"price_type":"free"}`), http.StatusCreated)
+
+			free := createTestSeries(t, db, creator.ID, func(s *models.Series) { s.Status = "draft" })
+			check("reprice", serve(h.UpdateSeries, http.MethodPut, "/api/content/series/"+free.ID,
+				map[string]string{"id": free.ID}, owner.ID, `{"price_type":"one_time","price_amount":49}`), tt.paidCode)
+
+			// Publishing checks the price the series already has
+			paid := func() models.Series {
+				series := createTestSeries(t, db, creator.ID, func(s *models.Series) {
+					amount := 99.0
+					s.Status = "draft"
+					s.PriceType = "subscription"
+					s.PriceAmount = &amount
+				})
+				createTestEpisode(t, db, series.ID, 1, "ready")
+				return series
+			}
+			viaUpdate := paid()
+			check("publish paid via update", serve(h.UpdateSeries, http.MethodPut, "/api/content/series/"+viaUpdate.ID,
+				map[string]string{"id": viaUpdate.ID}, owner.ID, `{"status":"published"}`), tt.paidCode)
+			viaStatus := paid()
+			check("publish paid via status", serve(h.UpdateSeriesStatus, http.MethodPut, "/api/content/series/"+viaStatus.ID+"/status",
+				map[string]string{"id": viaStatus.ID}, owner.ID, `{"status":"published"}`), tt.paidCode)
+
+			freeDraft := createTestSeries(t, db, creator.ID, func(s *models.Series) { s.Status = "draft" })
+			createTestEpisode(t, db, freeDraft.ID, 1, "ready")
+			check("publish free", serve(h.UpdateSeriesStatus, http.MethodPut, "/api/content/series/"+freeDraft.ID+"/status",
+				map[string]string{"id": freeDraft.ID}, owner.ID, `{"status":"published"}`), http.StatusOK)
+		})
+	}
+}
+
+func TestSeriesRatingsCountPublishedEpisodesOnly(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	rated := createTestSeries(t, db, creator.ID, nil)
+	unrated := createTestSeries(t, db, creator.ID, nil)
+	first := createTestEpisode(t, db, rated.ID, 1, "published")
+	second := createTestEpisode(t, db, rated.ID, 2, "published")
+	unpublished := createTestEpisode(t, db, rated.ID, 3, "ready")
+	createTestEpisode(t, db, unrated.ID, 1, "published")
+
+	rate := func(episodeID string, score int) models.EpisodeRating {
+		t.Helper()
+		rating := models.EpisodeRating{EpisodeID: episodeID, UserID: createTestUser(t, db).ID, Score: score}
+		if err := db.Create(&rating).Error; err != nil {
+			t.Fatal(err)
+		}
+		return rating
+	}
+	rate(first.ID, 5)
+	rate(first.ID, 4)
+	rate(second.ID, 4)
+	rate(unpublished.ID, 1)
+	removed := rate(second.ID, 1)
+	if err := db.Delete(&removed).Error; err != nil {
+		t.Fatal(err)
+	}
+	// (5 + 4 + 4) / 3, rounded to one decimal
+	const wantAverage, wantCount = 4.3, 3
+
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+	rec := serve(h.ListSeries, http.MethodGet, "/content/series?creator_id="+creator.ID, nil, "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list = %d: %s", rec.Code, rec.Body)
+	}
+	var list SeriesListResponse
+	decodeBody(t, rec, &list)
+	if len(list.Items) != 2 {
+		t.Fatalf("listed %d series, want 2", len(list.Items))
+	}
+	for _, item := range list.Items {
+		average, count := wantAverage, int64(wantCount)
+		if item.ID == unrated.ID {
+			average, count = 0, 0
+		}
+		if item.AverageRating != average || item.RatingCount != count {
+			t.Errorf("list item %s rating = %v over %d, want %v over %d", item.ID, item.AverageRating, item.RatingCount, average, count)
+		}
+	}
+
+	rec = serve(h.GetSeries, http.MethodGet, "/content/series/"+rated.ID, map[string]string{"id": rated.ID}, "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get = %d: %s", rec.Code, rec.Body)
+	}
+	var detail struct {
+		AverageRating float64 `json:"average_rating"`
+		RatingCount   int64   `json:"rating_count"`
+	}
+	decodeBody(t, rec, &detail)
+	if detail.AverageRating != wantAverage || detail.RatingCount != wantCount {
+		t.Errorf("series rating = %v over %d, want %v over %d", detail.AverageRating, detail.RatingCount, wantAverage, wantCount)
+	}
+}
+
+func TestUpdateSeriesFieldErrors(t *testing.T) {
+	db := testdb.Open(t)
+	owner, creator := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	h := NewContentHandler(db, nil, false, "", nil, nil, TrendingOptions{}, UploadLimits{}, nil)
+
+	tests := []struct {
+		body  string
+		field string
+		rule  string
+	}{
+		{`{"price_type":"rental"}`, "price_type", "oneof"},
+		{`{"price_type":"subscription"}`, "price_amount", "pricing"},
+		{`{"price_amount":-5}`, "price_amount", "pricing"},
+		{`{"thumbnail_url":"https://evil.example.net/thumb.png"}`, "thumbnail_url", "allowed_host"},
+	}
+	for _, tt := range tests {
+		rec := serve(h.UpdateSeries, http.MethodPut, "/api/content/series/"+series.ID,
+			map[string]string{"id": series.ID}, owner.ID, tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400: %s", tt.body, rec.Code, rec.Body)
+		}
+		assertFieldError(t, rec, tt.field, tt.rule)
+	}
+}
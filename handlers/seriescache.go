@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"streamshort/pkg/cache"
+	"streamshort/pkg/metrics"
+)
+
+// seriesListGenerationKey holds a counter that is part of every cached
+// listing's key. Bumping it orphans all cached listings at once, and they
+// then expire by TTL.
+const seriesListGenerationKey = "series_list:generation"
+
+// SeriesListCache caches rendered public series listings in a store shared
+// by all instances. A nil *SeriesListCache caches nothing.
+type SeriesListCache struct {
+	store cache.Store
+	ttl   time.Duration
+}
+
+// NewSeriesListCache caches listings in store for ttl
+func NewSeriesListCache(store cache.Store, ttl time.Duration) *SeriesListCache {
+	return &SeriesListCache{store: store, ttl: ttl}
+}
+
+// key returns the cache key for a listing with the given normalized query
+// params, or "" when the cache is disabled or unavailable
+func (c *SeriesListCache) key(ctx context.Context, params url.Values) string {
+	if c == nil {
+		return ""
+	}
+	generation, err := c.store.Get(ctx, seriesListGenerationKey)
+	if errors.Is(err, cache.ErrMiss) {
+		generation = []byte("0")
+	} else if err != nil {
+		metrics.CacheRequests.WithLabelValues("series_list", "error").Inc()
+		return ""
+	}
+	// Encode sorts the params, so equal queries share a key
+	return "series_list:" + string(generation) + ":" + params.Encode()
+}
+
+func (c *SeriesListCache) get(ctx context.Context, key string) ([]byte, bool) {
+	if key == "" {
+		return nil, false
+	}
+	body, err := c.store.Get(ctx, key)
+	switch {
+	case err == nil:
+		metrics.CacheRequests.WithLabelValues("series_list", "hit").Inc()
+		return body, true
+	case errors.Is(err, cache.ErrMiss):
+		metrics.CacheRequests.WithLabelValues("series_list", "miss").Inc()
+	default:
+		metrics.CacheRequests.WithLabelValues("series_list", "error").Inc()
+	}
+	return nil, false
+}
+
+func (c *SeriesListCache) set(ctx context.Context, key string, body []byte) {
+	if key == "" {
+		return
+	}
+	if err := c.store.Set(ctx, key, body, c.ttl); err != nil {
+		log.Printf("Failed to cache series listing: %v", err)
+	}
+}
+
+// invalidate drops every cached listing. Handlers call it after changing a
+// series or episode that listings show; view counts, ratings and creator
+// names are left to the TTL.
+func (c *SeriesListCache) invalidate(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	if err := c.store.Incr(context.WithoutCancel(ctx), seriesListGenerationKey); err != nil {
+		log.Printf("Failed to invalidate cached series listings: %v", err)
+	}
+}
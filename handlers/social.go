@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SocialHandler struct {
@@ -45,19 +50,33 @@ type CommentRequest struct {
 }
 
 type CommentResponse struct {
-	ID        string    `json:"id"`
-	Content   string    `json:"content"`
-	UserID    string    `json:"user_id"`
-	EpisodeID string    `json:"episode_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              string    `json:"id"`
+	Content         string    `json:"content"`
+	UserID          string    `json:"user_id"`
+	UserDisplayName *string   `json:"user_display_name"`
+	EpisodeID       string    `json:"episode_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type ProgressRequest struct {
+	PositionSeconds int  `json:"position_seconds"`
+	Completed       bool `json:"completed"`
 }
 
-// LikeEpisode handles episode likes/unlikes
+type ProgressResponse struct {
+	EpisodeID       string    `json:"episode_id"`
+	PositionSeconds int       `json:"position_seconds"`
+	Completed       bool      `json:"completed"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// LikeEpisode likes or unlikes a published episode for the user and returns
+// its like count
 func (h *SocialHandler) LikeEpisode(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
@@ -65,42 +84,65 @@ func (h *SocialHandler) LikeEpisode(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	episodeID := vars["id"]
 	if episodeID == "" {
-		http.Error(w, "Episode ID is required", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Episode ID is required")
 		return
 	}
 
 	var req LikeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate action
 	if req.Action != "like" && req.Action != "unlike" {
-		http.Error(w, "Action must be 'like' or 'unlike'", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Action must be 'like' or 'unlike'")
 		return
 	}
 
-	// Mock like handling (in real implementation, save to database)
-	var likeCount int64 = 42 // Mock count
-	isLiked := req.Action == "like"
-
-	// In real implementation, you'd save this to database with userID and episodeID
-	_ = userID    // Use userID to avoid linter warning
-	_ = episodeID // Use episodeID to avoid linter warning
+	// Only published episodes can be liked
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", episodeID, "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
 
+	// Liking twice or unliking an episode that was never liked is a no-op.
+	// Unliking soft-deletes the like and liking again restores it with its
+	// original created_at, so toggling cannot make a like count as new
+	// activity for trending.
 	if req.Action == "like" {
-		likeCount++
+		like := models.EpisodeLike{EpisodeID: episode.ID, UserID: userID}
+		if err := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "episode_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"deleted_at": nil}),
+		}).Create(&like).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save like")
+			return
+		}
 	} else {
-		if likeCount > 0 {
-			likeCount--
+		if err := h.db.WithContext(r.Context()).
+			Where("episode_id = ? AND user_id = ?", episode.ID, userID).
+			Delete(&models.EpisodeLike{}).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to remove like")
+			return
 		}
 	}
 
+	var likeCount int64
+	if err := h.db.WithContext(r.Context()).Model(&models.EpisodeLike{}).
+		Where("episode_id = ?", episode.ID).Count(&likeCount).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count likes")
+		return
+	}
+
 	response := LikeResponse{
 		Status:    "success",
 		LikeCount: likeCount,
-		IsLiked:   isLiked,
+		IsLiked:   req.Action == "like",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -113,7 +155,7 @@ func (h *SocialHandler) RateEpisode(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
@@ -121,35 +163,64 @@ func (h *SocialHandler) RateEpisode(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	episodeID := vars["id"]
 	if episodeID == "" {
-		http.Error(w, "Episode ID is required", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Episode ID is required")
 		return
 	}
 
 	var req RatingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate rating (1-5 stars)
 	if req.Rating < 1 || req.Rating > 5 {
-		http.Error(w, "Rating must be between 1 and 5", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Rating must be between 1 and 5")
+		return
+	}
+
+	// Only published episodes can be rated
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", episodeID, "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
 		return
 	}
 
-	// Mock rating handling (in real implementation, save to database)
-	_ = userID    // Use userID to avoid linter warning
-	_ = episodeID // Use episodeID to avoid linter warning
+	// Upsert on (episode_id, user_id) so re-rating replaces the previous score
+	rating := models.EpisodeRating{
+		EpisodeID: episode.ID,
+		UserID:    userID,
+		Score:     req.Rating,
+	}
+	if err := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "episode_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "updated_at"}),
+	}).Create(&rating).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save rating")
+		return
+	}
 
-	// Mock average rating calculation
-	averageRating := 4.2
-	totalRatings := int64(156)
+	// Recompute aggregate rating for the episode
+	var agg struct {
+		AverageRating float64
+		TotalRatings  int64
+	}
+	if err := h.db.WithContext(r.Context()).Model(&models.EpisodeRating{}).
+		Select("COALESCE(ROUND(AVG(score)::numeric, 1), 0) AS average_rating, COUNT(*) AS total_ratings").
+		Where("episode_id = ?", episode.ID).
+		Scan(&agg).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to compute rating")
+		return
+	}
 
 	response := RatingResponse{
 		Status:        "success",
 		Rating:        req.Rating,
-		AverageRating: averageRating,
-		TotalRatings:  totalRatings,
+		AverageRating: agg.AverageRating,
+		TotalRatings:  agg.TotalRatings,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -162,7 +233,7 @@ func (h *SocialHandler) CommentEpisode(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := r.Context().Value("user_id").(string)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
 		return
 	}
 
@@ -170,35 +241,323 @@ func (h *SocialHandler) CommentEpisode(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	episodeID := vars["id"]
 	if episodeID == "" {
-		http.Error(w, "Episode ID is required", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Episode ID is required")
 		return
 	}
 
 	var req CommentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !httputil.DecodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate content
 	if req.Content == "" {
-		http.Error(w, "Comment content is required", http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Comment content is required")
 		return
 	}
 
-	// Mock comment creation (in real implementation, save to database)
-	commentID := "comment_" + strconv.FormatInt(time.Now().Unix(), 10)
-	now := time.Now()
+	// Only published episodes accept comments
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", episodeID, "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
 
-	response := CommentResponse{
-		ID:        commentID,
-		Content:   req.Content,
+	comment := models.EpisodeComment{
+		EpisodeID: episode.ID,
 		UserID:    userID,
-		EpisodeID: episodeID,
-		CreatedAt: now,
+		Text:      req.Content,
+	}
+	if err := h.db.WithContext(r.Context()).Create(&comment).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create comment")
+		return
+	}
+
+	response := CommentResponse{
+		ID:        comment.ID,
+		Content:   comment.Text,
+		UserID:    comment.UserID,
+		EpisodeID: comment.EpisodeID,
+		CreatedAt: comment.CreatedAt,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetEpisodeComments lists comments for an episode, newest first
+func (h *SocialHandler) GetEpisodeComments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+	if episodeID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Episode ID is required")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", episodeID, "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	var total int64
+	if err := h.db.WithContext(r.Context()).Model(&models.EpisodeComment{}).Where("episode_id = ?", episode.ID).Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to count comments")
+		return
+	}
+
+	// Commenters have no name of their own; use the creator display name when they have one
+	items := make([]CommentResponse, 0, perPage)
+	if err := h.db.WithContext(r.Context()).Table("episode_comments").
+		Select("episode_comments.id, episode_comments.text AS content, episode_comments.user_id, "+
+			"creator_profiles.display_name AS user_display_name, episode_comments.episode_id, episode_comments.created_at").
+		Joins("LEFT JOIN users ON users.id = episode_comments.user_id").
+		Joins("LEFT JOIN creator_profiles ON creator_profiles.user_id = users.id AND creator_profiles.deleted_at IS NULL").
+		Where("episode_comments.episode_id = ? AND episode_comments.deleted_at IS NULL", episode.ID).
+		Order("episode_comments.created_at DESC").
+		Offset(offset).Limit(perPage).
+		Scan(&items).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch comments")
+		return
+	}
+
+	response := httputil.NewPaginatedResponse(items, total, page, perPage)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// viewDedupWindow is how long repeat views of an episode by the same user count once
+const viewDedupWindow = 30 * time.Minute
+
+type ViewResponse struct {
+	EpisodeID string `json:"episode_id"`
+	ViewCount int64  `json:"view_count"`
+	Counted   bool   `json:"counted"`
+}
+
+// RecordView counts a view of a published episode. Repeat views by the same
+// user within viewDedupWindow are ignored. Counts are best-effort: concurrent
+// requests from one user can occasionally both be counted.
+func (h *SocialHandler) RecordView(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", episodeID, "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	now := time.Now()
+	counted := false
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		var recent int64
+		if err := tx.Model(&models.EpisodeView{}).
+			Where("episode_id = ? AND user_id = ? AND viewed_at > ?", episode.ID, userID, now.Add(-viewDedupWindow)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return nil
+		}
+
+		if err := tx.Create(&models.EpisodeView{EpisodeID: episode.ID, UserID: userID, ViewedAt: now}).Error; err != nil {
+			return err
+		}
+		counted = true
+		return tx.Model(&models.Episode{}).Where("id = ?", episode.ID).
+			UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to record view")
+		return
+	}
+
+	viewCount := episode.ViewCount
+	if counted {
+		viewCount++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ViewResponse{
+		EpisodeID: episode.ID,
+		ViewCount: viewCount,
+		Counted:   counted,
+	})
+}
+
+// RecordProgress saves the user's playback position for an episode
+func (h *SocialHandler) RecordProgress(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+
+	var req ProgressRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", episodeID, "published").First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	if req.PositionSeconds < 0 || req.PositionSeconds > episode.DurationSeconds {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "position_seconds must be between 0 and the episode duration")
+		return
+	}
+
+	progress := models.WatchProgress{
+		UserID:          userID,
+		EpisodeID:       episode.ID,
+		PositionSeconds: req.PositionSeconds,
+		Completed:       req.Completed || req.PositionSeconds >= episode.DurationSeconds,
+	}
+	if err := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "episode_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position_seconds", "completed", "updated_at"}),
+	}).Create(&progress).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save progress")
+		return
+	}
+
+	response := ProgressResponse{
+		EpisodeID:       progress.EpisodeID,
+		PositionSeconds: progress.PositionSeconds,
+		Completed:       progress.Completed,
+		UpdatedAt:       progress.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// EditComment updates the text of a comment owned by the authenticated user
+func (h *SocialHandler) EditComment(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+	commentID := vars["commentId"]
+
+	var req CommentRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Comment content is required")
+		return
+	}
+
+	comment, status, msg := h.findOwnedComment(r.Context(), episodeID, commentID, userID)
+	if comment == nil {
+		httputil.WriteError(w, status, httputil.CodeForStatus(status), msg)
+		return
+	}
+
+	if err := h.db.WithContext(r.Context()).Model(comment).Update("text", req.Content).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update comment")
+		return
+	}
+	comment.Text = req.Content
+
+	response := CommentResponse{
+		ID:        comment.ID,
+		Content:   comment.Text,
+		UserID:    comment.UserID,
+		EpisodeID: comment.EpisodeID,
+		CreatedAt: comment.CreatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteComment soft-deletes a comment owned by the authenticated user
+func (h *SocialHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	episodeID := vars["id"]
+	commentID := vars["commentId"]
+
+	comment, status, msg := h.findOwnedComment(r.Context(), episodeID, commentID, userID)
+	if comment == nil {
+		httputil.WriteError(w, status, httputil.CodeForStatus(status), msg)
+		return
+	}
+
+	if err := h.db.WithContext(r.Context()).Delete(comment).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Comment deleted successfully",
+		"id":      comment.ID,
+	})
+}
+
+// findOwnedComment loads a comment on an episode and checks it belongs to userID.
+// On failure it returns a nil comment with the HTTP status and message to report.
+func (h *SocialHandler) findOwnedComment(ctx context.Context, episodeID, commentID, userID string) (*models.EpisodeComment, int, string) {
+	var comment models.EpisodeComment
+	if err := h.db.WithContext(ctx).Where("id = ? AND episode_id = ?", commentID, episodeID).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, http.StatusNotFound, "Comment not found"
+		}
+		return nil, http.StatusInternalServerError, "Database error"
+	}
+
+	if comment.UserID != userID {
+		return nil, http.StatusForbidden, "You can only modify your own comments"
+	}
+
+	return &comment, 0, ""
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestRateEpisodeTwiceKeepsLatestRating(t *testing.T) {
+	db := testdb.Open(t)
+	_, creator := createTestCreator(t, db)
+	series := createTestSeries(t, db, creator.ID, nil)
+	episode := createTestEpisode(t, db, series.ID, 1, "published")
+	rater, other := createTestUser(t, db), createTestUser(t, db)
+	h := NewSocialHandler(db)
+	vars := map[string]string{"id": episode.ID}
+
+	rate := func(userID, body string) RatingResponse {
+		t.Helper()
+		rec := serve(h.RateEpisode, http.MethodPost, "/api/episodes/"+episode.ID+"/rating", vars, userID, body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("rate = %d: %s", rec.Code, rec.Body)
+		}
+		var resp RatingResponse
+		decodeBody(t, rec, &resp)
+		return resp
+	}
+
+	rate(other.ID, `{"rating":4}`)
+	rate(rater.ID, `{"rating":1}`)
+	resp := rate(rater.ID, `{"rating":5}`)
+
+	// (4 + 5) / 2: the first rating of 1 was replaced, not counted again
+	if resp.TotalRatings != 2 || resp.AverageRating != 4.5 {
+		t.Errorf("aggregate = %v over %d ratings, want 4.5 over 2", resp.AverageRating, resp.TotalRatings)
+	}
+	var rows []models.EpisodeRating
+	db.Where("episode_id = ? AND user_id = ?", episode.ID, rater.ID).Find(&rows)
+	if len(rows) != 1 || rows[0].Score != 5 {
+		t.Errorf("rater has %d rating rows (%+v), want one with score 5", len(rows), rows)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// thumbnailExtensions maps the accepted thumbnail image types to their file extension
+var thumbnailExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+type EpisodeThumbnailRequest struct {
+	// URL attaches an already hosted image. When omitted, a presigned upload
+	// URL for an image of ContentType is returned instead.
+	URL         *string `json:"url"`
+	ContentType string  `json:"content_type"`
+}
+
+type EpisodeThumbnailResponse struct {
+	EpisodeID     string            `json:"episode_id"`
+	ThumbURL      string            `json:"thumb_url"`
+	UploadURL     string            `json:"upload_url,omitempty"`
+	ExpiresIn     int               `json:"expires_in,omitempty"`
+	UploadHeaders map[string]string `json:"upload_headers,omitempty"`
+}
+
+// SetEpisodeThumbnail sets an episode's thumbnail, either to an image URL on
+// an allowed host or to a new image uploaded through the returned presigned URL
+func (h *ContentHandler) SetEpisodeThumbnail(w http.ResponseWriter, r *http.Request) {
+	episodeID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var req EpisodeThumbnailRequest
+	if !httputil.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	var episode models.Episode
+	if err := h.db.WithContext(r.Context()).Preload("Series").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	isOwner, err := h.ownsSeries(r.Context(), userID, episode.Series)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if episode.Series.ID == "" || !isOwner {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Episode not found or access denied")
+		return
+	}
+
+	response := EpisodeThumbnailResponse{EpisodeID: episode.ID}
+
+	if req.URL != nil {
+		if !validThumbnailURL(*req.URL, h.thumbnailHosts) {
+			httputil.WriteValidationError(w, map[string]httputil.FieldError{
+				"url": {Rule: "allowed_host", Message: "url must be an http(s) URL on an allowed host"},
+			})
+			return
+		}
+		if !isThumbnailImagePath(*req.URL) {
+			httputil.WriteValidationError(w, map[string]httputil.FieldError{
+				"url": {Rule: "image", Message: "url must point to a JPEG, PNG or WebP image"},
+			})
+			return
+		}
+		response.ThumbURL = *req.URL
+	} else {
+		mediaType, _, err := mime.ParseMediaType(req.ContentType)
+		ext, ok := thumbnailExtensions[mediaType]
+		if err != nil || !ok {
+			httputil.WriteValidationError(w, map[string]httputil.FieldError{
+				"content_type": {Rule: "image", Message: "content_type must be image/jpeg, image/png or image/webp"},
+			})
+			return
+		}
+		if h.storage == nil && !h.mockUploads {
+			httputil.WriteError(w, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Upload storage is not configured")
+			return
+		}
+
+		// A fresh key per upload keeps CDN caches from serving the old image
+		key := fmt.Sprintf("thumbnails/episodes/%s/%s%s", episode.ID, uuid.New().String(), ext)
+		var uploadURL string
+		if h.storage != nil {
+			uploadURL, err = h.storage.PresignPut(r.Context(), key, mediaType, 0, uploadURLExpiration)
+			if err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate upload URL")
+				return
+			}
+		} else {
+			// Local development without AWS credentials (S3_MOCK_UPLOADS=true)
+			uploadURL = fmt.Sprintf("https://s3.amazonaws.com/bucket/%s?AWSAccessKeyId=mock&Signature=mock", key)
+		}
+
+		response.ThumbURL = h.cdnURL(key)
+		response.UploadURL = uploadURL
+		response.ExpiresIn = int(uploadURLExpiration.Seconds())
+		response.UploadHeaders = map[string]string{"Content-Type": mediaType}
+	}
+
+	if err := h.db.WithContext(r.Context()).Model(&models.Episode{}).Where("id = ?", episode.ID).
+		Updates(map[string]interface{}{
+			"thumb_url": response.ThumbURL,
+			"version":   gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save thumbnail")
+		return
+	}
+	h.seriesCache.invalidate(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// isThumbnailImagePath reports whether raw's path ends in an accepted image extension
+func isThumbnailImagePath(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if ext == ".jpeg" {
+		return true
+	}
+	for _, accepted := range thumbnailExtensions {
+		if ext == accepted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TranscodingHandler struct {
+	db            *gorm.DB
+	webhookSecret string
+}
+
+func NewTranscodingHandler(db *gorm.DB, webhookSecret string) *TranscodingHandler {
+	return &TranscodingHandler{db: db, webhookSecret: webhookSecret}
+}
+
+type TranscodingWebhookRequest struct {
+	JobID       string            `json:"job_id"`
+	Status      string            `json:"status"`   // "processing", "completed" or "failed"
+	Progress    int               `json:"progress"` // 0-100
+	ManifestURL string            `json:"manifest_url"`
+	OutputPaths map[string]string `json:"output_paths"`
+	Error       string            `json:"error"`
+	// DurationSeconds is the length of the transcoded media, reported on completion
+	DurationSeconds *float64 `json:"duration_seconds"`
+}
+
+// A detected duration differing from the creator's value by more than this
+// fraction, and at least durationMismatchMinSeconds, flags the episode
+const (
+	durationMismatchTolerance  = 0.1
+	durationMismatchMinSeconds = 5
+)
+
+// durationMismatch reports whether claimed is too far from the detected duration
+func durationMismatch(claimed, detected int) bool {
+	diff := math.Abs(float64(claimed - detected))
+	return diff > durationMismatchMinSeconds && diff > durationMismatchTolerance*float64(detected)
+}
+
+// Webhook receives progress reports from the transcoder and updates the job and its episode
+func (h *TranscodingHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, ok := httputil.ReadBody(w, r)
+	if !ok {
+		return
+	}
+
+	if !validHMACSignature(h.webhookSecret, body, r.Header.Get("X-Transcoder-Signature")) {
+		httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid signature")
+		return
+	}
+
+	var req TranscodingWebhookRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	if req.JobID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "job_id is required")
+		return
+	}
+	switch req.Status {
+	case models.TranscodingStatusProcessing, models.TranscodingStatusCompleted, models.TranscodingStatusFailed:
+	default:
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "status must be processing, completed or failed")
+		return
+	}
+	if req.Progress < 0 || req.Progress > 100 {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "progress must be between 0 and 100")
+		return
+	}
+	if req.Status == models.TranscodingStatusCompleted && req.ManifestURL == "" {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "manifest_url is required for completed jobs")
+		return
+	}
+	if req.DurationSeconds != nil && *req.DurationSeconds <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, httputil.CodeInvalidRequest, "duration_seconds must be positive")
+		return
+	}
+
+	// Job IDs are UUIDs, so anything else cannot match a job
+	if uuid.Validate(req.JobID) != nil {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Transcoding job not found")
+		return
+	}
+
+	var job models.TranscodingJob
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the job so concurrent deliveries see each other's final status
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", req.JobID).First(&job).Error; err != nil {
+			return err
+		}
+
+		// Finished jobs ignore late or replayed events
+		if job.Status == models.TranscodingStatusCompleted || job.Status == models.TranscodingStatusFailed {
+			return nil
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status": req.Status,
+		}
+		// Progress and timestamps only ever move forward
+		if req.Progress > job.Progress {
+			updates["progress"] = req.Progress
+		}
+		if job.StartedAt == nil {
+			updates["started_at"] = now
+		}
+
+		episodeUpdates := map[string]interface{}{}
+		switch req.Status {
+		case models.TranscodingStatusCompleted:
+			updates["progress"] = 100
+			updates["completed_at"] = now
+			if len(req.OutputPaths) > 0 {
+				outputs, _ := json.Marshal(req.OutputPaths)
+				updates["output_paths"] = string(outputs)
+			}
+			episodeUpdates["hls_manifest_url"] = req.ManifestURL
+			// A re-upload of a published episode switches media without unpublishing it
+			episodeUpdates["status"] = gorm.Expr("CASE WHEN status = 'published' THEN status ELSE 'ready' END")
+			if req.DurationSeconds != nil {
+				if err := reconcileDuration(tx, job.EpisodeID, *req.DurationSeconds, episodeUpdates); err != nil {
+					return err
+				}
+			}
+		case models.TranscodingStatusFailed:
+			updates["completed_at"] = now
+			updates["error_message"] = req.Error
+			// Send the episode back for a new upload; a published one stays
+			// live on its previous media
+			episodeUpdates["status"] = gorm.Expr("CASE WHEN status = 'published' THEN status ELSE 'pending_upload' END")
+		}
+
+		if err := tx.Model(&job).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if req.Status == models.TranscodingStatusProcessing {
+			return nil
+		}
+		// A job for media that has since been replaced leaves the episode alone
+		return tx.Model(&models.Episode{}).Where("id = ? AND s3_master_path = ?", job.EpisodeID, job.InputPath).
+			Updates(episodeUpdates).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Transcoding job not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update transcoding job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "processed",
+		"job_id": job.ID,
+	})
+}
+
+// reconcileDuration makes the media's measured duration authoritative for the
+// episode, keeping the creator's claimed value and flagging large differences
+func reconcileDuration(tx *gorm.DB, episodeID string, seconds float64, episodeUpdates map[string]interface{}) error {
+	var episode models.Episode
+	if err := tx.Select("id", "duration_seconds", "claimed_duration_seconds").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		return err
+	}
+
+	claimed := episode.DurationSeconds
+	if episode.ClaimedDurationSeconds != nil {
+		claimed = *episode.ClaimedDurationSeconds
+	} else {
+		episodeUpdates["claimed_duration_seconds"] = claimed
+	}
+
+	detected := max(int(math.Round(seconds)), 1)
+	mismatch := durationMismatch(claimed, detected)
+	if mismatch {
+		log.Printf("Episode %s duration mismatch: creator claimed %ds, media is %ds", episodeID, claimed, detected)
+	}
+
+	episodeUpdates["duration_seconds"] = detected
+	episodeUpdates["detected_duration_seconds"] = detected
+	episodeUpdates["duration_mismatch"] = mismatch
+	return nil
+}
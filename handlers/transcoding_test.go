@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranscodingWebhookRejectsMalformedJobID(t *testing.T) {
+	const secret = "transcoder-secret"
+	// A nil database would panic if a malformed ID reached the query
+	h := NewTranscodingHandler(nil, secret)
+	for _, id := range []string{"42", "not-a-uuid", "7d9f3c1e-2b4a-4f6e-9a1b-3c5d7e9f1a2"} {
+		body := `{"job_id":"` + id + `","status":"processing","progress":10}`
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/transcoding", strings.NewReader(body))
+		req.Header.Set("X-Transcoder-Signature", hex.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+		h.Webhook(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("job_id=%q: status = %d, want 404", id, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"gorm.io/gorm"
+)
+
+// trendingMaxSeries caps how many series the trending ranking keeps
+const trendingMaxSeries = 100
+
+// Trending weights: a new subscription says more about a series than a like,
+// and a like more than a view
+const (
+	trendingViewWeight         = 1.0
+	trendingLikeWeight         = 3.0
+	trendingSubscriptionWeight = 5.0
+)
+
+// TrendingOptions configures the trending ranking
+type TrendingOptions struct {
+	// Window is how far back views, likes and subscriptions count
+	Window time.Duration
+	// CacheTTL is how long a computed ranking is served before recomputing
+	CacheTTL time.Duration
+}
+
+type trendingEntry struct {
+	SeriesID string
+	Score    float64
+}
+
+// trendingCache holds the last computed ranking. It is per instance, so
+// invalidating it only affects the instance that handles the request.
+type trendingCache struct {
+	mu         sync.Mutex
+	entries    []trendingEntry
+	computedAt time.Time
+}
+
+type TrendingItem struct {
+	SeriesListItem
+	Score float64 `json:"score"`
+}
+
+type TrendingResponse struct {
+	httputil.PaginatedResponse[TrendingItem]
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// computeTrending ranks published series by activity in the window. Each
+// event is weighted by type and decays with age, counting in full when it
+// just happened and half after a day.
+func computeTrending(ctx context.Context, db *gorm.DB, window time.Duration) ([]trendingEntry, error) {
+	since := time.Now().Add(-window)
+
+	var entries []trendingEntry
+	err := db.WithContext(ctx).Raw(`
+		WITH events AS (
+			SELECT episodes.series_id, ?::float AS weight, episode_views.viewed_at AS at
+			FROM episode_views JOIN episodes ON episodes.id = episode_views.episode_id
+			WHERE episode_views.viewed_at > ?
+			UNION ALL
+			SELECT episodes.series_id, ?::float, episode_likes.created_at
+			FROM episode_likes JOIN episodes ON episodes.id = episode_likes.episode_id
+			WHERE episode_likes.created_at > ? AND episode_likes.deleted_at IS NULL
+			UNION ALL
+			SELECT series_id, ?::float, created_at
+			FROM subscriptions
+			WHERE created_at > ? AND status IN (?, ?) AND deleted_at IS NULL
+		)
+		SELECT events.series_id, SUM(events.weight / (1 + EXTRACT(EPOCH FROM (NOW() - events.at)) / 86400)) AS score
+		FROM events
+		JOIN series ON series.id = events.series_id AND series.status = 'published' AND series.deleted_at IS NULL
+		GROUP BY events.series_id
+		ORDER BY score DESC, events.series_id
+		LIMIT ?`,
+		trendingViewWeight, since,
+		trendingLikeWeight, since,
+		trendingSubscriptionWeight, since, models.SubscriptionStatusActive, models.SubscriptionStatusCancelled,
+		trendingMaxSeries,
+	).Scan(&entries).Error
+	return entries, err
+}
+
+// trendingRanking returns the cached ranking, recomputing it once it is older than the TTL
+func (h *ContentHandler) trendingRanking(ctx context.Context) ([]trendingEntry, time.Time, error) {
+	h.trending.mu.Lock()
+	defer h.trending.mu.Unlock()
+
+	if !h.trending.computedAt.IsZero() && time.Since(h.trending.computedAt) < h.trendingOpts.CacheTTL {
+		return h.trending.entries, h.trending.computedAt, nil
+	}
+
+	entries, err := computeTrending(ctx, h.db, h.trendingOpts.Window)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	h.trending.entries = entries
+	h.trending.computedAt = time.Now()
+	return entries, h.trending.computedAt, nil
+}
+
+// GetTrending lists published series ranked by recent views, likes and subscriptions
+func (h *ContentHandler) GetTrending(w http.ResponseWriter, r *http.Request) {
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	ranking, computedAt, err := h.trendingRanking(r.Context())
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to compute trending series")
+		return
+	}
+
+	var pageEntries []trendingEntry
+	if offset < len(ranking) {
+		pageEntries = ranking[offset:min(offset+perPage, len(ranking))]
+	}
+
+	ids := make([]string, len(pageEntries))
+	for i, e := range pageEntries {
+		ids[i] = e.SeriesID
+	}
+
+	// The ranking may be stale, so re-check that each series is still published
+	var seriesRows []models.Series
+	if len(ids) > 0 {
+		if err := h.db.WithContext(r.Context()).Where("id IN ? AND status = ?", ids, "published").
+			Preload("Creator").
+			Preload("Episodes", "status = ?", "published").
+			Find(&seriesRows).Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch series")
+			return
+		}
+	}
+	byID := make(map[string]models.Series, len(seriesRows))
+	for _, s := range seriesRows {
+		byID[s.ID] = s
+	}
+
+	listItems := make([]SeriesListItem, 0, len(pageEntries))
+	scores := make([]float64, 0, len(pageEntries))
+	for _, e := range pageEntries {
+		if s, ok := byID[e.SeriesID]; ok {
+			listItems = append(listItems, toSeriesListItem(s))
+			scores = append(scores, e.Score)
+		}
+	}
+	if err := attachSeriesRatings(h.db.WithContext(r.Context()), listItems); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ratings")
+		return
+	}
+
+	items := make([]TrendingItem, len(listItems))
+	for i := range listItems {
+		items[i] = TrendingItem{SeriesListItem: listItems[i], Score: scores[i]}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TrendingResponse{
+		PaginatedResponse: httputil.NewPaginatedResponse(items, int64(len(ranking)), page, perPage),
+		ComputedAt:        computedAt,
+	})
+}
+
+// InvalidateTrending drops this instance's cached trending ranking so the next
+// request recomputes it
+func (h *ContentHandler) InvalidateTrending(w http.ResponseWriter, r *http.Request) {
+	h.trending.mu.Lock()
+	h.trending.entries = nil
+	h.trending.computedAt = time.Time{}
+	h.trending.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
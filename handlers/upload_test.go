@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestRequestUploadURLIdempotencyKey(t *testing.T) {
+	db := testdb.Open(t)
+	user, _ := createTestCreator(t, db)
+	h := NewContentHandler(db, nil, true, "", nil, nil, TrendingOptions{}, UploadLimits{ContentTypes: []string{"video/mp4"}}, nil)
+
+	request := func() string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/content/upload-url",
+			strings.NewReader(`{"filename":"pilot.mp4","content_type":"video/mp4","size_bytes":1024}`))
+		req.Header.Set("Idempotency-Key", "upload-once")
+		rec := httptest.NewRecorder()
+		h.RequestUploadURL(rec, asUser(req, user.ID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var resp UploadUrlResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.UploadID
+	}
+
+	first, second := request(), request()
+	if first != second {
+		t.Errorf("retry created upload %s, want the original %s", second, first)
+	}
+
+	var uploads, keys int64
+	db.Model(&models.UploadRequest{}).Where("user_id = ?", user.ID).Count(&uploads)
+	db.Model(&models.IdempotencyKey{}).Where("user_id = ? AND resource_id = ?", user.ID, first).Count(&keys)
+	if uploads != 1 || keys != 1 {
+		t.Errorf("uploads = %d, keys = %d; want one of each", uploads, keys)
+	}
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/httputil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserHandler struct {
+	db *gorm.DB
+}
+
+func NewUserHandler(db *gorm.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+type SeriesSummary struct {
+	ID           string  `json:"id"`
+	Title        string  `json:"title"`
+	ThumbnailURL *string `json:"thumbnail_url"`
+}
+
+type ContinueWatchingItem struct {
+	EpisodeID       string        `json:"episode_id"`
+	Title           string        `json:"title"`
+	EpisodeNumber   int           `json:"episode_number"`
+	DurationSeconds int           `json:"duration_seconds"`
+	ThumbURL        *string       `json:"thumb_url"`
+	PositionSeconds int           `json:"position_seconds"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Series          SeriesSummary `json:"series"`
+}
+
+type ContinueWatchingResponse struct {
+	Items []ContinueWatchingItem `json:"items"`
+}
+
+// GetContinueWatching lists the user's partially watched episodes, most recent first
+func (h *UserHandler) GetContinueWatching(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	var rows []struct {
+		EpisodeID          string
+		Title              string
+		EpisodeNumber      int
+		DurationSeconds    int
+		ThumbURL           *string
+		PositionSeconds    int
+		UpdatedAt          time.Time
+		SeriesID           string
+		SeriesTitle        string
+		SeriesThumbnailURL *string
+	}
+	if err := h.db.WithContext(r.Context()).Table("watch_progress").
+		Select("episodes.id AS episode_id, episodes.title, episodes.episode_number, episodes.duration_seconds, episodes.thumb_url, "+
+			"watch_progress.position_seconds, watch_progress.updated_at, "+
+			"series.id AS series_id, series.title AS series_title, series.thumbnail_url AS series_thumbnail_url").
+		Joins("JOIN episodes ON episodes.id = watch_progress.episode_id AND episodes.deleted_at IS NULL").
+		Joins("JOIN series ON series.id = episodes.series_id AND series.deleted_at IS NULL").
+		Where("watch_progress.user_id = ? AND watch_progress.completed = ? AND watch_progress.deleted_at IS NULL", userID, false).
+		Where("episodes.status = ?", "published").
+		Order("watch_progress.updated_at DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch watch progress")
+		return
+	}
+
+	items := make([]ContinueWatchingItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, ContinueWatchingItem{
+			EpisodeID:       row.EpisodeID,
+			Title:           row.Title,
+			EpisodeNumber:   row.EpisodeNumber,
+			DurationSeconds: row.DurationSeconds,
+			ThumbURL:        row.ThumbURL,
+			PositionSeconds: row.PositionSeconds,
+			UpdatedAt:       row.UpdatedAt,
+			Series: SeriesSummary{
+				ID:           row.SeriesID,
+				Title:        row.SeriesTitle,
+				ThumbnailURL: row.SeriesThumbnailURL,
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ContinueWatchingResponse{Items: items})
+}
+
+type FavoriteResponse struct {
+	SeriesID   string `json:"series_id"`
+	IsFavorite bool   `json:"is_favorite"`
+}
+
+// AddFavorite adds a published series to the user's favorites. Favoriting a
+// series twice is a no-op.
+func (h *UserHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	seriesID := mux.Vars(r)["id"]
+
+	var series models.Series
+	if err := h.db.WithContext(r.Context()).Where("id = ? AND status = ?", seriesID, "published").First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Series not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	favorite := models.UserFavorite{UserID: userID, SeriesID: series.ID}
+	if err := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save favorite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FavoriteResponse{SeriesID: series.ID, IsFavorite: true})
+}
+
+// RemoveFavorite removes a series from the user's favorites. Removing a series
+// that is not a favorite succeeds.
+func (h *UserHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	seriesID := mux.Vars(r)["id"]
+
+	if err := h.db.WithContext(r.Context()).Where("user_id = ? AND series_id = ?", userID, seriesID).Delete(&models.UserFavorite{}).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to remove favorite")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FavoriteResponse{SeriesID: seriesID, IsFavorite: false})
+}
+
+// GetFavorites lists the user's favorited series, most recently favorited first.
+// Series that have since been unpublished or deleted are left out.
+func (h *UserHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Model(&models.Series{}).
+		Joins("JOIN user_favorites ON user_favorites.series_id = series.id").
+		Where("user_favorites.user_id = ? AND series.status = ?", userID, "published")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch favorites")
+		return
+	}
+
+	var seriesRows []models.Series
+	if err := query.
+		Preload("Creator").
+		Preload("Episodes", "status = ?", "published").
+		Order("user_favorites.created_at DESC").
+		Offset(offset).Limit(perPage).
+		Find(&seriesRows).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch favorites")
+		return
+	}
+
+	items := make([]SeriesListItem, 0, len(seriesRows))
+	for _, s := range seriesRows {
+		items = append(items, toSeriesListItem(s))
+	}
+	if err := attachSeriesRatings(h.db.WithContext(r.Context()), items); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ratings")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(items, total, page, perPage))
+}
+
+type FollowResponse struct {
+	CreatorID   string `json:"creator_id"`
+	IsFollowing bool   `json:"is_following"`
+}
+
+// FollowCreator follows a creator so the user is notified of new episodes
+// across all of their series. Following a creator twice is a no-op.
+func (h *UserHandler) FollowCreator(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	var creator models.CreatorProfile
+	if err := h.db.WithContext(r.Context()).Where("id = ?", mux.Vars(r)["id"]).First(&creator).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "Creator not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+
+	follow := models.CreatorFollow{UserID: userID, CreatorID: creator.ID}
+	if err := h.db.WithContext(r.Context()).Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to follow creator")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FollowResponse{CreatorID: creator.ID, IsFollowing: true})
+}
+
+// UnfollowCreator stops following a creator. Unfollowing a creator that is
+// not followed succeeds.
+func (h *UserHandler) UnfollowCreator(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	creatorID := mux.Vars(r)["id"]
+
+	if err := h.db.WithContext(r.Context()).Where("user_id = ? AND creator_id = ?", userID, creatorID).Delete(&models.CreatorFollow{}).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to unfollow creator")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FollowResponse{CreatorID: creatorID, IsFollowing: false})
+}
+
+// GetFollowing lists the creators the user follows, most recently followed
+// first. Creators that have since been deleted are left out.
+func (h *UserHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	page, perPage, offset := httputil.ParsePagination(r)
+
+	query := h.db.WithContext(r.Context()).Model(&models.CreatorProfile{}).
+		Joins("JOIN creator_follows ON creator_follows.creator_id = creator_profiles.id").
+		Where("creator_follows.user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch followed creators")
+		return
+	}
+
+	items := []CreatorListItem{}
+	if err := query.
+		Select(creatorListColumns).
+		Order("creator_follows.created_at DESC").
+		Offset(offset).Limit(perPage).
+		Scan(&items).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch followed creators")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httputil.NewPaginatedResponse(items, total, page, perPage))
+}
+
+type MeResponse struct {
+	ID        string    `json:"id"`
+	Phone     string    `json:"phone"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	// PhoneVerified is always true: accounts are only created by verifying an OTP
+	PhoneVerified     bool    `json:"phone_verified"`
+	HasCreatorProfile bool    `json:"has_creator_profile"`
+	CreatorID         *string `json:"creator_id"`
+	// KYCStatus is the creator's KYC review status, null without a creator profile
+	KYCStatus     *string                `json:"kyc_status"`
+	Subscriptions MeSubscriptionsSummary `json:"subscriptions"`
+}
+
+type MeSubscriptionsSummary struct {
+	ActiveCount int64 `json:"active_count"`
+	// NextExpiresAt is when the soonest-ending active subscription ends
+	NextExpiresAt *time.Time `json:"next_expires_at"`
+}
+
+// meRow is the flat result of the single query behind GetMe
+type meRow struct {
+	ID                  string
+	Phone               string
+	Role                string
+	CreatedAt           time.Time
+	CreatorID           *string
+	KYCStatus           *string
+	ActiveSubscriptions int64
+	NextExpiresAt       *time.Time
+}
+
+// GetMe returns the authenticated user's account, creator status and a
+// summary of their active subscriptions in one query. Clients call it on
+// launch to learn who is signed in.
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "User ID not found in context")
+		return
+	}
+
+	// Active matches models.IsSubscriptionActive: cancelled subscriptions keep
+	// access until the paid period ends
+	activeSubscriptions := "FROM subscriptions WHERE subscriptions.user_id = users.id AND subscriptions.deleted_at IS NULL " +
+		"AND subscriptions.status IN (@active, @cancelled) AND subscriptions.expires_at > @now"
+	args := map[string]interface{}{
+		"active":    models.SubscriptionStatusActive,
+		"cancelled": models.SubscriptionStatusCancelled,
+		"now":       time.Now(),
+	}
+
+	var row meRow
+	res := h.db.WithContext(r.Context()).Model(&models.User{}).
+		Select("users.id, users.phone, users.role, users.created_at, "+
+			"creator_profiles.id AS creator_id, creator_profiles.kyc_status, "+
+			"(SELECT COUNT(*) "+activeSubscriptions+") AS active_subscriptions, "+
+			"(SELECT MIN(subscriptions.expires_at) "+activeSubscriptions+") AS next_expires_at", args).
+		Joins("LEFT JOIN creator_profiles ON creator_profiles.user_id = users.id AND creator_profiles.deleted_at IS NULL").
+		Where("users.id = ?", userID).
+		Scan(&row)
+	if res.Error != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	if res.RowsAffected == 0 {
+		httputil.WriteError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MeResponse{
+		ID:                row.ID,
+		Phone:             row.Phone,
+		Role:              row.Role,
+		CreatedAt:         row.CreatedAt,
+		PhoneVerified:     true,
+		HasCreatorProfile: row.CreatorID != nil,
+		CreatorID:         row.CreatorID,
+		KYCStatus:         row.KYCStatus,
+		Subscriptions: MeSubscriptionsSummary{
+			ActiveCount:   row.ActiveSubscriptions,
+			NextExpiresAt: row.NextExpiresAt,
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+
+	"streamshort/pkg/storage"
+)
+
+// validThumbnailURL reports whether raw is an absolute http(s) URL on one of
+// the allowed hosts. Anything else (javascript:, data:, foreign hosts) could
+// be rendered by web clients, so it is refused.
+func validThumbnailURL(raw string, allowedHosts []string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.User != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range allowedHosts {
+		if host == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// validUserUploadPath reports whether path is an s3://bucket/... URI for our
+// bucket whose key lies under the user's own uploads prefix. When no bucket is
+// configured (local development), bare keys are accepted too.
+func validUserUploadPath(path, bucket, userID string) bool {
+	if bucket != "" && !strings.HasPrefix(path, "s3://") {
+		return false
+	}
+	key, ok := storage.KeyFromPath(bucket, path)
+	if !ok || strings.Contains(key, "..") {
+		return false
+	}
+	return strings.HasPrefix(key, "uploads/"+userID+"/")
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"streamshort/pkg/httputil"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// expectedVersion returns the version a client last read, from the request
+// body or else an If-Match header ("3", "\"3\"" or W/"3"). nil means the
+// client did not ask for a version check.
+func expectedVersion(r *http.Request, bodyVersion *int) (*int, error) {
+	if bodyVersion != nil {
+		return bodyVersion, nil
+	}
+
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	header = strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	v, err := strconv.Atoi(header)
+	if err != nil || v <= 0 {
+		return nil, errors.New("If-Match must be a version number")
+	}
+	return &v, nil
+}
+
+// versionedUpdate applies updates to model and bumps its version, refreshing
+// model.Version from the database. With an expected version the write only
+// happens while the row is still at that version; updated is false otherwise.
+func versionedUpdate(db *gorm.DB, model interface{}, expected *int, updates map[string]interface{}) (updated bool, err error) {
+	updates["version"] = gorm.Expr("version + 1")
+
+	query := db.Model(model).Clauses(clause.Returning{Columns: []clause.Column{{Name: "version"}}})
+	if expected != nil {
+		query = query.Where("version = ?", *expected)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// writeStaleVersion responds 409 with the row's current version so the client can reload and retry
+func writeStaleVersion(w http.ResponseWriter, db *gorm.DB, model interface{}, id string) {
+	var current int
+	if err := db.Model(model).Where("id = ?", id).Select("version").Scan(&current).Error; err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+		return
+	}
+	httputil.WriteErrorDetails(w, http.StatusConflict, httputil.CodeConflict,
+		"This item was changed by someone else; reload it and try again", map[string]interface{}{
+			"current_version": current,
+		})
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"streamshort/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhookRefs identifies the payment and the subscription or purchase a
+// webhook event is about. The provider IDs and the amount come from the event
+// itself; our own IDs come from the notes attached when the checkout was
+// created, which the client can influence.
+type webhookRefs struct {
+	PaymentID              string
+	ProviderSubscriptionID string
+	ProviderOrderID        string
+	// AmountPaise is what the provider charged, in the currency's smallest unit
+	AmountPaise    int64
+	HasAmount      bool
+	Currency       string
+	SubscriptionID string
+	PurchaseID     string
+}
+
+func parseWebhookRefs(data map[string]interface{}) webhookRefs {
+	refs := webhookRefs{
+		PaymentID:              stringField(data, "payment_id"),
+		ProviderSubscriptionID: stringField(data, "subscription_id"),
+		ProviderOrderID:        stringField(data, "order_id"),
+		Currency:               stringField(data, "currency"),
+	}
+	switch amount := data["amount"].(type) {
+	case float64:
+		refs.AmountPaise, refs.HasAmount = int64(math.Round(amount)), true
+	case string:
+		if n, err := strconv.ParseInt(amount, 10, 64); err == nil {
+			refs.AmountPaise, refs.HasAmount = n, true
+		}
+	}
+	if notes, ok := data["notes"].(map[string]interface{}); ok {
+		// Only well-formed IDs are used; they are trusted no further than that
+		if id := stringField(notes, "subscription_id"); uuid.Validate(id) == nil {
+			refs.SubscriptionID = id
+		}
+		if id := stringField(notes, "purchase_id"); uuid.Validate(id) == nil {
+			refs.PurchaseID = id
+		}
+	}
+	return refs
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// webhookFlag is returned when a verified event must not be applied, such as
+// a payment for the wrong amount. The event is marked processed with the
+// reason instead of being retried.
+type webhookFlag struct {
+	reason string
+}
+
+func (f *webhookFlag) Error() string {
+	return f.reason
+}
+
+// paidAmount returns what the event says was charged, in rupees, after
+// checking it is exactly the expected price in INR
+func (refs webhookRefs) paidAmount(expected float64) (float64, error) {
+	if !refs.HasAmount {
+		return 0, &webhookFlag{reason: "payment amount missing"}
+	}
+	if refs.currency() != "INR" {
+		return 0, &webhookFlag{reason: fmt.Sprintf("payment currency %s, expected INR", refs.Currency)}
+	}
+	if refs.AmountPaise != int64(math.Round(expected*100)) {
+		return 0, &webhookFlag{reason: fmt.Sprintf("payment amount %d paise, expected %.2f", refs.AmountPaise, expected)}
+	}
+	return float64(refs.AmountPaise) / 100, nil
+}
+
+// findWebhookSubscription locks the subscription an event refers to: the one
+// already linked to the provider's subscription ID or, when allowNotes is
+// set, the unlinked one named in the notes. It returns nil when none matches.
+func findWebhookSubscription(tx *gorm.DB, refs webhookRefs, allowNotes bool) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if refs.ProviderSubscriptionID != "" {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider_subscription_id = ?", refs.ProviderSubscriptionID).First(&subscription).Error
+		if err == nil {
+			return &subscription, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+	if !allowNotes || refs.SubscriptionID == "" {
+		return nil, nil
+	}
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND provider_subscription_id IS NULL", refs.SubscriptionID).First(&subscription).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// findWebhookPurchase locks the purchase an event refers to: the one already
+// linked to the provider's order ID or, when allowNotes is set, the unlinked
+// one named in the notes. It returns nil when none matches.
+func findWebhookPurchase(tx *gorm.DB, refs webhookRefs, allowNotes bool) (*models.Purchase, error) {
+	var purchase models.Purchase
+	if refs.ProviderOrderID != "" {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider_order_id = ?", refs.ProviderOrderID).First(&purchase).Error
+		if err == nil {
+			return &purchase, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+	if !allowNotes || refs.PurchaseID == "" {
+		return nil, nil
+	}
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND provider_order_id IS NULL", refs.PurchaseID).First(&purchase).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// cancelProviderSubscription marks a subscription cancelled when the provider
+// stops renewing it. Access continues until the paid period ends. Only a
+// subscription already linked by a verified payment can be cancelled this
+// way, so notes cannot point the event at someone else's subscription.
+func (h *PaymentHandler) cancelProviderSubscription(tx *gorm.DB, refs webhookRefs) error {
+	subscription, err := findWebhookSubscription(tx, refs, false)
+	if err != nil {
+		return err
+	}
+	if subscription == nil {
+		log.Printf("Webhook subscription.cancelled matched no subscription (provider id %q)", refs.ProviderSubscriptionID)
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"status":     models.SubscriptionStatusCancelled,
+		"auto_renew": false,
+	}
+	if subscription.ExpiresAt == nil {
+		// Never paid for, so there is no period left to honour
+		updates["expires_at"] = time.Now()
+	}
+	return tx.Model(subscription).Updates(updates).Error
+}
+
+// applyPaymentSucceeded activates the subscription or completes the purchase
+// a payment was for and records the charge. The amount charged must match
+// the stored price, otherwise the event is flagged and nothing is unlocked.
+// The provider's subscription or order ID is linked only once a payment has
+// been verified. A payment that was already recorded is skipped, so the
+// provider's retries are harmless.
+func (h *PaymentHandler) applyPaymentSucceeded(tx *gorm.DB, refs webhookRefs) error {
+	recorded, err := paymentRecorded(tx, refs.PaymentID, models.PaymentStatusSucceeded)
+	if err != nil || recorded {
+		return err
+	}
+
+	subscription, err := findWebhookSubscription(tx, refs, true)
+	if err != nil {
+		return err
+	}
+	if subscription != nil {
+		paid, err := refs.paidAmount(subscription.Amount)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		// Each payment extends access by one period from the later of now and
+		// the current expiry, so early renewals are not lost
+		expiresAt := now
+		if subscription.ExpiresAt != nil && subscription.ExpiresAt.After(now) {
+			expiresAt = *subscription.ExpiresAt
+		}
+		expiresAt = expiresAt.Add(h.opts.SubscriptionPeriod)
+
+		updates := map[string]interface{}{
+			"status":     models.SubscriptionStatusActive,
+			"expires_at": expiresAt,
+		}
+		if subscription.StartedAt == nil {
+			updates["started_at"] = now
+		}
+		if subscription.ProviderSubscriptionID == nil && refs.ProviderSubscriptionID != "" {
+			updates["provider_subscription_id"] = refs.ProviderSubscriptionID
+		}
+		if err := tx.Model(subscription).Updates(updates).Error; err != nil {
+			return err
+		}
+		transaction := models.PaymentTransaction{
+			UserID:            subscription.UserID,
+			SeriesID:          subscription.SeriesID,
+			SubscriptionID:    &subscription.ID,
+			ProviderPaymentID: refs.PaymentID,
+			Amount:            paid,
+			Currency:          refs.currency(),
+			Status:            models.PaymentStatusSucceeded,
+		}
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+		return h.creditCreator(tx, transaction)
+	}
+
+	purchase, err := findWebhookPurchase(tx, refs, true)
+	if err != nil {
+		return err
+	}
+	if purchase != nil {
+		paid, err := refs.paidAmount(purchase.Amount)
+		if err != nil {
+			return err
+		}
+		if purchase.ProviderOrderID == nil && refs.ProviderOrderID != "" {
+			if err := tx.Model(purchase).Update("provider_order_id", refs.ProviderOrderID).Error; err != nil {
+				return err
+			}
+		}
+
+		// A second charge for the series is due a refund, so the creator is
+		// only credited for the payment that completes the purchase
+		completed := false
+		if purchase.Status == models.PurchaseStatusCompleted {
+			log.Printf("Purchase %s was paid again by payment %q; refund required", purchase.ID, refs.PaymentID)
+		} else if completed, err = completePurchase(tx, purchase); err != nil {
+			return err
+		}
+		transaction := models.PaymentTransaction{
+			UserID:            purchase.UserID,
+			SeriesID:          purchase.SeriesID,
+			PurchaseID:        &purchase.ID,
+			ProviderPaymentID: refs.PaymentID,
+			Amount:            paid,
+			Currency:          refs.currency(),
+			Status:            models.PaymentStatusSucceeded,
+		}
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+		if !completed {
+			return nil
+		}
+		return h.creditCreator(tx, transaction)
+	}
+
+	log.Printf("Webhook payment.succeeded for payment %q matched no subscription or purchase", refs.PaymentID)
+	return nil
+}
+
+// completePurchase marks a purchase completed and reports whether it did. If
+// the user already completed another purchase of the series the charge is
+// still recorded, but this purchase is left as it is and logged for a refund.
+func completePurchase(tx *gorm.DB, purchase *models.Purchase) (bool, error) {
+	var completed int64
+	if err := tx.Model(&models.Purchase{}).
+		Where("user_id = ? AND series_id = ? AND status = ?", purchase.UserID, purchase.SeriesID, models.PurchaseStatusCompleted).
+		Count(&completed).Error; err != nil {
+		return false, err
+	}
+	if completed > 0 {
+		log.Printf("Purchase %s was paid but the series is already purchased; refund required", purchase.ID)
+		return false, nil
+	}
+
+	err := tx.Model(purchase).Updates(map[string]interface{}{
+		"status":       models.PurchaseStatusCompleted,
+		"completed_at": time.Now(),
+	}).Error
+	return err == nil, err
+}
+
+// creditCreator appends the series creator's share of a successful payment,
+// net of the platform commission, to the earnings ledger
+func (h *PaymentHandler) creditCreator(tx *gorm.DB, transaction models.PaymentTransaction) error {
+	// The series may have been deleted since it was paid for
+	var series models.Series
+	if err := tx.Unscoped().Select("id", "creator_id").Where("id = ?", transaction.SeriesID).First(&series).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.CreatorEarning{
+		CreatorID:            series.CreatorID,
+		SeriesID:             series.ID,
+		PaymentTransactionID: transaction.ID,
+		GrossAmount:          transaction.Amount,
+		CommissionRate:       h.opts.CommissionRate,
+		NetAmount:            creatorShare(transaction.Amount, h.opts.CommissionRate),
+		Currency:             transaction.Currency,
+	}).Error
+}
+
+// creatorShare is what a creator earns from a payment of amount rupees after
+// the platform's commission, rounded half up to the paisa. It works in whole
+// paise and ten-thousandths of the rate, the precision the ledger stores, so
+// float error cannot push a half paisa the wrong way.
+func creatorShare(amount, commissionRate float64) float64 {
+	paise := int64(math.Round(amount * 100))
+	keep := 10000 - int64(math.Round(commissionRate*10000))
+	return float64((paise*keep+5000)/10000) / 100
+}
+
+// applyPaymentFailed records a failed charge against the subscription or
+// purchase it was for, with the amount the provider reports. A failed
+// purchase is marked failed; a subscription is left alone so it lapses when
+// its paid period ends.
+func (h *PaymentHandler) applyPaymentFailed(tx *gorm.DB, refs webhookRefs) error {
+	recorded, err := paymentRecorded(tx, refs.PaymentID, models.PaymentStatusFailed)
+	if err != nil || recorded {
+		return err
+	}
+
+	transaction := models.PaymentTransaction{
+		ProviderPaymentID: refs.PaymentID,
+		Amount:            float64(refs.AmountPaise) / 100,
+		Currency:          refs.currency(),
+		Status:            models.PaymentStatusFailed,
+	}
+	subscription, err := findWebhookSubscription(tx, refs, true)
+	if err != nil {
+		return err
+	}
+	if subscription != nil {
+		transaction.UserID, transaction.SeriesID = subscription.UserID, subscription.SeriesID
+		transaction.SubscriptionID = &subscription.ID
+		return tx.Create(&transaction).Error
+	}
+
+	purchase, err := findWebhookPurchase(tx, refs, true)
+	if err != nil {
+		return err
+	}
+	if purchase != nil {
+		if purchase.Status == models.PurchaseStatusPending {
+			if err := tx.Model(purchase).Update("status", models.PurchaseStatusFailed).Error; err != nil {
+				return err
+			}
+		}
+		transaction.UserID, transaction.SeriesID = purchase.UserID, purchase.SeriesID
+		transaction.PurchaseID = &purchase.ID
+		return tx.Create(&transaction).Error
+	}
+
+	log.Printf("Webhook payment.failed for payment %q matched no subscription or purchase", refs.PaymentID)
+	return nil
+}
+
+// paymentRecorded reports whether the provider's payment was already recorded
+// with the given status
+func paymentRecorded(tx *gorm.DB, paymentID, status string) (bool, error) {
+	if paymentID == "" {
+		return false, nil
+	}
+	var count int64
+	err := tx.Model(&models.PaymentTransaction{}).
+		Where("provider_payment_id = ? AND status = ?", paymentID, status).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (refs webhookRefs) currency() string {
+	if refs.Currency == "" {
+		return "INR"
+	}
+	return strings.ToUpper(refs.Currency)
+}
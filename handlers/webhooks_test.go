@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseWebhookRefs(t *testing.T) {
+	const purchaseID = "7d9f3c1e-2b4a-4f6e-9a1b-3c5d7e9f1a2b"
+	refs := parseWebhookRefs(map[string]interface{}{
+		"payment_id": "pay_123",
+		"order_id":   "order_456",
+		"amount":     float64(19900),
+		"currency":   "inr",
+		"notes": map[string]interface{}{
+			"purchase_id":     purchaseID,
+			"subscription_id": "not-a-uuid",
+		},
+	})
+
+	if refs.PaymentID != "pay_123" || refs.ProviderOrderID != "order_456" {
+		t.Errorf("provider ids = %q, %q", refs.PaymentID, refs.ProviderOrderID)
+	}
+	if !refs.HasAmount || refs.AmountPaise != 19900 {
+		t.Errorf("amount = %d (present %v), want 19900", refs.AmountPaise, refs.HasAmount)
+	}
+	if refs.currency() != "INR" {
+		t.Errorf("currency = %q, want INR", refs.currency())
+	}
+	if refs.PurchaseID != purchaseID {
+		t.Errorf("purchase id = %q, want %q", refs.PurchaseID, purchaseID)
+	}
+	if refs.SubscriptionID != "" {
+		t.Errorf("malformed subscription id %q was accepted", refs.SubscriptionID)
+	}
+}
+
+func TestPaidAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		refs     webhookRefs
+		expected float64
+		want     float64
+		flagged  bool
+	}{
+		{"exact amount", webhookRefs{AmountPaise: 19900, HasAmount: true}, 199, 199, false},
+		{"paise rounding", webhookRefs{AmountPaise: 4999, HasAmount: true, Currency: "INR"}, 49.99, 49.99, false},
+		{"underpaid", webhookRefs{AmountPaise: 100, HasAmount: true}, 199, 0, true},
+		{"overpaid", webhookRefs{AmountPaise: 29900, HasAmount: true}, 199, 0, true},
+		{"amount missing", webhookRefs{}, 199, 0, true},
+		{"other currency", webhookRefs{AmountPaise: 19900, HasAmount: true, Currency: "USD"}, 199, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.refs.paidAmount(tt.expected)
+			var flag *webhookFlag
+			if flagged := errors.As(err, &flag); flagged != tt.flagged {
+				t.Fatalf("flagged = %v (%v), want %v", flagged, err, tt.flagged)
+			}
+			if got != tt.want {
+				t.Errorf("paid = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatorShare(t *testing.T) {
+	tests := []struct {
+		amount, rate, want float64
+	}{
+		{199, 0.2, 159.2},
+		{99.99, 0.2, 79.99},    // 79.992
+		{10.01, 0.2, 8.01},     // 8.008
+		{0.15, 0.3, 0.11},      // 0.105 rounds half up, not down through float error
+		{1.25, 0.15, 1.06},     // 1.0625
+		{49.99, 0.1234, 43.82}, // 43.8212
+		{199, 0, 199},
+		{199, 1, 0},
+		{0, 0.2, 0},
+	}
+	for _, tt := range tests {
+		if got := creatorShare(tt.amount, tt.rate); got != tt.want {
+			t.Errorf("creatorShare(%v, %v) = %v, want %v", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"streamshort/config"
 	"streamshort/handlers"
 	"streamshort/middleware"
+	"streamshort/models"
+	"streamshort/pkg/cache"
+	"streamshort/pkg/cdn"
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/metrics"
+	"streamshort/pkg/ratelimit"
+	"streamshort/pkg/sms"
+	"streamshort/pkg/storage"
+	"streamshort/pkg/sweeper"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 )
 
@@ -28,6 +42,11 @@ func helloHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	// Run in UTC so times the handlers take from time.Now and render as RFC
+	// 3339 do not depend on the host's time zone. The database layer scans
+	// timestamps in UTC on its own (config.Open).
+	time.Local = time.UTC
+
 	// Load environment variables from files for local development
 	// .env.local (if present) overrides .env
 	if err := godotenv.Load(".env.local"); err == nil {
@@ -38,46 +57,192 @@ func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	jwtSecret, err := cfg.ResolveJWTSecret()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ValidateTokenTTLs(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ValidateBilling(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	// Initialize database
 	db := config.InitDB()
 
+	// Initialize S3 storage (optional for local development)
+	s3Client, err := storage.NewS3Client(context.Background(), cfg.S3Bucket, cfg.AWSRegion)
+	if err != nil {
+		log.Printf("S3 storage disabled: %v", err)
+		if cfg.MockUploads {
+			log.Println("Using mock upload URLs (S3_MOCK_UPLOADS=true)")
+		}
+	}
+
+	// Initialize CloudFront URL signing (manifest URLs are unsigned without it)
+	var cdnSigner *cdn.Signer
+	if cfg.CloudFrontKeyPairID != "" && cfg.CloudFrontKeyPath != "" {
+		cdnSigner, err = cdn.NewSignerFromFile(cfg.CloudFrontKeyPairID, cfg.CloudFrontKeyPath)
+		if err != nil {
+			log.Fatalf("Failed to load CloudFront signing key: %v", err)
+		}
+	} else {
+		log.Println("CloudFront signing keys not configured; manifest URLs will be unsigned")
+	}
+
+	// Initialize SMS delivery for OTP codes
+	var smsSender handlers.SMSSender
+	if cfg.TwilioAccountSID != "" && cfg.TwilioAuthToken != "" && cfg.TwilioFromNumber != "" {
+		smsSender = sms.NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFromNumber)
+	} else {
+		log.Println("WARNING: Twilio is not configured; OTP messages will only be logged")
+		smsSender = sms.LogSender{}
+	}
+
+	// Rate limit buckets and series listings are shared through Redis when configured
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	var seriesCache *handlers.SeriesListCache
+	var healthChecks []handlers.HealthCheck
+	if cfg.RedisURL != "" {
+		redisStore, err := ratelimit.NewRedisStore(context.Background(), cfg.RedisURL)
+		if err != nil {
+			log.Printf("Redis unavailable, using in-memory rate limits: %v", err)
+			redisErr := fmt.Errorf("not connected: %w", err)
+			healthChecks = append(healthChecks, handlers.HealthCheck{Name: "redis", Check: func(context.Context) error {
+				return redisErr
+			}})
+		} else {
+			limitStore = redisStore
+			healthChecks = append(healthChecks, handlers.HealthCheck{Name: "redis", Check: redisStore.Ping})
+		}
+		if cfg.SeriesListCacheTTL > 0 {
+			cacheStore, err := cache.NewRedisStore(context.Background(), cfg.RedisURL)
+			if err != nil {
+				log.Printf("Redis unavailable, series listings will not be cached: %v", err)
+			} else {
+				seriesCache = handlers.NewSeriesListCache(cacheStore, cfg.SeriesListCacheTTL)
+			}
+		}
+	}
+	publicLimiter := middleware.NewRateLimiter(limitStore, "public", ratelimit.Limit{
+		PerMinute: cfg.RateLimitPublicRPM, Burst: cfg.RateLimitPublicBurst,
+	}, cfg.TrustedProxyHops)
+	authLimiter := middleware.NewRateLimiter(limitStore, "auth", ratelimit.Limit{
+		PerMinute: cfg.RateLimitAuthRPM, Burst: cfg.RateLimitAuthBurst,
+	}, cfg.TrustedProxyHops)
+	apiLimiter := middleware.NewRateLimiter(limitStore, "api", ratelimit.Limit{
+		PerMinute: cfg.RateLimitAPIRPM, Burst: cfg.RateLimitAPIBurst,
+	}, cfg.TrustedProxyHops)
+	exportLimiter := middleware.NewRateLimiter(limitStore, "export", ratelimit.Limit{
+		PerMinute: cfg.RateLimitExportRPM, Burst: cfg.RateLimitExportBurst,
+	}, cfg.TrustedProxyHops)
+	reportLimiter := middleware.NewRateLimiter(limitStore, "report", ratelimit.Limit{
+		PerMinute: cfg.RateLimitReportRPM, Burst: cfg.RateLimitReportBurst,
+	}, cfg.TrustedProxyHops)
+
+	// Cap request body sizes for every JSON endpoint
+	httputil.MaxBodyBytes = cfg.MaxBodyBytes
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db)
-	creatorHandler := handlers.NewCreatorHandler(db)
-	contentHandler := handlers.NewContentHandler(db)
-	paymentHandler := handlers.NewPaymentHandler()
+	authHandler := handlers.NewAuthHandler(db, jwtSecret, smsSender, handlers.AuthOptions{
+		OTPMaxSendsPerHour:   cfg.OTPMaxSendsPerHour,
+		OTPMaxVerifyAttempts: cfg.OTPMaxVerifyAttempts,
+		AccessTokenTTL:       cfg.AccessTokenTTL,
+		RefreshTokenTTL:      cfg.RefreshTokenTTL,
+		RevokeOnPhoneChange:  cfg.RevokeOnPhoneChange,
+	})
+	creatorHandler := handlers.NewCreatorHandler(db, handlers.CreatorOptions{
+		MinPayoutAmount: cfg.MinPayoutAmount,
+		S3Bucket:        cfg.S3Bucket,
+		ImageHosts:      cfg.ThumbnailHosts,
+	})
+	contentHandler := handlers.NewContentHandler(db, s3Client, cfg.MockUploads, cfg.CDNBaseURL, cdnSigner, cfg.ThumbnailHosts, handlers.TrendingOptions{
+		Window:   cfg.TrendingWindow,
+		CacheTTL: cfg.TrendingCacheTTL,
+	}, handlers.UploadLimits{
+		MaxBytes:     cfg.UploadMaxBytes,
+		ContentTypes: cfg.UploadContentTypes,
+	}, seriesCache)
+	paymentHandler := handlers.NewPaymentHandler(db, cfg.RazorpayWebhookSecret, handlers.PaymentOptions{
+		SubscriptionPeriod: cfg.SubscriptionPeriod,
+		CommissionRate:     cfg.CommissionRate,
+	})
+	paymentHandler.StartWebhookRetries(context.Background())
 	socialHandler := handlers.NewSocialHandler(db)
-	adminHandler := handlers.NewAdminHandler()
+	adminHandler := handlers.NewAdminHandler(db)
+	userHandler := handlers.NewUserHandler(db)
+	transcodingHandler := handlers.NewTranscodingHandler(db, cfg.TranscoderSecret)
+	healthHandler := handlers.NewHealthHandler(db, config.MigratedModels(), healthChecks...)
+
+	// Periodically expire lapsed subscriptions, prune old OTP transactions and
+	// purge series deleted longer ago than their restore window
+	sweeper.Start(context.Background(), db, sweeper.Options{
+		Interval:                cfg.SweepInterval,
+		OTPRetention:            cfg.OTPRetention,
+		DeletedAccountRetention: cfg.AccountRetention,
+		DeletedSeriesRetention:  handlers.SeriesRestoreWindow,
+		CleanupEpisodeStorage:   contentHandler.CleanupEpisodeStorage,
+	})
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware()
+	authMiddleware := middleware.NewAuthMiddleware(jwtSecret, db)
 
 	// Create router
 	r := mux.NewRouter()
+	r.Use(middleware.Metrics)
+	r.Use(middleware.QueryTimeout(cfg.DBQueryTimeout))
+	// Signed webhooks and health/metrics probes are exempt from the per-IP limit
+	r.Use(middleware.Exempt(publicLimiter.LimitByIP,
+		"/health", "/ready", "/metrics", "/payments/webhook", "/transcoding/webhook"))
 
 	// Public routes
 	r.HandleFunc("/", helloHandler).Methods("GET")
-	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(Response{Message: "Server is running!"})
-	}).Methods("GET")
+	r.HandleFunc("/health", healthHandler.Health).Methods("GET")
+	r.HandleFunc("/ready", healthHandler.Ready).Methods("GET")
+
+	// Prometheus metrics, optionally on their own listener so they stay off the public port
+	metrics.Register(prometheus.DefaultRegisterer)
+	if cfg.MetricsAddr != "" {
+		go func() {
+			metricsMux := http.NewServeMux()
+			metricsMux.Handle("/metrics", promhttp.Handler())
+			log.Printf("Serving metrics on %s/metrics", cfg.MetricsAddr)
+			if err := http.ListenAndServe(cfg.MetricsAddr, metricsMux); err != nil {
+				log.Printf("Metrics listener stopped: %v", err)
+			}
+		}()
+	} else {
+		r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	}
 
 	// Public content routes (no authentication required)
 	r.HandleFunc("/content/series", contentHandler.ListSeries).Methods("GET")
-	r.HandleFunc("/content/series/{id}", contentHandler.GetSeries).Methods("GET")
-	r.HandleFunc("/content/series/{seriesId}/episodes", contentHandler.GetEpisodes).Methods("GET")
+	r.HandleFunc("/content/categories", contentHandler.ListCategories).Methods("GET")
+	r.HandleFunc("/content/languages", contentHandler.ListLanguages).Methods("GET")
+	r.HandleFunc("/content/trending", contentHandler.GetTrending).Methods("GET")
+	r.HandleFunc("/content/creators", creatorHandler.ListCreators).Methods("GET")
+	r.Handle("/content/series/{id}", authMiddleware.OptionalAuth(http.HandlerFunc(contentHandler.GetSeries))).Methods("GET")
+	r.Handle("/content/series/{seriesId}/episodes", authMiddleware.OptionalAuth(http.HandlerFunc(contentHandler.GetEpisodes))).Methods("GET")
+	r.Handle("/episodes/{id}", authMiddleware.OptionalAuth(http.HandlerFunc(contentHandler.GetEpisode))).Methods("GET")
+	r.HandleFunc("/episodes/{id}/comments", socialHandler.GetEpisodeComments).Methods("GET")
+	r.HandleFunc("/creators/{id}", creatorHandler.GetPublicProfile).Methods("GET")
 
 	// Public payment webhook (no authentication required)
 	r.HandleFunc("/payments/webhook", paymentHandler.Webhook).Methods("POST")
 
+	// Transcoder callbacks (authenticated by shared-secret signature)
+	r.HandleFunc("/transcoding/webhook", transcodingHandler.Webhook).Methods("POST")
+
 	// Auth routes (matching OpenAPI schema)
-	r.HandleFunc("/auth/otp/send", authHandler.SendOTP).Methods("POST")
-	r.HandleFunc("/auth/otp/verify", authHandler.VerifyOTP).Methods("POST")
+	r.Handle("/auth/otp/send", authLimiter.LimitByIP(http.HandlerFunc(authHandler.SendOTP))).Methods("POST")
+	r.Handle("/auth/otp/verify", authLimiter.LimitByIP(http.HandlerFunc(authHandler.VerifyOTP))).Methods("POST")
 	r.HandleFunc("/auth/refresh", authHandler.RefreshToken).Methods("POST")
 
 	// Protected routes (example)
 	protected := r.PathPrefix("/api").Subrouter()
 	protected.Use(authMiddleware.AuthMiddleware)
+	protected.Use(apiLimiter.LimitByUser)
 	protected.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
 		userID := r.Context().Value("user_id")
 		phone := r.Context().Value("phone")
@@ -92,48 +257,113 @@ func main() {
 		json.NewEncoder(w).Encode(response)
 	}).Methods("GET")
 
+	// Session management (protected)
+	protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST")
+	protected.HandleFunc("/auth/sessions", authHandler.GetSessions).Methods("GET")
+
 	// Creator routes (protected)
 	protected.HandleFunc("/creators/profile", creatorHandler.GetCreatorProfile).Methods("GET")
 	protected.HandleFunc("/creators/profile", creatorHandler.UpdateCreatorProfile).Methods("PUT")
 	protected.HandleFunc("/creators/onboard", creatorHandler.OnboardCreator).Methods("POST")
 	protected.HandleFunc("/creators/{id}/dashboard", creatorHandler.GetCreatorDashboard).Methods("GET")
 	protected.HandleFunc("/creators/content", contentHandler.GetCreatorContent).Methods("GET")
+	protected.HandleFunc("/creators/episodes/{id}/analytics", creatorHandler.GetEpisodeAnalytics).Methods("GET")
+	protected.HandleFunc("/creators/payout-details", creatorHandler.UpdatePayoutDetails).Methods("PUT")
+	protected.HandleFunc("/creators/payouts", creatorHandler.RequestPayout).Methods("POST")
+	protected.HandleFunc("/creators/payouts", creatorHandler.GetPayouts).Methods("GET")
 
 	// Content routes (protected - creators only)
 	protected.HandleFunc("/content/series", contentHandler.CreateSeries).Methods("POST")
 	protected.HandleFunc("/content/series/{id}", contentHandler.UpdateSeries).Methods("PUT")
+	protected.HandleFunc("/content/series/{id}", contentHandler.DeleteSeries).Methods("DELETE")
+	protected.HandleFunc("/content/series/{id}/restore", contentHandler.RestoreSeries).Methods("POST")
 	protected.HandleFunc("/content/series/{id}/episodes", contentHandler.CreateEpisode).Methods("POST")
 	protected.HandleFunc("/content/upload-url", contentHandler.RequestUploadURL).Methods("POST")
 	protected.HandleFunc("/content/uploads/{upload_id}/notify", contentHandler.NotifyUploadComplete).Methods("POST")
+	protected.HandleFunc("/transcoding/jobs/{id}", contentHandler.GetTranscodingJob).Methods("GET")
 	protected.HandleFunc("/episodes/{id}/manifest", contentHandler.GetEpisodeManifest).Methods("GET")
+	protected.HandleFunc("/episodes/{id}/status", contentHandler.GetEpisodeStatus).Methods("GET")
+	protected.HandleFunc("/episodes/{id}/playback-token", contentHandler.CreatePlaybackToken).Methods("POST")
+	protected.HandleFunc("/episodes/{id}/captions", contentHandler.AddCaptions).Methods("POST")
+	protected.HandleFunc("/episodes/{id}/thumbnail", contentHandler.SetEpisodeThumbnail).Methods("PUT")
 	protected.HandleFunc("/content/episodes/{id}/status", contentHandler.UpdateEpisodeStatus).Methods("PUT")
 	protected.HandleFunc("/content/episodes/{id}", contentHandler.UpdateEpisode).Methods("PUT")
 	protected.HandleFunc("/content/episodes/{id}", contentHandler.DeleteEpisode).Methods("DELETE")
 	protected.HandleFunc("/content/series/{id}/status", contentHandler.UpdateSeriesStatus).Methods("PUT")
+	protected.HandleFunc("/content/series/{id}/episodes/reorder", contentHandler.ReorderEpisodes).Methods("PUT")
+	protected.HandleFunc("/content/series/{id}/publish-episodes", contentHandler.PublishEpisodes).Methods("POST")
 
 	// Payment routes (protected)
 	protected.HandleFunc("/payments/create-subscription", paymentHandler.CreateSubscription).Methods("POST")
+	protected.HandleFunc("/payments/purchase", paymentHandler.CreatePurchase).Methods("POST")
+	protected.HandleFunc("/subscriptions/{id}/cancel", paymentHandler.CancelSubscription).Methods("POST")
 
 	// Social/Engagement routes (protected)
 	protected.HandleFunc("/episodes/{id}/like", socialHandler.LikeEpisode).Methods("POST")
 	protected.HandleFunc("/episodes/{id}/rating", socialHandler.RateEpisode).Methods("POST")
 	protected.HandleFunc("/episodes/{id}/comments", socialHandler.CommentEpisode).Methods("POST")
+	protected.HandleFunc("/episodes/{id}/comments/{commentId}", socialHandler.EditComment).Methods("PUT")
+	protected.HandleFunc("/episodes/{id}/comments/{commentId}", socialHandler.DeleteComment).Methods("DELETE")
+	protected.HandleFunc("/episodes/{id}/progress", socialHandler.RecordProgress).Methods("POST")
+	protected.HandleFunc("/episodes/{id}/view", socialHandler.RecordView).Methods("POST")
+	protected.HandleFunc("/episodes/{id}/heartbeat", socialHandler.RecordHeartbeat).Methods("POST")
+	protected.Handle("/episodes/{id}/report", reportLimiter.LimitByUser(http.HandlerFunc(socialHandler.ReportEpisode))).Methods("POST")
+	protected.Handle("/comments/{id}/report", reportLimiter.LimitByUser(http.HandlerFunc(socialHandler.ReportComment))).Methods("POST")
+
+	// User routes (protected)
+	protected.HandleFunc("/users/me", userHandler.GetMe).Methods("GET")
+	protected.HandleFunc("/users/me", authHandler.DeleteAccount).Methods("DELETE")
+	protected.Handle("/users/me/phone/change", authLimiter.LimitByUser(http.HandlerFunc(authHandler.ChangePhone))).Methods("POST")
+	protected.Handle("/users/me/phone/verify", authLimiter.LimitByUser(http.HandlerFunc(authHandler.VerifyPhoneChange))).Methods("POST")
+	protected.Handle("/users/me/export", exportLimiter.LimitByUser(http.HandlerFunc(userHandler.ExportData))).Methods("GET")
+	protected.HandleFunc("/users/me/continue-watching", userHandler.GetContinueWatching).Methods("GET")
+	protected.HandleFunc("/users/me/subscriptions", paymentHandler.GetUserSubscriptions).Methods("GET")
+	protected.HandleFunc("/users/me/favorites", userHandler.GetFavorites).Methods("GET")
+	protected.HandleFunc("/users/me/following", userHandler.GetFollowing).Methods("GET")
+	protected.HandleFunc("/users/me/preferences", userHandler.GetPreferences).Methods("GET")
+	protected.HandleFunc("/users/me/preferences", userHandler.UpdatePreferences).Methods("PUT")
+	protected.HandleFunc("/users/me/notifications", userHandler.GetNotifications).Methods("GET")
+	protected.HandleFunc("/users/me/notifications/{id}/read", userHandler.MarkNotificationRead).Methods("POST")
+	protected.HandleFunc("/series/{id}/favorite", userHandler.AddFavorite).Methods("POST")
+	protected.HandleFunc("/series/{id}/favorite", userHandler.RemoveFavorite).Methods("DELETE")
+	protected.HandleFunc("/creators/{id}/follow", userHandler.FollowCreator).Methods("POST")
+	protected.HandleFunc("/creators/{id}/follow", userHandler.UnfollowCreator).Methods("DELETE")
 
 	// Admin routes (protected - admin only)
-	protected.HandleFunc("/admin/uploads/pending", adminHandler.GetPendingUploads).Methods("GET")
-	protected.HandleFunc("/admin/approve-content", adminHandler.ApproveContent).Methods("POST")
-
-	// CORS configuration
-	c := cors.New(cors.Options{
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"*"},
-		AllowOriginFunc: func(origin string) bool {
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(authMiddleware.RequireRole(models.RoleAdmin))
+	admin.HandleFunc("/uploads/pending", adminHandler.GetPendingUploads).Methods("GET")
+	admin.HandleFunc("/approve-content", adminHandler.ApproveContent).Methods("POST")
+	admin.HandleFunc("/kyc/pending", adminHandler.GetPendingKYC).Methods("GET")
+	admin.HandleFunc("/creators/{id}/kyc", adminHandler.ReviewCreatorKYC).Methods("POST")
+	admin.HandleFunc("/creators/{id}/payout-details/verify", adminHandler.VerifyPayoutDetails).Methods("POST")
+	admin.HandleFunc("/reports", adminHandler.GetReports).Methods("GET")
+	admin.HandleFunc("/reports/{id}/resolve", adminHandler.ResolveReport).Methods("POST")
+	admin.HandleFunc("/cache/trending", contentHandler.InvalidateTrending).Methods("DELETE")
+	admin.HandleFunc("/orphaned-objects", adminHandler.GetOrphanedObjects).Methods("GET")
+
+	// CORS configuration: explicit origins may send credentials and get their
+	// origin echoed back; otherwise development allows any origin and other
+	// environments only local frontends
+	corsOptions := cors.Options{
+		AllowedMethods: cfg.CORSAllowedMethods,
+		AllowedHeaders: cfg.CORSAllowedHeaders,
+	}
+	switch {
+	case len(cfg.CORSAllowedOrigins) > 0:
+		corsOptions.AllowedOrigins = cfg.CORSAllowedOrigins
+		corsOptions.AllowCredentials = true
+	case cfg.IsDevelopment():
+		corsOptions.AllowedOrigins = []string{"*"}
+	default:
+		corsOptions.AllowOriginFunc = func(origin string) bool {
 			return strings.HasPrefix(origin, "http://localhost:") ||
 				strings.HasPrefix(origin, "https://localhost:") ||
 				strings.HasPrefix(origin, "http://127.0.0.1:") ||
 				strings.HasPrefix(origin, "https://127.0.0.1:")
-		},
-	})
+		}
+	}
+	c := cors.New(corsOptions)
 
 	// Apply CORS middleware
 	handler := c.Handler(r)
@@ -147,36 +377,96 @@ func main() {
 	log.Printf("Server starting on port %s...", port)
 	log.Println("Available endpoints:")
 	log.Println("  GET  /                    - Hello World")
-	log.Println("  GET  /health              - Health check")
+	log.Println("  GET  /health              - Health check (database, Redis)")
+	log.Println("  GET  /ready               - Readiness check (dependencies and migrations)")
+	if cfg.MetricsAddr == "" {
+		log.Println("  GET  /metrics             - Prometheus metrics")
+	}
 	log.Println("  POST /auth/otp/send       - Send OTP")
 	log.Println("  POST /auth/otp/verify     - Verify OTP")
 	log.Println("  POST /auth/refresh        - Refresh token")
+	log.Println("  POST /api/auth/logout     - Revoke a session or all sessions (requires auth)")
+	log.Println("  GET  /api/auth/sessions   - List active sessions (requires auth)")
 	log.Println("  GET  /api/profile         - Protected profile (requires auth)")
 	log.Println("  POST /api/creators/onboard     - Creator onboarding (requires auth)")
 	log.Println("  GET  /api/creators/profile      - Get creator profile (requires auth)")
 	log.Println("  PUT  /api/creators/profile      - Update creator profile (requires auth)")
 	log.Println("  GET  /api/creators/{id}/dashboard - Creator dashboard (requires auth)")
 	log.Println("  GET  /api/creators/content - Get creator content (requires auth)")
+	log.Println("  GET  /api/creators/episodes/{id}/analytics - Per-episode analytics (creators only)")
+	log.Println("  PUT  /api/creators/payout-details - Save payout bank details (requires auth)")
+	log.Println("  POST /api/creators/payouts      - Request a payout (requires auth)")
+	log.Println("  GET  /api/creators/payouts      - List payouts (requires auth)")
 	log.Println("  POST /api/content/series        - Create series (creators only)")
 	log.Println("  PUT  /api/content/series/{id}   - Update series (creators only)")
+	log.Println("  DELETE /api/content/series/{id} - Delete series and its episodes (creators only)")
+	log.Println("  POST /api/content/series/{id}/restore - Restore a deleted series (creators only)")
 	log.Println("  POST /api/content/series/{id}/episodes - Create episode (creators only)")
 	log.Println("  POST /api/content/upload-url    - Request upload URL (creators only)")
 	log.Println("  POST /api/content/uploads/{id}/notify - Notify upload complete (creators only)")
+	log.Println("  GET  /api/transcoding/jobs/{id} - Get transcoding job status (creators only)")
 	log.Println("  GET  /api/episodes/{id}/manifest - Get episode manifest (requires auth)")
+	log.Println("  GET  /api/episodes/{id}/status  - Poll episode and transcoding status (creators only)")
+	log.Println("  POST /api/episodes/{id}/playback-token - Issue or renew a short-lived playback token (requires auth)")
+	log.Println("  POST /api/episodes/{id}/captions - Attach or upload captions (creators only)")
+	log.Println("  PUT  /api/episodes/{id}/thumbnail - Set or upload an episode thumbnail (creators only)")
 	log.Println("  PUT  /api/content/episodes/{id}/status - Update episode status (creators only)")
 	log.Println("  PUT  /api/content/episodes/{id}   - Update episode (creators only)")
 	log.Println("  DELETE /api/content/episodes/{id} - Delete episode (creators only)")
 	log.Println("  PUT  /api/content/series/{id}/status - Update series status (creators only)")
+	log.Println("  PUT  /api/content/series/{id}/episodes/reorder - Reorder series episodes (creators only)")
+	log.Println("  POST /api/content/series/{id}/publish-episodes - Publish all ready episodes (creators only)")
 	log.Println("  POST /api/payments/create-subscription - Create subscription (requires auth)")
+	log.Println("  POST /api/payments/purchase - Buy a one-time purchase series (requires auth)")
+	log.Println("  POST /api/subscriptions/{id}/cancel - Cancel subscription at period end (requires auth)")
 	log.Println("  POST /api/episodes/{id}/like    - Like/unlike episode (requires auth)")
 	log.Println("  POST /api/episodes/{id}/rating  - Rate episode (requires auth)")
 	log.Println("  POST /api/episodes/{id}/comments - Comment on episode (requires auth)")
-	log.Println("  GET  /api/admin/uploads/pending - List pending uploads (admin only)")
+	log.Println("  PUT  /api/episodes/{id}/comments/{commentId} - Edit own comment (requires auth)")
+	log.Println("  DELETE /api/episodes/{id}/comments/{commentId} - Delete own comment (requires auth)")
+	log.Println("  POST /api/episodes/{id}/progress - Save watch progress (requires auth)")
+	log.Println("  POST /api/episodes/{id}/view    - Record an episode view (requires auth)")
+	log.Println("  POST /api/episodes/{id}/heartbeat - Record playback watch time (requires auth)")
+	log.Println("  POST /api/episodes/{id}/report  - Report an episode for moderation (requires auth)")
+	log.Println("  POST /api/comments/{id}/report  - Report a comment for moderation (requires auth)")
+	log.Println("  GET  /api/users/me - My account, creator status and active subscriptions (requires auth)")
+	log.Println("  DELETE /api/users/me - Delete my account, confirmed with a fresh OTP (requires auth)")
+	log.Println("  POST /api/users/me/phone/change - Send a code to a new phone number (requires auth, rate limited)")
+	log.Println("  POST /api/users/me/phone/verify - Switch to the new phone number with its code (requires auth, rate limited)")
+	log.Println("  GET  /api/users/me/export - Download my data as JSON (requires auth, rate limited)")
+	log.Println("  GET  /api/users/me/continue-watching - Continue watching list (requires auth)")
+	log.Println("  GET  /api/users/me/subscriptions - List my subscriptions (requires auth)")
+	log.Println("  GET  /api/users/me/favorites - List favorite series (requires auth)")
+	log.Println("  GET  /api/users/me/following - List followed creators (requires auth)")
+	log.Println("  GET  /api/users/me/preferences - Get playback and notification preferences (requires auth)")
+	log.Println("  PUT  /api/users/me/preferences - Update playback and notification preferences (requires auth)")
+	log.Println("  GET  /api/users/me/notifications - List my notifications (requires auth)")
+	log.Println("  POST /api/users/me/notifications/{id}/read - Mark a notification as read (requires auth)")
+	log.Println("  POST /api/series/{id}/favorite - Add series to favorites (requires auth)")
+	log.Println("  DELETE /api/series/{id}/favorite - Remove series from favorites (requires auth)")
+	log.Println("  POST /api/creators/{id}/follow - Follow a creator (requires auth)")
+	log.Println("  DELETE /api/creators/{id}/follow - Unfollow a creator (requires auth)")
+	log.Println("  GET  /api/admin/uploads/pending - List uploads by status (admin only)")
 	log.Println("  POST /api/admin/approve-content - Approve/reject content (admin only)")
+	log.Println("  GET  /api/admin/kyc/pending     - List creators awaiting KYC review (admin only)")
+	log.Println("  POST /api/admin/creators/{id}/kyc - Approve/reject a creator's KYC (admin only)")
+	log.Println("  POST /api/admin/creators/{id}/payout-details/verify - Confirm a creator's payout bank account (admin only)")
+	log.Println("  GET  /api/admin/reports         - List content reports by status (admin only)")
+	log.Println("  POST /api/admin/reports/{id}/resolve - Resolve or dismiss a content report (admin only)")
+	log.Println("  DELETE /api/admin/cache/trending - Clear the cached trending ranking (admin only)")
+	log.Println("  GET  /api/admin/orphaned-objects - List storage left by failed episode cleanups (admin only)")
 	log.Println("  GET  /content/series            - List series (public)")
+	log.Println("  GET  /content/categories        - List categories with series counts (public)")
+	log.Println("  GET  /content/languages         - List supported content languages (public)")
+	log.Println("  GET  /content/trending          - Trending series by recent activity (public)")
+	log.Println("  GET  /content/creators          - Verified creator directory (public)")
 	log.Println("  GET  /content/series/{id}       - Get series details (public)")
-	log.Println("  GET  /content/series/{seriesId}/episodes - Get episodes for series (public)")
+	log.Println("  GET  /content/series/{seriesId}/episodes - Get episodes for series (public; owners also see unpublished)")
+	log.Println("  GET  /episodes/{id}             - Get episode details (public; owners see drafts)")
+	log.Println("  GET  /episodes/{id}/comments    - List episode comments (public)")
+	log.Println("  GET  /creators/{id}             - Public creator profile and series (public)")
 	log.Println("  POST /payments/webhook          - Payment webhook (public)")
+	log.Println("  POST /transcoding/webhook       - Transcoder progress callback (signed)")
 
 	// Bind to all interfaces (0.0.0.0) for deployment compatibility
 	addr := "0.0.0.0:" + port
@@ -2,18 +2,28 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"streamshort/handlers"
+	"streamshort/models"
+	"streamshort/pkg/httputil"
 
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-type AuthMiddleware struct{}
+type AuthMiddleware struct {
+	jwtSecret []byte
+	db        *gorm.DB
+}
 
-func NewAuthMiddleware() *AuthMiddleware {
-	return &AuthMiddleware{}
+// NewAuthMiddleware verifies access tokens signed with jwtSecret. db is used
+// to turn away tokens of accounts deleted since the token was issued.
+func NewAuthMiddleware(jwtSecret string, db *gorm.DB) *AuthMiddleware {
+	return &AuthMiddleware{jwtSecret: []byte(jwtSecret), db: db}
 }
 
 func (m *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
@@ -21,13 +31,13 @@ func (m *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 		// Get Authorization header
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Authorization header required")
 			return
 		}
 
 		// Check if it's a Bearer token
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid authorization header format")
 			return
 		}
 
@@ -35,25 +45,113 @@ func (m *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &handlers.Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(handlers.GetJWTSecret()), nil
-		})
-
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+		claims, err := m.parseToken(tokenString)
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			// Distinct code so clients know to refresh rather than sign in again
+			httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeTokenExpired, "Token expired")
+			return
+		}
+		if errors.Is(err, errMissingUserID) {
+			httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid token claims")
+			return
+		}
+		if err != nil {
+			httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid token")
 			return
 		}
 
-		// Extract claims
-		claims, ok := token.Claims.(*handlers.Claims)
-		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+		// Access tokens outlive account deletion, so the account is checked on every request
+		active, err := m.userActive(r.Context(), claims.UserID)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		if !active {
+			httputil.WriteError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Account has been deleted")
 			return
 		}
 
 		// Add user info to request context
 		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
 		ctx = context.WithValue(ctx, "phone", claims.Phone)
+		ctx = context.WithValue(ctx, "role", claims.Role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// OptionalAuth adds the user to the request context when a valid bearer token is
+// sent, and otherwise lets the request through anonymously. Public routes use it
+// to personalise responses for signed-in users.
+func (m *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := m.parseToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if active, err := m.userActive(r.Context(), claims.UserID); err != nil || !active {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "phone", claims.Phone)
+		ctx = context.WithValue(ctx, "role", claims.Role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+var errMissingUserID = errors.New("token has no user_id claim")
+
+// userActive reports whether the user exists and has not been deleted
+func (m *AuthMiddleware) userActive(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	if err := m.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// parseToken verifies an access token's HMAC signature and expiry and returns
+// its claims. Tokens signed with any other algorithm, including "none", are
+// rejected before the key is used.
+func (m *AuthMiddleware) parseToken(tokenString string) (*handlers.Claims, error) {
+	claims := &handlers.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return m.jwtSecret, nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if claims.UserID == "" {
+		return nil, errMissingUserID
+	}
+	return claims, nil
+}
+
+// RequireRole rejects requests whose token does not carry the given role.
+// It must run after AuthMiddleware, which places the role in the request context.
+func (m *AuthMiddleware) RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userRole, _ := r.Context().Value("role").(string)
+			if userRole != role {
+				httputil.WriteError(w, http.StatusForbidden, httputil.CodeForbidden, "Insufficient privileges")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
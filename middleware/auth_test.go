@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streamshort/handlers"
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const testSecret = "test-secret-at-least-32-bytes-long!!"
+
+func signHS256(t *testing.T, claims handlers.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// rawToken builds a token with an arbitrary alg header, signed with HMAC-SHA256
+// over the secret when sign is set, as an algorithm confusion attack would
+func rawToken(t *testing.T, alg string, claims handlers.Claims, sign bool) string {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	if !sign {
+		return unsigned + "."
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseToken(t *testing.T) {
+	m := NewAuthMiddleware(testSecret, nil)
+	valid := handlers.Claims{
+		UserID: "user-1",
+		Phone:  "+919876543210",
+		Role:   "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+	noUser := valid
+	noUser.UserID = ""
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", signHS256(t, valid), nil},
+		{"alg none", rawToken(t, "none", valid, false), jwt.ErrTokenUnverifiable},
+		{"RS256 signed with the HMAC secret", rawToken(t, "RS256", valid, true), jwt.ErrTokenUnverifiable},
+		{"expired", signHS256(t, expired), jwt.ErrTokenExpired},
+		{"no exp", signHS256(t, noExpiry), jwt.ErrTokenRequiredClaimMissing},
+		{"empty user_id", signHS256(t, noUser), errMissingUserID},
+		{"wrong secret", func() string {
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte("another-secret"))
+			return token
+		}(), jwt.ErrTokenSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := m.parseToken(tt.token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("parseToken: %v", err)
+				}
+				if claims.UserID != "user-1" || claims.Role != "user" {
+					t.Errorf("claims = %+v", claims)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("token was accepted with claims %+v", claims)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeletedAccountTokenIsRejected(t *testing.T) {
+	db := testdb.Open(t)
+	user := models.User{Phone: "+919800000074"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	otp := models.OTPTransaction{TxnID: uuid.New().String(), Phone: user.Phone, OTP: "123456", ExpiresAt: time.Now().Add(5 * time.Minute)}
+	if err := db.Create(&otp).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewAuthMiddleware(testSecret, db)
+	authHandler := handlers.NewAuthHandler(db, testSecret, nil, handlers.AuthOptions{})
+	token := signHS256(t, handlers.Claims{
+		UserID:           user.ID,
+		Phone:            user.Phone,
+		Role:             models.RoleUser,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	protected := m.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	call := func(handler http.Handler, method, body string) int {
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, "/api/users/me", reader)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call(protected, http.MethodGet, ""); code != http.StatusOK {
+		t.Fatalf("protected route before deletion = %d, want 200", code)
+	}
+	deleteAccount := m.AuthMiddleware(http.HandlerFunc(authHandler.DeleteAccount))
+	if code := call(deleteAccount, http.MethodDelete, `{"otp":"123456","txn_id":"`+otp.TxnID+`"}`); code != http.StatusOK {
+		t.Fatalf("DeleteAccount = %d, want 200", code)
+	}
+	if code := call(protected, http.MethodGet, ""); code != http.StatusUnauthorized {
+		t.Errorf("protected route after deletion = %d, want 401", code)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"streamshort/pkg/ratelimit"
+)
+
+func TestExempt(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), "public", ratelimit.Limit{PerMinute: 1, Burst: 1}, 0)
+	handler := Exempt(limiter.LimitByIP, "/health", "/payments/webhook")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve("/content/series"); code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", code)
+	}
+	if code := serve("/content/series"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", code)
+	}
+	for i := 0; i < 5; i++ {
+		for _, path := range []string{"/health", "/payments/webhook"} {
+			if code := serve(path); code != http.StatusOK {
+				t.Fatalf("exempt %s = %d, want 200", path, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"streamshort/pkg/httputil"
+)
+
+// timeoutWriter replaces a handler's 5xx response with a 504 once the request
+// deadline has passed, since the handler only sees a failed query
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+func (t *timeoutWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && errors.Is(t.ctx.Err(), context.DeadlineExceeded) {
+		t.timedOut = true
+		httputil.WriteError(t.ResponseWriter, http.StatusGatewayTimeout, httputil.CodeTimeout, "The request took too long to complete")
+		return
+	}
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *timeoutWriter) Write(b []byte) (int, error) {
+	if t.timedOut {
+		// Drop the handler's own error body; the 504 has already been written
+		return len(b), nil
+	}
+	return t.ResponseWriter.Write(b)
+}
+
+// QueryTimeout bounds each request's context by timeout. Handlers pass the
+// request context to GORM, so Postgres cancels queries still running at the
+// deadline or when the client disconnects, and the request fails with 504
+// instead of holding a connection.
+func QueryTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(&timeoutWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+		})
+	}
+}
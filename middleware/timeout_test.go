@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streamshort/pkg/httputil"
+	"streamshort/pkg/testdb"
+)
+
+func TestQueryTimeoutCancelsSlowQuery(t *testing.T) {
+	db := testdb.Shared(t)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := db.WithContext(r.Context()).Exec("SELECT pg_sleep(5)").Error; err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	started := time.Now()
+	QueryTimeout(100*time.Millisecond)(slow).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("request took %v; the query was not cancelled at the deadline", elapsed)
+	}
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504: %s", rec.Code, rec.Body)
+	}
+	var body httputil.ErrorResponse
+	dec := json.NewDecoder(rec.Body)
+	if err := dec.Decode(&body); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+	if body.Error.Code != httputil.CodeTimeout || body.Error.Message == "" {
+		t.Errorf("error = %+v, want the timeout error", body.Error)
+	}
+	if dec.More() {
+		t.Error("the handler's own error body was written after the 504")
+	}
+}
+
+func TestQueryTimeoutKeepsErrorsBeforeDeadline(t *testing.T) {
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httputil.WriteError(w, http.StatusInternalServerError, httputil.CodeInternal, "Database error")
+	})
+
+	rec := httptest.NewRecorder()
+	QueryTimeout(time.Minute)(failing).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want the handler's 500", rec.Code)
+	}
+	var body httputil.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != httputil.CodeInternal {
+		t.Errorf("code = %q, want %q", body.Error.Code, httputil.CodeInternal)
+	}
+}
@@ -0,0 +1,64 @@
+-- Migration: 008_engagement_tables.sql
+-- Description: Engagement and watch-progress tables with their indexes
+-- Created: 2026-10-16
+-- Generated from Go models (cmd/generate)
+
+-- Create episode_likes table
+CREATE TABLE IF NOT EXISTS episode_likes (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    episode_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_episode_like_episode_user ON episode_likes (episode_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_episode_likes_deleted_at ON episode_likes (deleted_at);
+
+-- Create episode_ratings table
+CREATE TABLE IF NOT EXISTS episode_ratings (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    episode_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    score INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_episode_rating_episode_user ON episode_ratings (episode_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_episode_ratings_deleted_at ON episode_ratings (deleted_at);
+
+-- Create episode_comments table
+CREATE TABLE IF NOT EXISTS episode_comments (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    episode_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    text text NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_episode_comments_episode_id ON episode_comments (episode_id);
+CREATE INDEX IF NOT EXISTS idx_episode_comments_user_id ON episode_comments (user_id);
+CREATE INDEX IF NOT EXISTS idx_episode_comments_deleted_at ON episode_comments (deleted_at);
+
+-- Create watch_progress table
+CREATE TABLE IF NOT EXISTS watch_progress (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL,
+    episode_id uuid NOT NULL,
+    position_seconds INTEGER NOT NULL DEFAULT 0,
+    completed BOOLEAN DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watch_progress_user_episode ON watch_progress (user_id, episode_id);
+CREATE INDEX IF NOT EXISTS idx_watch_progress_updated_at ON watch_progress (updated_at);
+CREATE INDEX IF NOT EXISTS idx_watch_progress_deleted_at ON watch_progress (deleted_at);
+
+-- Migration completed successfully
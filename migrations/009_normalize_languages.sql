@@ -0,0 +1,43 @@
+-- Migration: 009_normalize_languages.sql
+-- Description: Normalize free-form series and preference languages to supported lowercase codes
+-- Created: 2026-10-16
+
+-- Language names and three-letter codes map onto the supported ISO 639-1 codes.
+-- Values that still don't match a supported code are left for manual review:
+--   SELECT language, COUNT(*) FROM series
+--   WHERE language NOT IN ('en','hi','bn','ta','te','mr','gu','kn','ml','pa','or','ur') GROUP BY language;
+CREATE TEMPORARY TABLE language_aliases (alias TEXT PRIMARY KEY, code TEXT NOT NULL);
+INSERT INTO language_aliases (alias, code) VALUES
+    ('en', 'en'), ('eng', 'en'), ('english', 'en'), ('en-in', 'en'), ('en-us', 'en'), ('en-gb', 'en'),
+    ('hi', 'hi'), ('hin', 'hi'), ('hindi', 'hi'), ('hi-in', 'hi'),
+    ('bn', 'bn'), ('ben', 'bn'), ('bengali', 'bn'), ('bangla', 'bn'),
+    ('ta', 'ta'), ('tam', 'ta'), ('tamil', 'ta'),
+    ('te', 'te'), ('tel', 'te'), ('telugu', 'te'),
+    ('mr', 'mr'), ('mar', 'mr'), ('marathi', 'mr'),
+    ('gu', 'gu'), ('guj', 'gu'), ('gujarati', 'gu'),
+    ('kn', 'kn'), ('kan', 'kn'), ('kannada', 'kn'),
+    ('ml', 'ml'), ('mal', 'ml'), ('malayalam', 'ml'),
+    ('pa', 'pa'), ('pan', 'pa'), ('punjabi', 'pa'),
+    ('or', 'or'), ('ori', 'or'), ('ory', 'or'), ('odia', 'or'), ('oriya', 'or'),
+    ('ur', 'ur'), ('urd', 'ur'), ('urdu', 'ur');
+
+-- The tables may have been created by AutoMigrate rather than these migrations
+DO $$
+BEGIN
+    IF to_regclass('series') IS NOT NULL THEN
+        UPDATE series SET language = language_aliases.code
+        FROM language_aliases
+        WHERE language_aliases.alias = LOWER(TRIM(series.language))
+          AND series.language <> language_aliases.code;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'user_preferences' AND column_name = 'language') THEN
+        UPDATE user_preferences SET language = language_aliases.code
+        FROM language_aliases
+        WHERE language_aliases.alias = LOWER(TRIM(user_preferences.language))
+          AND user_preferences.language <> language_aliases.code;
+    END IF;
+END $$;
+
+DROP TABLE language_aliases;
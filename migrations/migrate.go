@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -19,12 +20,19 @@ type Migration struct {
 	AppliedAt time.Time
 }
 
+// migrationFilePattern matches versioned migration files such as
+// "001_create_users_table.sql" or "20261016120000_add_index.sql", capturing
+// the numeric version prefix
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_.+\.sql$`)
+
 type MigrationRunner struct {
-	db *sql.DB
+	db  *sql.DB
+	dir string
 }
 
-func NewMigrationRunner(db *sql.DB) *MigrationRunner {
-	return &MigrationRunner{db: db}
+// NewMigrationRunner creates a runner that applies the migration files in dir
+func NewMigrationRunner(db *sql.DB, dir string) *MigrationRunner {
+	return &MigrationRunner{db: db, dir: dir}
 }
 
 // RunMigrations executes all pending migrations
@@ -97,30 +105,31 @@ func (mr *MigrationRunner) getAppliedMigrations() (map[string]Migration, error)
 }
 
 func (mr *MigrationRunner) getMigrationFiles() ([]Migration, error) {
-	// Get current directory
-	dir, err := os.Getwd()
+	info, err := os.Stat(mr.dir)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("migrations directory %s does not exist", mr.dir)
+		}
 		return nil, err
 	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("migrations path %s is not a directory", mr.dir)
+	}
 
-	// Look for migration files in migrations directory
-	migrationsDir := filepath.Join(dir, "migrations")
-	files, err := os.ReadDir(migrationsDir)
+	files, err := os.ReadDir(mr.dir)
 	if err != nil {
 		return nil, err
 	}
 
 	var migrations []Migration
 	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+		// Skip non-migration files such as migrate.sql and rollback.sql
+		if file.IsDir() || !migrationFilePattern.MatchString(file.Name()) {
 			continue
 		}
 
 		// Extract version from filename (e.g., "001_create_users_table.sql" -> "001_create_users_table")
 		version := strings.TrimSuffix(file.Name(), ".sql")
-		if !strings.HasPrefix(version, "00") {
-			continue // Skip non-migration files
-		}
 
 		migrations = append(migrations, Migration{
 			Version:  version,
@@ -128,14 +137,32 @@ func (mr *MigrationRunner) getMigrationFiles() ([]Migration, error) {
 		})
 	}
 
-	// Sort by version
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migration files found in %s", mr.dir)
+	}
+
+	// Sort by numeric version prefix so "9_x" runs before "10_x"
 	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Version < migrations[j].Version
+		return versionLess(migrations[i].Filename, migrations[j].Filename)
 	})
 
 	return migrations, nil
 }
 
+// versionLess orders migration files by their numeric prefix, falling back
+// to the full name for files sharing a prefix
+func versionLess(a, b string) bool {
+	na := strings.TrimLeft(migrationFilePattern.FindStringSubmatch(a)[1], "0")
+	nb := strings.TrimLeft(migrationFilePattern.FindStringSubmatch(b)[1], "0")
+	if len(na) != len(nb) {
+		return len(na) < len(nb)
+	}
+	if na != nb {
+		return na < nb
+	}
+	return a < b
+}
+
 func (mr *MigrationRunner) getPendingMigrations(files []Migration, applied map[string]Migration) []Migration {
 	var pending []Migration
 	for _, file := range files {
@@ -148,13 +175,7 @@ func (mr *MigrationRunner) getPendingMigrations(files []Migration, applied map[s
 
 func (mr *MigrationRunner) runMigration(migration Migration) error {
 	// Read migration file
-	dir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	filepath := filepath.Join(dir, "migrations", migration.Filename)
-	content, err := os.ReadFile(filepath)
+	content, err := os.ReadFile(filepath.Join(mr.dir, migration.Filename))
 	if err != nil {
 		return fmt.Errorf("failed to read migration file %s: %w", migration.Filename, err)
 	}
@@ -8,6 +8,10 @@
 BEGIN;
 
 -- Drop all tables (due to foreign key constraints)
+DROP TABLE IF EXISTS watch_progress CASCADE;
+DROP TABLE IF EXISTS episode_comments CASCADE;
+DROP TABLE IF EXISTS episode_ratings CASCADE;
+DROP TABLE IF EXISTS episode_likes CASCADE;
 DROP TABLE IF EXISTS creator_analytics CASCADE;
 DROP TABLE IF EXISTS payout_details CASCADE;
 DROP TABLE IF EXISTS creator_profiles CASCADE;
@@ -19,6 +19,7 @@ type Series struct {
 	PriceAmount  *float64       `json:"price_amount" gorm:"type:decimal(10,2)"`
 	ThumbnailURL *string        `json:"thumbnail_url"`
 	Status       string         `json:"status" gorm:"type:varchar(20);default:'draft';check:status IN ('draft', 'published')"`
+	Version      int            `json:"version" gorm:"not null;default:1"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -30,20 +31,28 @@ type Series struct {
 
 // Episode represents a single episode in a series
 type Episode struct {
-	ID              string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	SeriesID        string         `json:"series_id" gorm:"type:uuid;not null"`
-	Title           string         `json:"title" gorm:"not null"`
-	EpisodeNumber   int            `json:"episode_number" gorm:"not null"`
-	DurationSeconds int            `json:"duration_seconds" gorm:"not null"`
-	S3MasterPath    *string        `json:"s3_master_path"`
-	HLSManifestURL  *string        `json:"hls_manifest_url"`
-	ThumbURL        *string        `json:"thumb_url"`
-	CaptionsURL     *string        `json:"captions_url"`
-	Status          string         `json:"status" gorm:"type:varchar(30);default:'pending_upload';check:status IN ('pending_upload', 'queued_transcode', 'ready', 'published')"`
-	PublishedAt     *time.Time     `json:"published_at"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID              string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	SeriesID        string `json:"series_id" gorm:"type:uuid;not null"`
+	Title           string `json:"title" gorm:"not null"`
+	EpisodeNumber   int    `json:"episode_number" gorm:"not null"`
+	DurationSeconds int    `json:"duration_seconds" gorm:"not null"`
+	// ClaimedDurationSeconds is what the creator entered. Once the transcoder
+	// reports DetectedDurationSeconds, that value becomes DurationSeconds and
+	// DurationMismatch flags a large difference between the two.
+	ClaimedDurationSeconds  *int           `json:"claimed_duration_seconds"`
+	DetectedDurationSeconds *int           `json:"detected_duration_seconds"`
+	DurationMismatch        bool           `json:"duration_mismatch" gorm:"not null;default:false"`
+	S3MasterPath            *string        `json:"s3_master_path"`
+	HLSManifestURL          *string        `json:"hls_manifest_url"`
+	ThumbURL                *string        `json:"thumb_url"`
+	CaptionsURL             *string        `json:"captions_url"`
+	ViewCount               int64          `json:"view_count" gorm:"not null;default:0"`
+	Status                  string         `json:"status" gorm:"type:varchar(30);default:'pending_upload';check:status IN ('pending_upload', 'queued_transcode', 'ready', 'published')"`
+	PublishedAt             *time.Time     `json:"published_at"`
+	Version                 int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt               time.Time      `json:"created_at"`
+	UpdatedAt               time.Time      `json:"updated_at"`
+	DeletedAt               gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relationships
 	Series Series `json:"series" gorm:"foreignKey:SeriesID"`
@@ -53,12 +62,14 @@ type Episode struct {
 type UploadRequest struct {
 	ID          string                 `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	UserID      string                 `json:"user_id" gorm:"type:uuid;not null"`
+	EpisodeID   *string                `json:"episode_id" gorm:"type:uuid;index"`
 	Filename    string                 `json:"filename" gorm:"not null"`
 	ContentType string                 `json:"content_type" gorm:"not null"`
 	SizeBytes   int64                  `json:"size_bytes" gorm:"not null"`
 	Metadata    map[string]interface{} `json:"metadata" gorm:"type:jsonb"`
-	Status      string                 `json:"status" gorm:"type:varchar(30);default:'pending';check:status IN ('pending', 'uploading', 'completed', 'failed')"`
-	CreatedAt   time.Time              `json:"created_at"`
+	S3Key       string                 `json:"s3_key"`
+	Status      string                 `json:"status" gorm:"type:varchar(30);default:'pending';check:status IN ('pending', 'uploading', 'completed', 'failed');index:idx_upload_requests_status_created"`
+	CreatedAt   time.Time              `json:"created_at" gorm:"index:idx_upload_requests_status_created"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt         `json:"deleted_at,omitempty" gorm:"index"`
 
@@ -66,6 +77,34 @@ type UploadRequest struct {
 	User User `json:"user" gorm:"foreignKey:UserID"`
 }
 
+// Transcoding job statuses
+const (
+	TranscodingStatusPending    = "pending"
+	TranscodingStatusProcessing = "processing"
+	TranscodingStatusCompleted  = "completed"
+	TranscodingStatusFailed     = "failed"
+)
+
+// TranscodingJob tracks conversion of an uploaded master file into HLS renditions
+type TranscodingJob struct {
+	ID           string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EpisodeID    string         `json:"episode_id" gorm:"type:uuid;not null;index"`
+	UploadID     string         `json:"upload_id" gorm:"type:uuid;not null;index"`
+	InputPath    string         `json:"input_path" gorm:"not null"`
+	Status       string         `json:"status" gorm:"type:varchar(20);default:'pending';check:status IN ('pending', 'processing', 'completed', 'failed')"`
+	Progress     int            `json:"progress" gorm:"default:0"`
+	OutputPaths  *string        `json:"output_paths" gorm:"type:text"`
+	ErrorMessage *string        `json:"error_message"`
+	StartedAt    *time.Time     `json:"started_at"`
+	CompletedAt  *time.Time     `json:"completed_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relationships
+	Episode *Episode `json:"episode,omitempty" gorm:"foreignKey:EpisodeID"`
+}
+
 // TableName specifies the table name for Series
 func (Series) TableName() string {
 	return "series"
@@ -80,3 +119,39 @@ func (Episode) TableName() string {
 func (UploadRequest) TableName() string {
 	return "upload_requests"
 }
+
+// TableName specifies the table name for TranscodingJob
+func (TranscodingJob) TableName() string {
+	return "transcoding_jobs"
+}
+
+// EpisodeCaption is a WebVTT captions track for one language of an episode
+type EpisodeCaption struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EpisodeID string    `json:"episode_id" gorm:"type:uuid;not null;uniqueIndex:idx_episode_captions_episode_language"`
+	Language  string    `json:"language" gorm:"type:varchar(20);not null;uniqueIndex:idx_episode_captions_episode_language"`
+	URL       string    `json:"url" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for EpisodeCaption
+func (EpisodeCaption) TableName() string {
+	return "episode_captions"
+}
+
+// OrphanedObject records storage a deleted episode left behind because its
+// cleanup failed. Key is an object key, or a prefix when it ends in "/".
+// Rows are kept for reconciliation until someone removes the objects.
+type OrphanedObject struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EpisodeID string    `json:"episode_id" gorm:"type:uuid;not null;index"`
+	Key       string    `json:"key" gorm:"not null"`
+	Error     string    `json:"error" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for OrphanedObject
+func (OrphanedObject) TableName() string {
+	return "orphaned_objects"
+}
@@ -6,13 +6,24 @@ import (
 	"gorm.io/gorm"
 )
 
+// Creator KYC statuses
+const (
+	KYCStatusPending  = "pending"
+	KYCStatusVerified = "verified"
+	KYCStatusRejected = "rejected"
+)
+
 type CreatorProfile struct {
 	ID              string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	UserID          string         `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
 	DisplayName     string         `json:"display_name" gorm:"not null"`
 	Bio             string         `json:"bio"`
+	AvatarURL       *string        `json:"avatar_url"`
 	KYCDocumentPath string         `json:"kyc_document_s3_path" gorm:"column:kyc_document_s3_path"`
 	KYCStatus       string         `json:"kyc_status" gorm:"default:'pending';check:kyc_status IN ('pending', 'verified', 'rejected')"`
+	KYCReason       *string        `json:"kyc_rejection_reason" gorm:"column:kyc_rejection_reason"`
+	KYCReviewedBy   *string        `json:"kyc_reviewed_by" gorm:"type:uuid"`
+	KYCReviewedAt   *time.Time     `json:"kyc_reviewed_at"`
 	PayoutDetails   *PayoutDetails `json:"payout_details" gorm:"foreignKey:CreatorID"`
 	Rating          *float64       `json:"rating" gorm:"type:decimal(3,2)"`
 	CreatedAt       time.Time      `json:"created_at"`
@@ -24,15 +35,55 @@ type CreatorProfile struct {
 }
 
 type PayoutDetails struct {
-	ID            string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	CreatorID     string         `json:"creator_id" gorm:"type:uuid;not null;uniqueIndex"`
-	BankName      string         `json:"bank_name"`
-	AccountNumber string         `json:"account_number"`
-	IFSCCode      string         `json:"ifsc_code"`
-	AccountHolder string         `json:"account_holder"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID            string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	CreatorID     string `json:"creator_id" gorm:"type:uuid;not null;uniqueIndex"`
+	BankName      string `json:"bank_name"`
+	AccountNumber string `json:"-"`
+	IFSCCode      string `json:"ifsc_code"`
+	AccountHolder string `json:"account_holder"`
+	// Verified is set once the bank account has been confirmed and cleared whenever it changes
+	Verified  bool           `json:"verified" gorm:"default:false"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// Creator payout statuses
+const (
+	PayoutStatusPending    = "pending"
+	PayoutStatusProcessing = "processing"
+	PayoutStatusPaid       = "paid"
+	PayoutStatusFailed     = "failed"
+)
+
+// CreatorPayout is a creator's request to withdraw earnings to their bank account
+type CreatorPayout struct {
+	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	CreatorID   string         `json:"creator_id" gorm:"type:uuid;not null;index"`
+	Amount      float64        `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Currency    string         `json:"currency" gorm:"type:varchar(3);default:'INR'"`
+	Status      string         `json:"status" gorm:"type:varchar(20);default:'pending';check:status IN ('pending', 'processing', 'paid', 'failed')"`
+	Reference   *string        `json:"reference"`
+	ProcessedAt *time.Time     `json:"processed_at"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// CreatorEarning is an append-only ledger entry crediting a creator with
+// their share of a successful payment for one of their series. The platform
+// commission rate in force at the time is kept alongside the gross and net
+// amounts so every credit can be audited later.
+type CreatorEarning struct {
+	ID                   string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	CreatorID            string    `json:"creator_id" gorm:"type:uuid;not null;index:idx_creator_earnings_creator_created"`
+	SeriesID             string    `json:"series_id" gorm:"type:uuid;not null;index"`
+	PaymentTransactionID string    `json:"payment_transaction_id" gorm:"type:uuid;not null;uniqueIndex"`
+	GrossAmount          float64   `json:"gross_amount" gorm:"type:decimal(10,2);not null"`
+	CommissionRate       float64   `json:"commission_rate" gorm:"type:decimal(5,4);not null"`
+	NetAmount            float64   `json:"net_amount" gorm:"type:decimal(10,2);not null"`
+	Currency             string    `json:"currency" gorm:"type:varchar(3);default:'INR'"`
+	CreatedAt            time.Time `json:"created_at" gorm:"index:idx_creator_earnings_creator_created"`
 }
 
 type CreatorAnalytics struct {
@@ -60,6 +111,16 @@ func (PayoutDetails) TableName() string {
 	return "payout_details"
 }
 
+// TableName specifies the table name for CreatorPayout
+func (CreatorPayout) TableName() string {
+	return "creator_payouts"
+}
+
+// TableName specifies the table name for CreatorEarning
+func (CreatorEarning) TableName() string {
+	return "creator_earnings"
+}
+
 // TableName specifies the table name for CreatorAnalytics
 func (CreatorAnalytics) TableName() string {
 	return "creator_analytics"
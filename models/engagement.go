@@ -37,3 +37,78 @@ type EpisodeComment struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
+
+// WatchProgress records how far a user has watched an episode, for resume playback
+type WatchProgress struct {
+	ID              string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID          string         `json:"user_id" gorm:"type:uuid;not null;index:idx_watch_progress_user_episode,unique"`
+	EpisodeID       string         `json:"episode_id" gorm:"type:uuid;not null;index:idx_watch_progress_user_episode,unique"`
+	PositionSeconds int            `json:"position_seconds" gorm:"not null;default:0"`
+	Completed       bool           `json:"completed" gorm:"default:false"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" gorm:"index"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// EpisodeView is an append-only record of a counted view, used to dedupe repeat views
+type EpisodeView struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EpisodeID string    `json:"episode_id" gorm:"type:uuid;not null;index:idx_episode_views_episode_user_time"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;index:idx_episode_views_episode_user_time"`
+	ViewedAt  time.Time `json:"viewed_at" gorm:"not null;index:idx_episode_views_episode_user_time"`
+}
+
+// TableName specifies the table name for EpisodeView
+func (EpisodeView) TableName() string {
+	return "episode_views"
+}
+
+// TableName specifies the table name for WatchProgress
+func (WatchProgress) TableName() string {
+	return "watch_progress"
+}
+
+// UserFavorite marks a series as favorited (bookmarked) by a user
+type UserFavorite struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;index:idx_user_favorites_user_series,unique"`
+	SeriesID  string    `json:"series_id" gorm:"type:uuid;not null;index:idx_user_favorites_user_series,unique;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for UserFavorite
+func (UserFavorite) TableName() string {
+	return "user_favorites"
+}
+
+// CreatorFollow marks a creator as followed by a user
+type CreatorFollow struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;index:idx_creator_follows_user_creator,unique"`
+	CreatorID string    `json:"creator_id" gorm:"type:uuid;not null;index:idx_creator_follows_user_creator,unique;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for CreatorFollow
+func (CreatorFollow) TableName() string {
+	return "creator_follows"
+}
+
+// PlaybackSession accumulates the watch time of one playback of an episode
+// from the player's periodic heartbeats
+type PlaybackSession struct {
+	ID                  string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID              string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_playback_sessions_user_session"`
+	SessionID           string    `json:"session_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_playback_sessions_user_session"`
+	EpisodeID           string    `json:"episode_id" gorm:"type:uuid;not null;index:idx_playback_sessions_episode_created"`
+	LastPositionSeconds int       `json:"last_position_seconds" gorm:"not null;default:0"`
+	LastHeartbeatAt     time.Time `json:"last_heartbeat_at" gorm:"not null"`
+	WatchedSeconds      int       `json:"watched_seconds" gorm:"not null;default:0"`
+	CreatedAt           time.Time `json:"created_at" gorm:"index:idx_playback_sessions_episode_created"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for PlaybackSession
+func (PlaybackSession) TableName() string {
+	return "playback_sessions"
+}
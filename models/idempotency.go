@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers which resource a client-supplied Idempotency-Key produced,
+// so retried requests return the original result instead of creating duplicates
+type IdempotencyKey struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID     string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_keys_user_scope_key"`
+	Scope      string    `json:"scope" gorm:"type:varchar(50);not null;uniqueIndex:idx_idempotency_keys_user_scope_key"`
+	Key        string    `json:"key" gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_scope_key"`
+	ResourceID string    `json:"resource_id" gorm:"type:uuid;not null"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for IdempotencyKey
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Kinds of content a report can point at
+const (
+	ReportTargetEpisode = "episode"
+	ReportTargetComment = "comment"
+)
+
+// Report reason categories
+const (
+	ReportReasonSpam       = "spam"
+	ReportReasonHarassment = "harassment"
+	ReportReasonHate       = "hate_speech"
+	ReportReasonSexual     = "sexual_content"
+	ReportReasonViolence   = "violence"
+	ReportReasonCopyright  = "copyright"
+	ReportReasonOther      = "other"
+)
+
+// Report moderation statuses
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// ContentReport is a user's flag on an episode or comment, queued for admin
+// moderation. A user can have only one pending report per target.
+type ContentReport struct {
+	ID         string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ReporterID string `json:"reporter_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_content_reports_pending_target,where:status = 'pending'"`
+	TargetType string `json:"target_type" gorm:"type:varchar(20);not null;check:target_type IN ('episode', 'comment');index:idx_content_reports_target;uniqueIndex:idx_content_reports_pending_target,where:status = 'pending'"`
+	TargetID   string `json:"target_id" gorm:"type:uuid;not null;index:idx_content_reports_target;uniqueIndex:idx_content_reports_pending_target,where:status = 'pending'"`
+	Reason     string `json:"reason" gorm:"type:varchar(30);not null"`
+	Details    string `json:"details" gorm:"type:text"`
+	Status     string `json:"status" gorm:"type:varchar(20);not null;default:'pending';check:status IN ('pending', 'resolved', 'dismissed');index:idx_content_reports_status_created"`
+	// ResolvedBy is the admin who closed the report, with their notes
+	ResolvedBy *string    `json:"resolved_by" gorm:"type:uuid"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	Resolution string     `json:"resolution" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index:idx_content_reports_status_created"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for ContentReport
+func (ContentReport) TableName() string {
+	return "content_reports"
+}
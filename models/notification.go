@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Notification types
+const (
+	NotificationTypeNewEpisode = "new_episode"
+)
+
+// Notification is an in-app notification for a user. Delivery to a push
+// provider, if any, reads from this table rather than being coupled to the
+// code that creates notifications.
+type Notification struct {
+	ID     string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID string `json:"user_id" gorm:"type:uuid;not null;index:idx_notifications_user_created;uniqueIndex:idx_notifications_user_episode_type"`
+	Type   string `json:"type" gorm:"type:varchar(30);not null;uniqueIndex:idx_notifications_user_episode_type"`
+	Title  string `json:"title" gorm:"not null"`
+	Body   string `json:"body" gorm:"type:text"`
+	// SeriesID and EpisodeID point at the content the notification is about
+	SeriesID  *string    `json:"series_id" gorm:"type:uuid"`
+	EpisodeID *string    `json:"episode_id" gorm:"type:uuid;uniqueIndex:idx_notifications_user_episode_type"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index:idx_notifications_user_created"`
+}
+
+// TableName specifies the table name for Notification
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Subscription statuses
+const (
+	SubscriptionStatusPending   = "pending"
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusCancelled = "cancelled"
+	SubscriptionStatusExpired   = "expired"
+)
+
+// Subscription grants a user access to a paid series
+type Subscription struct {
+	ID        string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string         `json:"user_id" gorm:"type:uuid;not null;index"`
+	SeriesID  string         `json:"series_id" gorm:"type:uuid;not null;index"`
+	PlanID    string         `json:"plan_id"`
+	Amount    float64        `json:"amount" gorm:"type:decimal(10,2);not null"`
+	AutoRenew bool           `json:"auto_renew" gorm:"default:false"`
+	Status    string         `json:"status" gorm:"type:varchar(20);default:'pending';check:status IN ('pending', 'active', 'cancelled', 'expired')"`
+	StartedAt *time.Time     `json:"started_at"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// ProviderSubscriptionID is the payment provider's subscription, linked
+	// from its webhook events
+	ProviderSubscriptionID *string `json:"provider_subscription_id" gorm:"type:varchar(100);index"`
+
+	// Relationships
+	Series *Series `json:"series,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+// IsSubscriptionActive reports whether the subscription currently grants access.
+// Cancelled subscriptions keep access until the paid period ends.
+func IsSubscriptionActive(sub *Subscription) bool {
+	if sub == nil || sub.ExpiresAt == nil || !sub.ExpiresAt.After(time.Now()) {
+		return false
+	}
+	return sub.Status == SubscriptionStatusActive || sub.Status == SubscriptionStatusCancelled
+}
+
+// Purchase statuses
+const (
+	PurchaseStatusPending   = "pending"
+	PurchaseStatusCompleted = "completed"
+	PurchaseStatusFailed    = "failed"
+	PurchaseStatusRefunded  = "refunded"
+)
+
+// Purchase is a one-time purchase of a series. It stays pending until the
+// payment provider confirms the charge, and once completed grants access to
+// the series for good. A user can complete only one purchase per series.
+type Purchase struct {
+	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      string         `json:"user_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_purchases_completed,where:status = 'completed'"`
+	SeriesID    string         `json:"series_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_purchases_completed,where:status = 'completed'"`
+	Amount      float64        `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Status      string         `json:"status" gorm:"type:varchar(20);not null;default:'pending';check:status IN ('pending', 'completed', 'failed', 'refunded')"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// ProviderOrderID is the payment provider's order, linked from its webhook events
+	ProviderOrderID *string `json:"provider_order_id" gorm:"type:varchar(100);index"`
+
+	// Relationships
+	Series *Series `json:"series,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+// Payment transaction statuses
+const (
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+)
+
+// PaymentTransaction records a charge against a user for a series
+type PaymentTransaction struct {
+	ID                string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID            string         `json:"user_id" gorm:"type:uuid;not null;index"`
+	SeriesID          string         `json:"series_id" gorm:"type:uuid;not null;index"`
+	SubscriptionID    *string        `json:"subscription_id" gorm:"type:uuid;index"`
+	PurchaseID        *string        `json:"purchase_id" gorm:"type:uuid;index"`
+	ProviderPaymentID string         `json:"provider_payment_id" gorm:"index"`
+	Amount            float64        `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Currency          string         `json:"currency" gorm:"type:varchar(3);default:'INR'"`
+	Status            string         `json:"status" gorm:"type:varchar(20);not null;check:status IN ('succeeded', 'failed', 'refunded')"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// PaymentWebhook is an audit record of every webhook delivery received from the payment provider.
+// Verified deliveries double as an outbox: they are processed until ProcessedAt is set,
+// and EventID dedupes redeliveries of the same event. FlagReason is set on events
+// that were closed without being applied, such as payments for the wrong amount.
+type PaymentWebhook struct {
+	ID             string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EventID        *string        `json:"event_id" gorm:"type:varchar(100);uniqueIndex"`
+	EventType      string         `json:"event_type" gorm:"index"`
+	Data           string         `json:"data" gorm:"type:text"`
+	Signature      string         `json:"signature"`
+	SignatureValid bool           `json:"signature_valid" gorm:"default:false"`
+	ProcessedAt    *time.Time     `json:"processed_at" gorm:"index"`
+	Attempts       int            `json:"attempts" gorm:"not null;default:0"`
+	LastError      *string        `json:"last_error"`
+	FlagReason     *string        `json:"flag_reason"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// TableName specifies the table name for PaymentWebhook
+func (PaymentWebhook) TableName() string {
+	return "payment_webhooks"
+}
+
+// TableName specifies the table name for PaymentTransaction
+func (PaymentTransaction) TableName() string {
+	return "payment_transactions"
+}
+
+// TableName specifies the table name for Purchase
+func (Purchase) TableName() string {
+	return "purchases"
+}
+
+// TableName specifies the table name for Subscription
+func (Subscription) TableName() string {
+	return "subscriptions"
+}
@@ -6,9 +6,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID        string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	Phone     string         `json:"phone" gorm:"not null;index:idx_users_phone,unique"`
+	Role      string         `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -17,22 +24,61 @@ type User struct {
 	CreatorProfile *CreatorProfile `json:"creator_profile,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// Playback quality preferences
+const (
+	QualityAuto = "auto"
+	Quality480  = "480"
+	Quality720  = "720"
+	Quality1080 = "1080"
+)
+
+// NotificationSettings are the push notification topics a user has opted into
+type NotificationSettings struct {
+	NewEpisodes   bool `json:"new_episodes" gorm:"not null;default:true"`
+	Subscriptions bool `json:"subscriptions" gorm:"not null;default:true"`
+	Promotions    bool `json:"promotions" gorm:"not null;default:false"`
+}
+
+// DefaultNotificationSettings matches the column defaults for a new preferences row
+func DefaultNotificationSettings() NotificationSettings {
+	return NotificationSettings{NewEpisodes: true, Subscriptions: true}
+}
+
+// UserPreferences holds per-user playback and app settings
+type UserPreferences struct {
+	ID                string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID            string `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	QualityPreference string `json:"quality_preference" gorm:"type:varchar(10);not null;default:'auto'"`
+	// Language is the preferred content language code; empty when unset
+	Language      string               `json:"language" gorm:"type:varchar(10);not null;default:''"`
+	Notifications NotificationSettings `json:"notifications" gorm:"embedded;embeddedPrefix:notify_"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt       `json:"deleted_at,omitempty" gorm:"index"`
+}
+
 type OTPTransaction struct {
-	ID        string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	TxnID     string         `json:"txn_id" gorm:"not null;index:idx_otp_transactions_txn_id,unique"`
-	Phone     string         `json:"phone" gorm:"not null"`
-	OTP       string         `json:"otp" gorm:"not null"`
-	ExpiresAt time.Time      `json:"expires_at" gorm:"not null"`
-	Used      bool           `json:"used" gorm:"default:false"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	TxnID     string    `json:"txn_id" gorm:"not null;index:idx_otp_transactions_txn_id,unique"`
+	Phone     string    `json:"phone" gorm:"not null"`
+	OTP       string    `json:"otp" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	Used      bool      `json:"used" gorm:"default:false"`
+	// Invalidated is set when a newer code is sent to the same phone
+	Invalidated bool `json:"invalidated" gorm:"default:false"`
+	// FailedAttempts counts wrong codes entered against this transaction
+	FailedAttempts int            `json:"failed_attempts" gorm:"default:0"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 type RefreshToken struct {
-	ID        string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Token     string         `json:"token" gorm:"not null;index:idx_refresh_tokens_token,unique"`
-	UserID    string         `json:"user_id" gorm:"not null;type:uuid"`
+	ID     string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Token  string `json:"token" gorm:"not null;index:idx_refresh_tokens_token,unique"`
+	UserID string `json:"user_id" gorm:"not null;type:uuid"`
+	// FamilyID links every token produced by rotating the one issued at login
+	FamilyID  string         `json:"family_id" gorm:"type:uuid;index"`
 	ExpiresAt time.Time      `json:"expires_at" gorm:"not null"`
 	Revoked   bool           `json:"revoked" gorm:"default:false"`
 	CreatedAt time.Time      `json:"created_at"`
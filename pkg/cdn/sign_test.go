@@ -0,0 +1,127 @@
+package cdn
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSigner(t *testing.T) (*Signer, *rsa.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	signer, err := NewSigner("KPTEST", pemKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, &key.PublicKey
+}
+
+// decodeSignature reverses CloudFront's URL-safe base64 variant
+func decodeSignature(t *testing.T, s string) []byte {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(s))
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return raw
+}
+
+func verifyPolicy(t *testing.T, pub *rsa.PublicKey, policy string, sig []byte) {
+	t.Helper()
+	hash := sha1.Sum([]byte(policy))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA1, hash[:], sig); err != nil {
+		t.Errorf("signature does not verify over %s: %v", policy, err)
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	signer, pub := newTestSigner(t)
+	const rawURL = "https://cdn.example.com/hls/ep1/master.m3u8"
+	expires := time.Unix(1767225600, 0)
+
+	signed, err := signer.SignURL(rawURL, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if got := q.Get("Expires"); got != "1767225600" {
+		t.Errorf("Expires = %q", got)
+	}
+	if got := q.Get("Key-Pair-Id"); got != "KPTEST" {
+		t.Errorf("Key-Pair-Id = %q", got)
+	}
+	if strings.ContainsAny(q.Get("Signature"), "+/=") {
+		t.Errorf("Signature %q is not CloudFront URL-safe", q.Get("Signature"))
+	}
+
+	policy := fmt.Sprintf(`{"Statement":[{"Resource":"%s","Condition":{"DateLessThan":{"AWS:EpochTime":1767225600}}}]}`, rawURL)
+	verifyPolicy(t, pub, policy, decodeSignature(t, q.Get("Signature")))
+}
+
+func TestSignPrefix(t *testing.T) {
+	signer, pub := newTestSigner(t)
+	const prefix = "https://cdn.example.com/hls/ep1/"
+
+	params, err := signer.SignPrefix(prefix, time.Unix(1767225600, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := url.ParseQuery(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"Statement":[{"Resource":"https://cdn.example.com/hls/ep1/*","Condition":{"DateLessThan":{"AWS:EpochTime":1767225600}}}]}`
+	policy := string(decodeSignature(t, q.Get("Policy")))
+	if policy != want {
+		t.Fatalf("policy = %s, want %s", policy, want)
+	}
+	verifyPolicy(t, pub, want, decodeSignature(t, q.Get("Signature")))
+	if got := q.Get("Key-Pair-Id"); got != "KPTEST" {
+		t.Errorf("Key-Pair-Id = %q", got)
+	}
+}
+
+func TestEncodeSignature(t *testing.T) {
+	// 0xfb 0xff encodes to "+/8=" in standard base64
+	if got := encodeSignature([]byte{0xfb, 0xff}); got != "-~8_" {
+		t.Errorf("encodeSignature = %q, want -~8_", got)
+	}
+}
+
+func TestNewSignerPKCS8(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner("KPTEST", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		t.Errorf("PKCS8 key rejected: %v", err)
+	}
+	if _, err := NewSigner("", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err == nil {
+		t.Error("missing key pair id was accepted")
+	}
+	if _, err := NewSigner("KPTEST", []byte("not pem")); err == nil {
+		t.Error("non-PEM key was accepted")
+	}
+}
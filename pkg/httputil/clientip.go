@@ -0,0 +1,36 @@
+package httputil
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the address of the client that made the request. trustedHops
+// is the number of reverse proxies in front of the server; each appends the
+// address it received the request from to X-Forwarded-For, so the client is the
+// entry trustedHops from the end. Entries further left are client-controlled
+// and are never trusted. With no trusted proxies the socket address is used.
+func ClientIP(r *http.Request, trustedHops int) string {
+	if trustedHops > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, part := range strings.Split(header, ",") {
+				if ip := strings.TrimSpace(part); ip != "" {
+					hops = append(hops, ip)
+				}
+			}
+		}
+		if len(hops) >= trustedHops {
+			if ip := net.ParseIP(hops[len(hops)-trustedHops]); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,84 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxBodyBytes caps the size of request bodies read through DecodeJSON and ReadBody.
+// It is set once at startup from configuration.
+var MaxBodyBytes int64 = 1 << 20
+
+// DecodeJSON strictly decodes a single JSON object from the request body into dst.
+// Unknown fields, trailing data and bodies over MaxBodyBytes are rejected. On
+// failure it writes a 400 (or 413) error response and returns false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		writeDecodeError(w, err)
+		return false
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeDecodeError(w, err)
+			return false
+		}
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, "Request body must contain a single JSON object")
+		return false
+	}
+	return true
+}
+
+// ReadBody reads the raw request body, enforcing MaxBodyBytes. On failure it
+// writes an error response and returns false.
+func ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteError(w, http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+				fmt.Sprintf("Request body must not exceed %d bytes", maxErr.Limit))
+			return nil, false
+		}
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+		return nil, false
+	}
+	return body, true
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxErr):
+		WriteError(w, http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			fmt.Sprintf("Request body must not exceed %d bytes", maxErr.Limit))
+	case errors.Is(err, io.EOF):
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, "Request body must not be empty")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, "Request body contains malformed JSON")
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			WriteError(w, http.StatusBadRequest, CodeInvalidRequest,
+				fmt.Sprintf("Field %q has the wrong type", typeErr.Field))
+			return
+		}
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, "Request body has the wrong type")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("Unknown field %s", field))
+	default:
+		WriteError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+}
@@ -0,0 +1,72 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	previous := MaxBodyBytes
+	MaxBodyBytes = 64
+	t.Cleanup(func() { MaxBodyBytes = previous })
+
+	tests := []struct {
+		name       string
+		body       string
+		wantOK     bool
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{"valid", `{"name":"ok"}`, true, http.StatusOK, "", ""},
+		{"oversized", `{"name":"` + strings.Repeat("x", 100) + `"}`, false, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "must not exceed 64 bytes"},
+		{"oversized after a valid object", `{"name":"ok"}` + strings.Repeat(" ", 100) + `{}`, false, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "must not exceed 64 bytes"},
+		{"unknown field", `{"name":"ok","admin":true}`, false, http.StatusBadRequest, CodeInvalidRequest, `Unknown field "admin"`},
+		{"trailing data", `{"name":"ok"}{"name":"again"}`, false, http.StatusBadRequest, CodeInvalidRequest, "single JSON object"},
+		{"trailing garbage", `{"name":"ok"} x`, false, http.StatusBadRequest, CodeInvalidRequest, "single JSON object"},
+		{"empty body", ``, false, http.StatusBadRequest, CodeInvalidRequest, "must not be empty"},
+		{"malformed", `{"name":`, false, http.StatusBadRequest, CodeInvalidRequest, "malformed JSON"},
+		{"wrong type", `{"name":1}`, false, http.StatusBadRequest, CodeInvalidRequest, `Field "name" has the wrong type`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			var dst struct {
+				Name string `json:"name"`
+			}
+			if ok := DecodeJSON(rec, req, &dst); ok != tt.wantOK {
+				t.Fatalf("DecodeJSON = %v, want %v (body %s)", ok, tt.wantOK, rec.Body)
+			}
+			if tt.wantOK {
+				if dst.Name != "ok" {
+					t.Errorf("name = %q, want ok", dst.Name)
+				}
+				return
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Error.Code, tt.wantCode)
+			}
+			if !strings.Contains(body.Error.Message, tt.wantMsg) {
+				t.Errorf("message = %q, want it to mention %q", body.Error.Message, tt.wantMsg)
+			}
+		})
+	}
+}
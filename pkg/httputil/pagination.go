@@ -0,0 +1,44 @@
+package httputil
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Page size limits shared by every list endpoint
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// PaginatedResponse is the envelope returned by offset-paginated list endpoints
+type PaginatedResponse[T any] struct {
+	Total   int64 `json:"total"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	Items   []T   `json:"items"`
+}
+
+// NewPaginatedResponse builds a response envelope, never encoding items as null
+func NewPaginatedResponse[T any](items []T, total int64, page, perPage int) PaginatedResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return PaginatedResponse[T]{Total: total, Page: page, PerPage: perPage, Items: items}
+}
+
+// ParsePagination reads the page and per_page query parameters. Missing, negative
+// or non-numeric values fall back to the defaults and per_page is capped at MaxPerPage.
+func ParsePagination(r *http.Request) (page, perPage, offset int) {
+	page = 1
+	perPage = DefaultPerPage
+
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if pp, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && pp > 0 {
+		perPage = min(pp, MaxPerPage)
+	}
+
+	return page, perPage, (page - 1) * perPage
+}
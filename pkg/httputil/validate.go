@@ -0,0 +1,119 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why one request field failed validation. Rule and
+// Param are stable so clients can show their own (localized) message.
+type FieldError struct {
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their JSON names, which is what clients send
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// DecodeAndValidate decodes the body like DecodeJSON, then checks the
+// `validate` struct tags of dst. On failure it writes an error response and
+// returns false.
+func DecodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return DecodeJSON(w, r, dst) && Validate(w, dst)
+}
+
+// Validate checks the `validate` struct tags of v. On failure it writes a 400
+// listing every invalid field and returns false.
+func Validate(w http.ResponseWriter, v interface{}) bool {
+	err := validate.Struct(v)
+	if err == nil {
+		return true
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		WriteError(w, http.StatusInternalServerError, CodeInternal, "Failed to validate request")
+		return false
+	}
+
+	fields := make(map[string]FieldError, len(verrs))
+	for _, fe := range verrs {
+		// Namespace is "Struct.field.nested"; drop the struct name
+		_, name, _ := strings.Cut(fe.Namespace(), ".")
+		if _, seen := fields[name]; !seen {
+			fields[name] = FieldError{Rule: fe.Tag(), Param: fe.Param(), Message: fieldMessage(name, fe)}
+		}
+	}
+	WriteValidationError(w, fields)
+	return false
+}
+
+// WriteValidationError writes a 400 whose details.fields maps each invalid
+// field to why it was rejected. Handlers use it directly for checks that
+// struct tags cannot express.
+func WriteValidationError(w http.ResponseWriter, fields map[string]FieldError) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	message := fields[names[0]].Message
+	if len(names) > 1 {
+		message = "Invalid fields: " + strings.Join(names, ", ")
+	}
+	WriteErrorDetails(w, http.StatusBadRequest, CodeInvalidRequest, message, map[string]interface{}{"fields": fields})
+}
+
+// fieldMessage renders an English message for a failed rule
+func fieldMessage(name string, fe validator.FieldError) string {
+	kind := fe.Kind()
+	switch fe.Tag() {
+	case "required":
+		return name + " is required"
+	case "min", "max", "len":
+		bound := map[string]string{"min": "at least", "max": "at most", "len": "exactly"}[fe.Tag()]
+		switch kind {
+		case reflect.String:
+			return fmt.Sprintf("%s must be %s %s characters long", name, bound, fe.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("%s must have %s %s items", name, bound, fe.Param())
+		}
+		return fmt.Sprintf("%s must be %s %s", name, bound, fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", name, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", name, fe.Param())
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", name, fe.Param())
+	case "lte":
+		return fmt.Sprintf("%s must be at most %s", name, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", name, strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "numeric":
+		return name + " must be numeric"
+	}
+	return fmt.Sprintf("%s is invalid (%s)", name, fe.Tag())
+}
@@ -0,0 +1,62 @@
+// Package metrics defines the Prometheus collectors exported on /metrics
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// HTTPRequests counts handled requests by route template, method and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "streamshort_http_requests_total",
+		Help: "HTTP requests handled, by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration observes request latency by route template, method and status code
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "streamshort_http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, by route, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// OTPsSent counts OTP codes handed to the SMS sender
+	OTPsSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "streamshort_otp_sent_total",
+		Help: "OTP codes sent.",
+	})
+
+	// OTPVerificationsFailed counts OTP verifications rejected for a wrong, expired or locked code
+	OTPVerificationsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "streamshort_otp_verifications_failed_total",
+		Help: "OTP verifications that failed.",
+	})
+
+	// SeriesPublished counts series moved to the published status
+	SeriesPublished = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "streamshort_series_published_total",
+		Help: "Series published by creators.",
+	})
+
+	// CacheRequests counts cache lookups by cache name and result (hit, miss or error)
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "streamshort_cache_requests_total",
+		Help: "Cache lookups, by cache and result.",
+	}, []string{"cache", "result"})
+
+	// SubscriptionsCreated counts subscriptions created through the payments API
+	SubscriptionsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "streamshort_subscriptions_created_total",
+		Help: "Subscriptions created.",
+	})
+)
+
+// Register adds all collectors to reg. Call once at startup.
+func Register(reg prometheus.Registerer) {
+	reg.MustRegister(
+		HTTPRequests,
+		HTTPDuration,
+		OTPsSent,
+		OTPVerificationsFailed,
+		SeriesPublished,
+		SubscriptionsCreated,
+		CacheRequests,
+	)
+}
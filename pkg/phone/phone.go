@@ -0,0 +1,94 @@
+// Package phone normalizes user-entered phone numbers to E.164.
+//
+// It deliberately does not use libphonenumber (nyaruka/phonenumbers): sign-in
+// is only offered in the regions listed below, whose numbering rules fit in a
+// few lines, and the library's metadata for every region would be the largest
+// dependency in the binary. Numbers from other regions are only checked for
+// E.164 length; add a region here before the service launches in it.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultRegion is assumed for numbers entered without a country code
+const DefaultRegion = "IN"
+
+// ErrInvalid is returned for input that cannot be a valid phone number
+var ErrInvalid = errors.New("invalid phone number")
+
+type region struct {
+	countryCode    string
+	nationalLength int
+	// leadingDigits lists the digits a national (subscriber) number may start with
+	leadingDigits string
+}
+
+var regions = map[string]region{
+	"IN": {countryCode: "91", nationalLength: 10, leadingDigits: "6789"},
+}
+
+// Normalize converts raw into E.164 form (e.g. +919876543210). Numbers without
+// an international prefix are interpreted in defaultRegion, so "9876543210",
+// "09876543210" and "+91 98765-43210" all normalize to the same value.
+func Normalize(raw, defaultRegion string) (string, error) {
+	s := strings.TrimSpace(raw)
+	international := false
+	switch {
+	case strings.HasPrefix(s, "+"):
+		international = true
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		international = true
+		s = s[2:]
+	}
+
+	var digits strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')':
+			// Common visual separators
+		default:
+			return "", ErrInvalid
+		}
+	}
+	number := digits.String()
+
+	if !international {
+		reg, ok := regions[defaultRegion]
+		if !ok {
+			return "", ErrInvalid
+		}
+		// Drop the trunk prefix used for domestic dialling
+		number = strings.TrimPrefix(number, "0")
+		// Accept a country code typed without the leading "+"
+		if len(number) == len(reg.countryCode)+reg.nationalLength && strings.HasPrefix(number, reg.countryCode) {
+			number = number[len(reg.countryCode):]
+		}
+		if !validNational(reg, number) {
+			return "", ErrInvalid
+		}
+		return "+" + reg.countryCode + number, nil
+	}
+
+	// Known regions get full validation; anything else only needs to fit E.164
+	for _, reg := range regions {
+		if strings.HasPrefix(number, reg.countryCode) {
+			if !validNational(reg, number[len(reg.countryCode):]) {
+				return "", ErrInvalid
+			}
+			return "+" + number, nil
+		}
+	}
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalid
+	}
+	return "+" + number, nil
+}
+
+func validNational(reg region, national string) bool {
+	return len(national) == reg.nationalLength && strings.ContainsRune(reg.leadingDigits, rune(national[0]))
+}
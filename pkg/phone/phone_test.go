@@ -0,0 +1,66 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"9876543210", "+919876543210"},
+		{"09876543210", "+919876543210"},
+		{"+91 98765-43210", "+919876543210"},
+		{"+91 (98765) 43210", "+919876543210"},
+		{"0091 98765 43210", "+919876543210"},
+		{"919876543210", "+919876543210"},
+		{" 98765.43210 ", "+919876543210"},
+		{"6123456789", "+916123456789"},
+		// Outside the regions validated in full, E.164 length is all that is checked
+		{"+1 415 555 0100", "+14155550100"},
+		{"+44 20 7946 0958", "+442079460958"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Normalize(tt.raw, DefaultRegion)
+			if err != nil {
+				t.Fatalf("Normalize(%q): %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		region string
+	}{
+		{"empty", "", DefaultRegion},
+		{"too short", "98765", DefaultRegion},
+		{"too long", "98765432101", DefaultRegion},
+		{"bad leading digit", "5876543210", DefaultRegion},
+		{"bad leading digit with country code", "+91 5876543210", DefaultRegion},
+		{"short with country code", "+91 98765", DefaultRegion},
+		{"letters", "98765abcde", DefaultRegion},
+		{"plus in the middle", "91+9876543210", DefaultRegion},
+		{"unknown default region", "9876543210", "ZZ"},
+		{"international too short", "+1234567", DefaultRegion},
+		{"international too long", "+1234567890123456", DefaultRegion},
+		{"international leading zero", "+0123456789", DefaultRegion},
+		{"plus only", "+", DefaultRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.raw, tt.region)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Normalize(%q) = %q, %v; want ErrInvalid", tt.raw, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package ratelimit implements token-bucket rate limiting backed by Redis or process memory.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket: it refills at PerMinute tokens per minute
+// and holds at most Burst tokens
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Enabled reports whether the limit should be enforced
+func (l Limit) Enabled() bool {
+	return l.PerMinute > 0 && l.Burst > 0
+}
+
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Store takes one token from the bucket identified by key. When the bucket is
+// empty it returns false and how long until a token becomes available.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// MemoryStore keeps buckets in process memory. Limits are per instance, so it
+// is only suitable for single-instance deployments and local development.
+type MemoryStore struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// idleBucketTTL is how long an untouched bucket is kept before being dropped
+const idleBucketTTL = 10 * time.Minute
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastCleanup: time.Now()}
+}
+
+// Allow implements Store
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastCleanup) > idleBucketTTL {
+		for k, b := range s.buckets {
+			if now.Sub(b.last) > idleBucketTTL {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastCleanup = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.ratePerSecond())
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, retryAfter(b.tokens, limit), nil
+}
+
+// retryAfter is the time needed to refill from tokens to one whole token
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	seconds := (1 - tokens) / limit.ratePerSecond()
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
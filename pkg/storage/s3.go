@@ -0,0 +1,144 @@
+// Package storage wraps the S3 operations used for creator uploads.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrNotConfigured is returned when no bucket or AWS credentials are available
+var ErrNotConfigured = errors.New("s3 storage is not configured")
+
+// S3Client issues presigned URLs against, and cleans up objects in, a single bucket
+type S3Client struct {
+	bucket  string
+	client  *s3.Client
+	presign *s3.PresignClient
+}
+
+// NewS3Client builds a client for bucket using the default AWS credential chain
+// (AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, shared config, instance roles).
+func NewS3Client(ctx context.Context, bucket, region string) (*S3Client, error) {
+	if bucket == "" {
+		return nil, ErrNotConfigured
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+
+	// Fail early rather than handing out URLs signed with empty credentials
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotConfigured, err)
+	}
+
+	client := s3.NewFromConfig(cfg)
+	return &S3Client{
+		bucket:  bucket,
+		client:  client,
+		presign: s3.NewPresignClient(client),
+	}, nil
+}
+
+// Bucket returns the bucket name the client operates on
+func (c *S3Client) Bucket() string {
+	return c.bucket
+}
+
+// PresignPut returns a URL allowing a single PUT of key with the given content type until expires elapses.
+// When size is positive the Content-Length is signed too, so S3 rejects a body of any other size.
+func (c *S3Client) PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}
+	if size > 0 {
+		input.ContentLength = aws.Int64(size)
+	}
+	req, err := c.presign.PresignPutObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// KeyFromPath accepts either a bare object key or an s3://bucket/key URI and returns the key.
+// ok is false when the URI names a different bucket.
+func (c *S3Client) KeyFromPath(path string) (key string, ok bool) {
+	return KeyFromPath(c.bucket, path)
+}
+
+// KeyFromPath is the bucket-agnostic form of (*S3Client).KeyFromPath
+func KeyFromPath(bucket, path string) (string, bool) {
+	if !strings.HasPrefix(path, "s3://") {
+		return strings.TrimPrefix(path, "/"), true
+	}
+	rest := strings.TrimPrefix(path, "s3://")
+	b, key, found := strings.Cut(rest, "/")
+	if !found || (bucket != "" && b != bucket) {
+		return "", false
+	}
+	return key, true
+}
+
+// DeleteObject removes key from the bucket. Deleting a key that does not
+// exist succeeds.
+func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// DeletePrefix removes every object whose key starts with prefix and returns
+// how many were deleted. prefix must be non-empty so the bucket cannot be
+// emptied by mistake.
+func (c *S3Client) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("refusing to delete an empty prefix")
+	}
+
+	deleted := 0
+	pages := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		// A listing page holds at most 1000 keys, the most one request can delete
+		objects := make([]types.ObjectIdentifier, len(page.Contents))
+		for i, object := range page.Contents {
+			objects[i] = types.ObjectIdentifier{Key: object.Key}
+		}
+		out, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(c.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += len(objects) - len(out.Errors)
+		if len(out.Errors) > 0 {
+			return deleted, fmt.Errorf("delete %s: %s", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message))
+		}
+	}
+	return deleted, nil
+}
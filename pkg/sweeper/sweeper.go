@@ -0,0 +1,259 @@
+// Package sweeper runs periodic housekeeping against the database: expiring
+// lapsed subscriptions, pruning old OTP transactions and idempotency keys,
+// releasing the phone numbers of deleted accounts and purging deleted series.
+package sweeper
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"streamshort/models"
+
+	"gorm.io/gorm"
+)
+
+// batchSize bounds how many rows a single statement touches so no sweep holds
+// long-running locks on busy tables
+const batchSize = 500
+
+// Options controls what the sweep considers stale
+type Options struct {
+	// Interval between sweeps when running in the background
+	Interval time.Duration
+	// OTPRetention is how long OTP transactions are kept after creation
+	OTPRetention time.Duration
+	// DeletedAccountRetention is how long a deleted account keeps its phone
+	// number before it is scrubbed and can sign up again
+	DeletedAccountRetention time.Duration
+	// DeletedSeriesRetention is how long a deleted series can be restored
+	// before it and its episodes are permanently removed; zero keeps them
+	DeletedSeriesRetention time.Duration
+	// CleanupEpisodeStorage, if set, removes a purged episode's media from
+	// object storage
+	CleanupEpisodeStorage func(models.Episode)
+}
+
+// Result reports how many rows a sweep changed
+type Result struct {
+	ExpiredSubscriptions   int64
+	DeletedOTPs            int64
+	DeletedIdempotencyKeys int64
+	ReleasedPhones         int64
+	PurgedSeries           int64
+}
+
+// Sweep runs a single pass as of now
+func Sweep(ctx context.Context, db *gorm.DB, opts Options, now time.Time) (Result, error) {
+	var result Result
+
+	expired, err := expireSubscriptions(ctx, db, now)
+	result.ExpiredSubscriptions = expired
+	if err != nil {
+		return result, err
+	}
+
+	deleted, err := deleteOldOTPs(ctx, db, now.Add(-opts.OTPRetention))
+	result.DeletedOTPs = deleted
+	if err != nil {
+		return result, err
+	}
+
+	deleted, err = deleteExpired(ctx, db, &models.IdempotencyKey{}, now)
+	result.DeletedIdempotencyKeys = deleted
+	if err != nil {
+		return result, err
+	}
+
+	released, err := releaseDeletedPhones(ctx, db, now.Add(-opts.DeletedAccountRetention))
+	result.ReleasedPhones = released
+	if err != nil {
+		return result, err
+	}
+
+	if opts.DeletedSeriesRetention > 0 {
+		purged, err := purgeDeletedSeries(ctx, db, now.Add(-opts.DeletedSeriesRetention), opts.CleanupEpisodeStorage)
+		result.PurgedSeries = purged
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Start runs Sweep every opts.Interval until ctx is cancelled
+func Start(ctx context.Context, db *gorm.DB, opts Options) {
+	if opts.Interval <= 0 {
+		log.Println("Sweeper disabled (interval is not positive)")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				result, err := Sweep(ctx, db, opts, now)
+				if err != nil {
+					log.Printf("Sweep failed: %v", err)
+					continue
+				}
+				if result.ExpiredSubscriptions > 0 || result.DeletedOTPs > 0 || result.ReleasedPhones > 0 || result.PurgedSeries > 0 {
+					log.Printf("Sweep expired %d subscriptions, deleted %d OTP transactions, released %d phone numbers and purged %d deleted series",
+						result.ExpiredSubscriptions, result.DeletedOTPs, result.ReleasedPhones, result.PurgedSeries)
+				}
+			}
+		}
+	}()
+}
+
+// expireSubscriptions marks active or cancelled subscriptions past their expiry as expired
+func expireSubscriptions(ctx context.Context, db *gorm.DB, now time.Time) (int64, error) {
+	var total int64
+	for {
+		batch := db.WithContext(ctx).Model(&models.Subscription{}).
+			Select("id").
+			Where("status IN ? AND expires_at <= ?",
+				[]string{models.SubscriptionStatusActive, models.SubscriptionStatusCancelled}, now).
+			Limit(batchSize)
+
+		res := db.WithContext(ctx).Model(&models.Subscription{}).
+			Where("id IN (?)", batch).
+			Update("status", models.SubscriptionStatusExpired)
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if res.RowsAffected < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// deleteOldOTPs permanently removes OTP transactions created before cutoff
+func deleteOldOTPs(ctx context.Context, db *gorm.DB, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		batch := db.WithContext(ctx).Unscoped().Model(&models.OTPTransaction{}).
+			Select("id").
+			Where("created_at < ?", cutoff).
+			Limit(batchSize)
+
+		res := db.WithContext(ctx).Unscoped().
+			Where("id IN (?)", batch).
+			Delete(&models.OTPTransaction{})
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if res.RowsAffected < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// deleteExpired removes rows of model whose expires_at has passed
+func deleteExpired(ctx context.Context, db *gorm.DB, model interface{}, now time.Time) (int64, error) {
+	var total int64
+	for {
+		batch := db.WithContext(ctx).Model(model).
+			Select("id").
+			Where("expires_at <= ?", now).
+			Limit(batchSize)
+
+		res := db.WithContext(ctx).Where("id IN (?)", batch).Delete(model)
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if res.RowsAffected < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// deletedPhonePrefix marks a phone number that was scrubbed from a deleted account
+const deletedPhonePrefix = "deleted:"
+
+// releaseDeletedPhones replaces the phone number of accounts deleted before
+// cutoff with a placeholder, so the number can register a new account
+func releaseDeletedPhones(ctx context.Context, db *gorm.DB, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		batch := db.WithContext(ctx).Unscoped().Model(&models.User{}).
+			Select("id").
+			Where("deleted_at < ? AND phone NOT LIKE ?", cutoff, deletedPhonePrefix+"%").
+			Limit(batchSize)
+
+		res := db.WithContext(ctx).Unscoped().Model(&models.User{}).
+			Where("id IN (?)", batch).
+			Update("phone", gorm.Expr("? || id", deletedPhonePrefix))
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if res.RowsAffected < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// purgeDeletedSeries permanently removes series deleted before cutoff along
+// with their episodes and the episodes' captions and transcoding jobs, then
+// hands each episode to cleanup so its media leaves object storage too.
+// Purchases, subscriptions and earnings keep referring to the series id.
+func purgeDeletedSeries(ctx context.Context, db *gorm.DB, cutoff time.Time, cleanup func(models.Episode)) (int64, error) {
+	var total int64
+	for {
+		var seriesIDs []string
+		if err := db.WithContext(ctx).Unscoped().Model(&models.Series{}).
+			Where("deleted_at < ?", cutoff).
+			Limit(batchSize).
+			Pluck("id", &seriesIDs).Error; err != nil {
+			return total, err
+		}
+		if len(seriesIDs) == 0 {
+			return total, nil
+		}
+
+		var episodes []models.Episode
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Where("series_id IN ?", seriesIDs).Find(&episodes).Error; err != nil {
+				return err
+			}
+			episodeIDs := make([]string, len(episodes))
+			for i, episode := range episodes {
+				episodeIDs[i] = episode.ID
+			}
+			if len(episodeIDs) > 0 {
+				if err := tx.Where("episode_id IN ?", episodeIDs).Delete(&models.EpisodeCaption{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Unscoped().Where("episode_id IN ?", episodeIDs).Delete(&models.TranscodingJob{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Unscoped().Where("id IN ?", episodeIDs).Delete(&models.Episode{}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Unscoped().Where("id IN ?", seriesIDs).Delete(&models.Series{}).Error
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(seriesIDs))
+
+		if cleanup != nil {
+			for _, episode := range episodes {
+				cleanup(episode)
+			}
+		}
+		if len(seriesIDs) < batchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sweeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"streamshort/models"
+	"streamshort/pkg/testdb"
+)
+
+func TestPurgeDeletedSeries(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Now()
+
+	user := models.User{Phone: "+919800000030"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	creator := models.CreatorProfile{UserID: user.ID, DisplayName: "Sweeper Test"}
+	if err := db.Create(&creator).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// deletedSeries creates a series with one episode, both deleted at deletedAt
+	deletedSeries := func(deletedAt time.Time) (models.Series, models.Episode) {
+		t.Helper()
+		series := models.Series{CreatorID: creator.ID, Title: "Gone", Synopsis: "Deleted", Language: "en", PriceType: "free"}
+		if err := db.Create(&series).Error; err != nil {
+			t.Fatal(err)
+		}
+		episode := models.Episode{SeriesID: series.ID, Title: "Pilot", EpisodeNumber: 1, DurationSeconds: 60}
+		if err := db.Create(&episode).Error; err != nil {
+			t.Fatal(err)
+		}
+		caption := models.EpisodeCaption{EpisodeID: episode.ID, Language: "en", URL: "captions/" + episode.ID + "/en.vtt"}
+		if err := db.Create(&caption).Error; err != nil {
+			t.Fatal(err)
+		}
+		db.Model(&models.Episode{}).Where("id = ?", episode.ID).Update("deleted_at", deletedAt)
+		db.Model(&models.Series{}).Where("id = ?", series.ID).Update("deleted_at", deletedAt)
+		return series, episode
+	}
+	expired, expiredEpisode := deletedSeries(now.Add(-31 * 24 * time.Hour))
+	recent, recentEpisode := deletedSeries(now.Add(-time.Hour))
+
+	var cleaned []string
+	result, err := Sweep(context.Background(), db, Options{
+		DeletedSeriesRetention: 30 * 24 * time.Hour,
+		CleanupEpisodeStorage:  func(episode models.Episode) { cleaned = append(cleaned, episode.ID) },
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PurgedSeries != 1 {
+		t.Errorf("purged %d series, want 1", result.PurgedSeries)
+	}
+	if len(cleaned) != 1 || cleaned[0] != expiredEpisode.ID {
+		t.Errorf("storage cleaned for %v, want only %s", cleaned, expiredEpisode.ID)
+	}
+
+	count := func(model interface{}, query string, args ...interface{}) int64 {
+		var n int64
+		db.Unscoped().Model(model).Where(query, args...).Count(&n)
+		return n
+	}
+	if n := count(&models.Series{}, "id = ?", expired.ID); n != 0 {
+		t.Error("series past the restore window was not purged")
+	}
+	if n := count(&models.Episode{}, "id = ?", expiredEpisode.ID); n != 0 {
+		t.Error("episode of a purged series was not purged")
+	}
+	if n := count(&models.EpisodeCaption{}, "episode_id = ?", expiredEpisode.ID); n != 0 {
+		t.Error("captions of a purged episode were not purged")
+	}
+	if count(&models.Series{}, "id = ?", recent.ID) != 1 || count(&models.Episode{}, "id = ?", recentEpisode.ID) != 1 {
+		t.Error("series still within the restore window was purged")
+	}
+}
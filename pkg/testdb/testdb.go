@@ -0,0 +1,86 @@
+// Package testdb opens the Postgres database that DB-backed tests run
+// against. Tests using it are skipped unless TEST_DATABASE_URL is set.
+package testdb
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"streamshort/config"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// migrationLockID is the advisory lock key serializing test migrations
+const migrationLockID = 7463526
+
+var (
+	once       sync.Once
+	shared     *gorm.DB
+	openFailed error
+)
+
+// Open returns a transaction on the test database, migrated with every
+// model, that is rolled back when the test ends. Handlers' own transactions
+// run as savepoints inside it, so tests never see each other's rows.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	tx := Shared(t).Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// Shared returns the migrated test database itself rather than a
+// transaction, for tests of concurrent requests that need real transactions
+// on separate connections. Nothing is rolled back: such tests must delete the
+// rows they create.
+func Shared(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	once.Do(func() {
+		db, err := config.Open(dsn, logger.Default.LogMode(logger.Silent))
+		if err != nil {
+			openFailed = err
+			return
+		}
+		// go test runs packages in parallel, each migrating on first use, so
+		// the migration holds an advisory lock on a single connection
+		openFailed = db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+			for _, stmt := range []string{
+				"CREATE EXTENSION IF NOT EXISTS pgcrypto",
+				"CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"",
+			} {
+				if err := conn.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			for _, model := range config.MigratedModels() {
+				if err := conn.AutoMigrate(model); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		shared = db
+	})
+	if openFailed != nil {
+		t.Fatalf("open test database: %v", openFailed)
+	}
+	return shared
+}